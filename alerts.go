@@ -0,0 +1,205 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AlertRule represents a rule that notifies when too many logs are created in a window of time,
+// e.g. more than 10 errors tagged "payments" in 5 minutes
+// the alerts are sent to the notifier of the logger (see SetNotifier)
+type AlertRule struct {
+	Name      string        // the name of the rule, it is the title of its notifications
+	Level     LogLevel      // the minimum level of the logs counted
+	Tags      []string      // the logs counted have at least one of the tags, every log if empty
+	Threshold int           // the rule fires when the logs in the window are more than the threshold
+	Window    time.Duration // the window of time of the logs counted
+	// Scheduled evaluates the rule on the stored logs (of every process writing in the database)
+	// when the maintenance runs (see StartMaintenance), otherwise the rule counts the logs created
+	// by the logger and it is evaluated on every log
+	Scheduled bool
+}
+
+// alerts are the alert rules of a logger, shared by its copies
+type alerts struct {
+	mu    sync.Mutex
+	rules []*alertState
+}
+
+// alertState is an alert rule with the logs counted in its window
+type alertState struct {
+	rule  AlertRule
+	route route       // the level and the tags of the logs counted
+	times []time.Time // the times of the logs counted in the window, for the rules evaluated on write
+	fired time.Time   // the last time the rule fired
+}
+
+// AddAlert adds an alert rule to the logger, the rule sends a notification to the notifier
+// of the logger (see SetNotifier) when the logs with its level (or a higher one) and its tags
+// created in its window are more than its threshold, then it doesn't fire again for a window
+// the notification has the name of the rule as title and the number of logs counted
+// the copies and the children of the logger share the rules
+// Example:
+//
+//	log.SetNotifier(logger.WebhookNotifier("https://hooks.example.com/logs"), logger.Fatal)
+//	log.AddAlert(logger.AlertRule{
+//		Name:      "payments failing",
+//		Level:     logger.Error,
+//		Tags:      []string{"payments"},
+//		Threshold: 10,
+//		Window:    5 * time.Minute,
+//	})
+//
+// the scheduled rules are evaluated only while the maintenance runs, with an interval
+// not longer than their window (e.g. log.StartMaintenance(ctx, time.Minute))
+// this method returns an error if the rule has no name, if its window is not positive,
+// if its threshold is negative or if the logger already has a rule with the same name
+func (opts *Logger) AddAlert(rule AlertRule) error {
+	if rule.Name == "" {
+		return errors.New("[logger-pkg] the name of the alert rule can't be empty")
+	}
+	if rule.Window <= 0 {
+		return fmt.Errorf("[logger-pkg] the window of the alert rule %q must be positive", rule.Name)
+	}
+	if rule.Threshold < 0 {
+		return fmt.Errorf("[logger-pkg] the threshold of the alert rule %q can't be negative", rule.Name)
+	}
+
+	rule.Tags = slices.Clone(rule.Tags)
+	opts.mu.Lock()
+	if opts.alerts == nil {
+		opts.alerts = new(alerts)
+	}
+	a := opts.alerts
+	opts.mu.Unlock()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, s := range a.rules {
+		if s.rule.Name == rule.Name {
+			return fmt.Errorf("[logger-pkg] the alert rule %q already exists", rule.Name)
+		}
+	}
+	a.rules = append(a.rules, &alertState{rule: rule, route: route{level: rule.Level, tags: rule.Tags}})
+	return nil
+}
+
+// RemoveAlert removes the alert rule with the name passed, it does nothing if the rule doesn't exist
+func (opts *Logger) RemoveAlert(name string) {
+	opts.mu.RLock()
+	a := opts.alerts
+	opts.mu.RUnlock()
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rules = slices.DeleteFunc(a.rules, func(s *alertState) bool { return s.rule.Name == name })
+}
+
+// checkAlerts counts the log passed in the rules evaluated on write and fires the ones exceeding their threshold
+func (opts *Logger) checkAlerts(l *log) {
+	opts.mu.RLock()
+	a, n := opts.alerts, opts.notifications
+	opts.mu.RUnlock()
+	if a == nil {
+		return
+	}
+
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, s := range a.rules {
+		if s.rule.Scheduled || !s.route.match(l) {
+			continue
+		}
+
+		s.times = append(s.times, now)
+		from := now.Add(-s.rule.Window)
+		i := 0
+		for i < len(s.times) && s.times[i].Before(from) {
+			i++
+		}
+		s.times = s.times[i:]
+
+		if len(s.times) > s.rule.Threshold && now.Sub(s.fired) >= s.rule.Window {
+			s.fired = now
+			s.fire(n, len(s.times), l.entry())
+		}
+	}
+}
+
+// evaluateAlerts evaluates the scheduled alert rules on the logs stored in their windows,
+// it is run by the maintenance
+func (opts *Logger) evaluateAlerts() error {
+	opts.mu.RLock()
+	a, n := opts.alerts, opts.notifications
+	opts.mu.RUnlock()
+	if a == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var window time.Duration
+	for _, s := range a.rules {
+		if s.rule.Scheduled {
+			window = max(window, s.rule.Window)
+		}
+	}
+	if window == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	logs, err := opts.queryLogs(createdAfter(now.Add(-window)))
+	if err != nil {
+		return err
+	}
+
+	for _, s := range a.rules {
+		if !s.rule.Scheduled || now.Sub(s.fired) < s.rule.Window {
+			continue
+		}
+
+		count := 0
+		var last *log
+		from := now.Add(-s.rule.Window)
+		for _, l := range logs {
+			if time.Time(l.timestamp).Before(from) || !s.route.match(l) {
+				continue
+			}
+
+			count += l.occurrences()
+			if last == nil || time.Time(l.timestamp).After(time.Time(last.timestamp)) {
+				last = l
+			}
+		}
+
+		if count > s.rule.Threshold {
+			s.fired = now
+			s.fire(n, count, last.entry())
+		}
+	}
+	return nil
+}
+
+// fire sends the notification of the rule to the notifier passed, with the number of logs
+// counted and the last one, it does nothing if the logger has no notifier
+func (s *alertState) fire(n *notifications, count int, last Entry) {
+	if n == nil {
+		return
+	}
+
+	go n.notifier.Notify(Notification{
+		Level:  s.rule.Level,
+		Count:  count,
+		Window: s.rule.Window,
+		Entry:  last,
+		Rule:   s.rule.Name,
+	})
+}
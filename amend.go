@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// notesField is the field of the logs with the notes added by Append
+const notesField = "notes"
+
+// Append adds a note to the log with the id passed, the notes are kept in the "notes"
+// field of the log (a list of objects with the time and the text of the note)
+// it is useful to record the follow-up of an event, e.g. how an error was resolved
+// Example:
+//
+//	id, _ := log.Error("payment %s failed", payment)
+//	// ...
+//	log.Append(id, "refunded by the support team")
+//
+// the note is redacted like the messages of the logs
+// this method returns ErrNotSupported if the store is not a SQLite store
+func (opts *Logger) Append(id int64, note string) error {
+	s := opts.getStore()
+	store, ok := s.(*sqliteStore)
+	if !ok {
+		return unsupported(s)
+	}
+
+	opts.mu.RLock()
+	note = opts.redaction.redactString(note)
+	opts.mu.RUnlock()
+
+	entry := map[string]any{
+		"time": timestamp(time.Now().In(opts.getLocation())).rfc3339(),
+		"note": note,
+	}
+
+	return store.retry(context.Background(), func() error {
+		return appendNote(context.Background(), store, id, entry)
+	})
+}
+
+// AddTagsToLog adds the tags passed to the log with the id passed,
+// the tags the log already has are ignored
+// Example:
+//
+//	id, _ := log.Warn("slow query: %s", query)
+//	log.AddTagsToLog(id, "database", "needs-review")
+//
+// this method returns ErrNotSupported if the store is not a SQLite store
+func (opts *Logger) AddTagsToLog(id int64, tags ...string) error {
+	for _, tag := range tags {
+		if tag == "" {
+			return errors.New("[logger-pkg] the name of the tag can't be empty")
+		}
+	}
+
+	s := opts.getStore()
+	store, ok := s.(*sqliteStore)
+	if !ok {
+		return unsupported(s)
+	}
+
+	return store.retry(context.Background(), func() error {
+		return addTagsToLog(context.Background(), store, id, tags)
+	})
+}
+
+// appendNote adds the note passed to the notes field of the log with the id passed
+func appendNote(ctx context.Context, s *sqliteStore, id int64, note map[string]any) error {
+	db, err := getWritableDBConnection(s)
+	if err != nil {
+		return err
+	}
+	defer releaseDBConnection(s, db)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to amend the log: " + err.Error())
+	}
+
+	var stored string
+	err = tx.QueryRow("SELECT fields FROM logs WHERE id = ?;", id).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return fmt.Errorf("[logger-pkg] the log %d doesn't exist", id)
+	}
+
+	if err != nil {
+		tx.Rollback()
+		return errors.New("[logger-pkg] failed to amend the log: " + err.Error())
+	}
+
+	fields := unmarshalFields(s.encryption.open(stored))
+	if fields == nil {
+		fields = make(map[string]any, 1)
+	}
+	notes, _ := fields[notesField].([]any)
+	fields[notesField] = append(notes, note)
+
+	_, err = tx.Exec("UPDATE logs SET fields = ? WHERE id = ?;", s.encryption.seal(marshalFields(fields)), id)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("[logger-pkg] failed to amend the log: " + err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return errors.New("[logger-pkg] failed to amend the log: " + err.Error())
+	}
+
+	return nil
+}
+
+// addTagsToLog adds the tags passed to the log with the id passed
+func addTagsToLog(ctx context.Context, s *sqliteStore, id int64, tags []string) error {
+	db, err := getWritableDBConnection(s)
+	if err != nil {
+		return err
+	}
+	defer releaseDBConnection(s, db)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to amend the log: " + err.Error())
+	}
+
+	var exists int
+	err = tx.QueryRow("SELECT COUNT(*) FROM logs WHERE id = ?;", id).Scan(&exists)
+	if err == nil && exists == 0 {
+		tx.Rollback()
+		return fmt.Errorf("[logger-pkg] the log %d doesn't exist", id)
+	}
+
+	for _, tag := range tags {
+		if err != nil {
+			break
+		}
+		_, err = tx.Exec(insertTagQuery, tag)
+		if err == nil {
+			_, err = tx.Exec(insertLogTagQuery, id, tag)
+		}
+	}
+
+	if err != nil {
+		tx.Rollback()
+		return errors.New("[logger-pkg] failed to amend the log: " + err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return errors.New("[logger-pkg] failed to amend the log: " + err.Error())
+	}
+
+	return nil
+}
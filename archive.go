@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Archive moves the logs older than the duration passed from the database to a gzip-compressed
+// export file in the format passed (e.g. 20240102150405_archive.ndjson.gz in the folder of the logger)
+// the logs are exported and deleted in a single transaction: if the file can't be written
+// no log is deleted and if the logs can't be deleted the file is removed
+// Example:
+//
+//	path, err := log.Archive(90*24*time.Hour, logger.NDJSON)
+//
+// the JSON and NDJSON archives can be imported again with Import
+// this method returns the path of the archive, empty if no log is older than the duration
+// it returns ErrNotSupported if the store is not a SQLite store or if the rotation of the files
+// is enabled (see Rotate), the rotated files can be archived as they are
+func (opts *Logger) Archive(olderThan time.Duration, format ExportType) (string, error) {
+	s := opts.getStore()
+	store, ok := s.(*sqliteStore)
+	if !ok || store.rotation.policy != NoRotation {
+		return "", unsupported(s)
+	}
+
+	cfg := opts.Copy()
+	query, err := buildQuery(createdBefore(time.Now().Add(-olderThan)))
+	if err != nil {
+		return "", err
+	}
+
+	ext, write := cfg.exportFormat(format)
+	filePath := filepath.Join(cfg.folderPath, fmt.Sprintf("%s_archive%s.gz", time.Now().Format("20060102150405"), ext))
+
+	var archived int64
+	err = store.retry(context.Background(), func() error {
+		var err error
+		archived, err = archiveLogs(context.Background(), store, query, filePath, write, cfg.timeLayout)
+		return err
+	})
+	if err != nil || archived == 0 {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// archiveLogs writes the logs selected by the query passed in the gzip file passed
+// and deletes them in a single transaction, it returns the number of archived logs
+// the file is not created if no log is selected
+func archiveLogs(ctx context.Context, s *sqliteStore, query, filePath string, write func(io.Writer, []*log, string) error, layout string) (int64, error) {
+	db, err := getWritableDBConnection(s)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseDBConnection(s, db)
+
+	offset, err := getClockOffset(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.New("[logger-pkg] failed to archive the logs: " + err.Error())
+	}
+
+	s.logSQL(query)
+	logs, err := scanLogs(ctx, tx, s.encryption, offset, query)
+	if err != nil || len(logs) == 0 {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = writeArchive(filePath, logs, write, layout)
+	if err != nil {
+		tx.Rollback()
+		os.Remove(filePath)
+		return 0, errors.New("[logger-pkg] failed to write the archive: " + err.Error())
+	}
+
+	s.logSQL(deleteQuery(query))
+	archived, err := execDeleteLogs(ctx, tx, query)
+	if err == nil {
+		err = tx.Commit()
+	}
+
+	if err != nil {
+		tx.Rollback()
+		os.Remove(filePath)
+		return 0, errors.New("[logger-pkg] failed to archive the logs: " + err.Error())
+	}
+
+	return archived, nil
+}
+
+// writeArchive writes the logs passed in the gzip file passed with the write function of their format
+func writeArchive(filePath string, logs []*log, write func(io.Writer, []*log, string) error, layout string) error {
+	file, err := createExportFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	z := gzip.NewWriter(file)
+	err = write(z, logs, layout)
+	if err != nil {
+		return err
+	}
+
+	err = z.Close()
+	if err != nil {
+		return err
+	}
+	return file.Sync()
+}
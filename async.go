@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// productionQueueSize is the size of the async queue of the loggers created with NewProduction
+const productionQueueSize = 10000
+
+// asyncWriter writes the logs in the stores from a background goroutine,
+// it is shared by the copies of the logger
+type asyncWriter struct {
+	queue   chan func() // the writes of the logs waiting to be stored
+	mu      sync.Mutex  // protects pending and closed, the queue is closed while no log is being queued
+	done    *sync.Cond  // signalled when the queued writes are done
+	pending int         // the writes queued and not done yet
+	closed  bool        // if true the queue is closed and the logs are written synchronously
+}
+
+// newAsyncWriter creates an async writer with a queue of the size passed and starts its goroutine
+func newAsyncWriter(size int) *asyncWriter {
+	w := &asyncWriter{queue: make(chan func(), size)}
+	w.done = sync.NewCond(&w.mu)
+	go func() {
+		for write := range w.queue {
+			write()
+			w.mu.Lock()
+			w.pending--
+			if w.pending == 0 {
+				w.done.Broadcast()
+			}
+			w.mu.Unlock()
+		}
+	}()
+	return w
+}
+
+// enqueue queues the write passed, it returns false if the queue is full or closed
+func (w *asyncWriter) enqueue(write func()) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+
+	select {
+	case w.queue <- write:
+		w.pending++
+		return true
+	default:
+		return false
+	}
+}
+
+// flush waits until the queued writes are done
+func (w *asyncWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for w.pending > 0 {
+		w.done.Wait()
+	}
+}
+
+// close writes the queued logs and stops the goroutine of the writer
+func (w *asyncWriter) close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	w.flush()
+}
+
+// Async sets the size of the queue of the logs written in the store by a background goroutine,
+// so the logging methods don't wait for the database, useful for the services on a hot path
+// the logs created when the queue is full are dropped (counted as Dropped by ReadMetrics),
+// the Fatal logs are always written synchronously after the queued ones
+// the logs written asynchronously have no id: the hooks, the sinks and the methods returning it receive 0
+// if the size is 0 or negative the logs are written synchronously (default)
+// call Flush to wait for the queued logs, Close flushes them before closing the store
+// Example:
+//
+//	log.Async(10000)
+//	defer log.Close()
+func (opts *Logger) Async(size int) {
+	opts.mu.Lock()
+	old := opts.async
+	opts.async = nil
+	if size > 0 {
+		opts.async = newAsyncWriter(size)
+	}
+	opts.mu.Unlock()
+
+	if old != nil {
+		old.close()
+	}
+}
+
+// Flush waits until the logs queued by the Async mode are written in the store
+func (opts *Logger) Flush() {
+	if w := opts.getAsync(); w != nil {
+		w.flush()
+	}
+}
+
+// getAsync returns the async writer of the logger, nil if the logs are written synchronously
+func (opts *Logger) getAsync() *asyncWriter {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	return opts.async
+}
+
+// writeAsync queues the write of the log passed in the store, it returns false
+// if the log must be written synchronously (async disabled or Fatal log)
+// the log is dropped if the queue is full
+func (opts *Logger) writeAsync(l *log) bool {
+	w := opts.getAsync()
+	if w == nil {
+		return false
+	}
+
+	if l.level >= Fatal {
+		w.flush()
+		return false
+	}
+
+	store := opts.getStore()
+	queued := w.enqueue(func() {
+		start := time.Now()
+		_, err := writeTo(store, l)
+		observeWrite(l.level, time.Since(start), err)
+	})
+
+	if !queued {
+		metrics.Lock()
+		metrics.dropped++
+		metrics.Unlock()
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestAsyncFlushWhileEnqueuing(t *testing.T) {
+	l := New("async")
+	l.Folder(t.TempDir())
+	l.SetOutput(io.Discard)
+	l.Async(64)
+	defer l.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.Info("queued")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				l.Flush()
+			}
+		}()
+	}
+	wg.Wait()
+	l.Flush()
+
+	w := l.getAsync()
+	w.mu.Lock()
+	pending := w.pending
+	w.mu.Unlock()
+	if pending != 0 {
+		t.Fatalf("pending writes after Flush = %d, want 0", pending)
+	}
+}
+
+func TestAsyncCloseFlushesQueue(t *testing.T) {
+	w := newAsyncWriter(16)
+	var mu sync.Mutex
+	done := 0
+	for i := 0; i < 10; i++ {
+		w.enqueue(func() {
+			mu.Lock()
+			done++
+			mu.Unlock()
+		})
+	}
+	w.close()
+
+	if done != 10 {
+		t.Fatalf("writes done after close = %d, want 10", done)
+	}
+	if w.enqueue(func() {}) {
+		t.Fatal("enqueue after close returned true")
+	}
+}
@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultMaxAttachment is the default maximum size in bytes of the attachments (10 MB)
+const defaultMaxAttachment = 10 << 20
+
+// the statements of the attachments of the logs
+const (
+	insertAttachmentQuery = `INSERT OR REPLACE INTO log_attachments (log_id, name, data, size, time)
+SELECT ?, ?, ?, ?, ? WHERE EXISTS (SELECT 1 FROM logs WHERE id = ?);`
+	selectAttachmentsQuery = "SELECT id, log_id, name, data, time FROM log_attachments WHERE log_id = ? ORDER BY id;"
+)
+
+// Attachment is a file attached to a stored log with Attach (e.g. a request payload, a screenshot or a dump)
+type Attachment struct {
+	ID    int64     // the id of the attachment
+	LogID int64     // the id of the log of the attachment
+	Name  string    // the name of the attachment, unique for every log
+	Data  []byte    // the content of the attachment
+	Time  time.Time // when the attachment was saved
+}
+
+// Attach saves the data passed as an attachment of the log with the id passed, so the logs can keep
+// the payloads too large or too binary for their fields (request bodies, screenshots, dumps, ...)
+// the attachments are kept in a separate table of the SQLite store, encrypted like the messages
+// (see EncryptionKey), and they are deleted with their log
+// attaching data with a name the log already has replaces the previous attachment
+// Example:
+//
+//	id, _ := log.Error("invalid webhook payload")
+//	log.Attach(id, "payload.json", body)
+//
+// this method returns an error if the name is empty, if the data is larger than the maximum size
+// (10 MB by default, see MaxAttachmentSize) or if the log doesn't exist,
+// and ErrNotSupported if the store is not a SQLite store
+func (opts *Logger) Attach(logID int64, name string, data []byte) error {
+	if name == "" {
+		return errors.New("[logger-pkg] the name of the attachment can't be empty")
+	}
+
+	opts.mu.RLock()
+	limit := opts.maxAttachment
+	opts.mu.RUnlock()
+	if limit <= 0 {
+		limit = defaultMaxAttachment
+	}
+
+	if len(data) > limit {
+		return fmt.Errorf("[logger-pkg] the attachment %q is too large (%d bytes, maximum %d bytes)", name, len(data), limit)
+	}
+
+	s := opts.getStore()
+	store, ok := s.(*sqliteStore)
+	if !ok {
+		return unsupported(s)
+	}
+
+	now := timestamp(time.Now().In(opts.getLocation()))
+	return store.retry(context.Background(), func() error {
+		return insertAttachment(context.Background(), store, logID, name, data, now)
+	})
+}
+
+// MaxAttachmentSize sets the maximum size in bytes of the data saved with Attach,
+// the default size is 10 MB, if the size is 0 or negative the default size is used
+func (opts *Logger) MaxAttachmentSize(size int) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.maxAttachment = size
+}
+
+// GetAttachments returns the attachments of the log with the id passed (see Attach)
+// sorted from the first saved, it is empty if the log has no attachment
+// this method returns ErrNotSupported if the store is not a SQLite store or a snapshot
+func (opts *Logger) GetAttachments(logID int64) ([]Attachment, error) {
+	s := opts.getStore()
+	store, ok := sqliteStoreOf(s)
+	if !ok {
+		return nil, unsupported(s)
+	}
+
+	db, err := getDBConnection(store)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseDBConnection(store, db)
+
+	rows, err := db.QueryContext(context.Background(), selectAttachmentsQuery, logID)
+	if err != nil {
+		return nil, errors.New("[logger-pkg] failed to read the attachments: " + err.Error())
+	}
+	defer rows.Close()
+
+	attachments := make([]Attachment, 0)
+	for rows.Next() {
+		var a Attachment
+		var data []byte
+		var stored string
+		err = rows.Scan(&a.ID, &a.LogID, &a.Name, &data, &stored)
+		if err != nil {
+			return nil, errors.New("[logger-pkg] failed to read the attachments: " + err.Error())
+		}
+
+		a.Data = []byte(store.encryption.open(string(data)))
+		a.Time, _ = time.Parse(time.RFC3339, stored)
+		attachments = append(attachments, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.New("[logger-pkg] failed to read the attachments: " + err.Error())
+	}
+	return attachments, nil
+}
+
+// ExportAttachments sets the exports (see Export) to write the attachments of the exported logs
+// if the include parameter is true, otherwise they are not exported (default)
+// the attachments are written in a folder next to the export file, named as the file followed
+// by "_attachments", with a sub-folder for every log (e.g. 20240102150405_logs.json_attachments/42/payload.json)
+func (opts *Logger) ExportAttachments(include bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.exportAttachments = include
+}
+
+// writeAttachments writes the attachments of the logs passed in the folder of the export file passed
+func (opts *Logger) writeAttachments(exportPath string, logs []*log) error {
+	folder := exportPath + "_attachments"
+	for _, l := range logs {
+		attachments, err := opts.GetAttachments(l.id)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range attachments {
+			dir := filepath.Join(folder, fmt.Sprint(l.id))
+			err = os.MkdirAll(dir, 0755)
+			if err == nil {
+				err = os.WriteFile(filepath.Join(dir, attachmentFileName(a.Name)), a.Data, 0644)
+			}
+
+			if err != nil {
+				return errors.New("[logger-pkg] failed to export the attachments: " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// attachmentFileName returns the name passed usable as a file name, without the path separators
+func attachmentFileName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+	if name == "." || name == ".." {
+		return "_" + name
+	}
+	return name
+}
+
+// insertAttachment saves the attachment passed of the log with the id passed
+func insertAttachment(ctx context.Context, s *sqliteStore, logID int64, name string, data []byte, at timestamp) error {
+	db, err := getWritableDBConnection(s)
+	if err != nil {
+		return err
+	}
+	defer releaseDBConnection(s, db)
+
+	result, err := db.ExecContext(ctx, insertAttachmentQuery, logID, name, []byte(s.encryption.seal(string(data))), len(data), at.rfc3339(), logID)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to save the attachment: " + err.Error())
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("[logger-pkg] the log %d doesn't exist", logID)
+	}
+	return nil
+}
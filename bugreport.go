@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// BugReport returns a text block, ready to be pasted in a GitHub issue,
+// that contains the system information, the configuration of the logger
+// and the last n logs in the database
+// the report is sanitized before being returned: the home directory,
+// the user name and the host name are replaced with placeholders,
+// so the end users of a CLI app can share it without leaking personal information
+// Example:
+//
+//	report, err := log.BugReport(20)
+//	if err == nil {
+//		fmt.Println("Please attach the following report to the issue:")
+//		fmt.Println(report)
+//	}
+func (opts *Logger) BugReport(n int) (string, error) {
+	entries, err := opts.Tail(n)
+	if err != nil {
+		return "", err
+	}
+
+	cfg := opts.Copy()
+	var b strings.Builder
+	b.WriteString("### Bug report\n\n")
+
+	b.WriteString("**System**\n")
+	executable, _ := os.Executable()
+	b.WriteString(fmt.Sprintf("- OS/Arch: %s/%s\n", runtime.GOOS, runtime.GOARCH))
+	b.WriteString(fmt.Sprintf("- Go version: %s\n", runtime.Version()))
+	b.WriteString(fmt.Sprintf("- Executable: %s\n", executable))
+	b.WriteString(fmt.Sprintf("- Time: %s\n\n", time.Now().Format(time.RFC3339)))
+
+	b.WriteString("**Configuration**\n")
+	b.WriteString(fmt.Sprintf("- Folder: %s\n", cfg.folderPath))
+	b.WriteString(fmt.Sprintf("- Tags: %s\n", strings.Join(cfg.tags, ", ")))
+	b.WriteString(fmt.Sprintf("- Inline: %t\n", cfg.inline))
+	b.WriteString(fmt.Sprintf("- Show tags: %t\n", cfg.showTags))
+	b.WriteString(fmt.Sprintf("- Caller level: %d\n", cfg.showCaller))
+	b.WriteString(fmt.Sprintf("- Timestamp level: %d\n", cfg.showTimestamp))
+	b.WriteString(fmt.Sprintf("- WAL: %t\n\n", cfg.wal))
+
+	b.WriteString(fmt.Sprintf("**Last %d logs**\n", len(entries)))
+	b.WriteString("```text\n")
+	for _, e := range entries {
+		b.WriteString(fromEntry(e).String())
+		b.WriteString("\n")
+	}
+	b.WriteString("```\n")
+
+	return sanitize(b.String()), nil
+}
+
+// sanitize replaces in the text passed the personal information
+// of the machine (home directory, user name and host name) with placeholders
+func sanitize(text string) string {
+	if home, err := os.UserHomeDir(); err == nil && home != "" && home != "/" {
+		text = strings.ReplaceAll(text, home, "~")
+	}
+
+	if u, err := user.Current(); err == nil && len(u.Username) > 2 {
+		text = strings.ReplaceAll(text, u.Username, "<user>")
+	}
+
+	if host, err := os.Hostname(); err == nil && len(host) > 2 {
+		text = strings.ReplaceAll(text, host, "<host>")
+	}
+
+	return text
+}
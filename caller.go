@@ -2,8 +2,13 @@ package logger
 
 import (
 	"errors"
+	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
 )
 
 // ShowCallerLevel is an enum to define the level of caller information to be shown
@@ -16,18 +21,151 @@ const (
 	ShowCallerFunction                        // show the caller file, line and function main.go:10 - main.main
 )
 
+// packagePrefix is the prefix of the functions of this package in the stack frames
+const packagePrefix = "github.com/Tagliapietra96/logger/v2."
+
+// skipFrame reports if the function of a stack frame must be skipped looking for the caller,
+// the functions of this package, of the runtime, of the standard log package and of database/sql
+// (for the queries logged by WrapDriver) are skipped, so the caller is the same however deep
+// in the package the log is created
+func skipFrame(function string) bool {
+	return strings.HasPrefix(function, packagePrefix) ||
+		strings.HasPrefix(function, "runtime.") ||
+		strings.HasPrefix(function, "log.") ||
+		strings.HasPrefix(function, "database/sql.")
+}
+
 // getCaller appends the caller information to a log, such as the file, line and function
+// the caller is the first function in the stack outside of this package
 func getCaller(l *log) error {
 	// get the caller information by runtime
-	pc, file, line, ok := runtime.Caller(3)
-	if !ok {
-		return errors.New("[logger-pkg] failed to get the caller information")
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !skipFrame(frame.Function) {
+			l.callerFile = filepath.Base(frame.File)
+			l.callerPath = frame.File
+			l.callerLine = frame.Line
+			l.callerFunction = frame.Function
+			return nil
+		}
+
+		if !more {
+			break
+		}
 	}
 
-	l.callerFile = filepath.Base(file)
-	l.callerLine = line
+	return errors.New("[logger-pkg] failed to get the caller information")
+}
+
+// CallerPathMode is an enum to define how the caller of the logs is recorded
+type CallerPathMode int
+
+const (
+	BaseCallerPath   CallerPathMode = iota // the base name of the file and the full function: handlers.go - github.com/user/app/api.Serve
+	FullCallerPath                         // the full path of the file: /home/user/app/api/handlers.go
+	ModuleCallerPath                       // the paths relative to the module root: api/handlers.go - api.Serve
+)
+
+// CallerPath sets how the caller file and function of the new logs are recorded,
+// the base name of the file is ambiguous when many packages have a file with the same name
+// the mode can be one of the following:
+//   - BaseCallerPath: the base name of the file and the full function name (default)
+//   - FullCallerPath: the full path of the file
+//   - ModuleCallerPath: the path of the file relative to the root of its module
+//     and the function qualified by its package, without the module prefix
+//
+// the module of the caller is the one of its nearest go.mod file, or the main module
+// of the binary if the sources are not available (e.g. built with -trimpath)
+// the mode is applied when the logs are created, the stored logs are not changed
+// Example:
+//
+//	log.CallerPath(logger.ModuleCallerPath)
+//	log.Info("listening") // internal/api/server.go:42 - internal/api.(*Server).Start
+func (opts *Logger) CallerPath(mode CallerPathMode) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.callerPath = mode
+}
+
+// resolveCaller sets the caller file and function of the log passed based on the caller path mode of the logger
+func (opts *Logger) resolveCaller(l *log) {
+	opts.mu.RLock()
+	mode := opts.callerPath
+	opts.mu.RUnlock()
+	if mode == BaseCallerPath || l.callerPath == "" {
+		return
+	}
+
+	if mode == FullCallerPath {
+		l.callerFile = l.callerPath
+		return
+	}
+
+	root, module := moduleOf(l.callerPath)
+	if module == "" {
+		return
+	}
 
-	f := runtime.FuncForPC(pc)
-	l.callerFunction = f.Name()
-	return nil
+	if root != "" {
+		if rel, err := filepath.Rel(root, l.callerPath); err == nil {
+			l.callerFile = filepath.ToSlash(rel)
+		}
+	} else {
+		l.callerFile = strings.TrimPrefix(l.callerPath, module+"/")
+	}
+
+	switch {
+	case strings.HasPrefix(l.callerFunction, module+"/"):
+		l.callerFunction = strings.TrimPrefix(l.callerFunction, module+"/")
+	case strings.HasPrefix(l.callerFunction, module+"."):
+		l.callerFunction = path.Base(module) + strings.TrimPrefix(l.callerFunction, module)
+	}
+}
+
+// modules caches the module root and path of the folders of the caller files
+var modules sync.Map
+
+// moduleOf returns the root folder and the path of the module of the file passed,
+// the root is empty if the file path is already relative to the module (built with -trimpath)
+// the path is empty if the module can't be found
+func moduleOf(file string) (root, module string) {
+	dir := filepath.Dir(file)
+	if cached, ok := modules.Load(dir); ok {
+		m := cached.([2]string)
+		return m[0], m[1]
+	}
+
+	for d := dir; ; d = filepath.Dir(d) {
+		if data, err := os.ReadFile(filepath.Join(d, "go.mod")); err == nil {
+			root, module = d, modulePath(data)
+			break
+		}
+
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+
+	if module == "" {
+		if info, ok := debug.ReadBuildInfo(); ok && strings.HasPrefix(filepath.ToSlash(file), info.Main.Path+"/") {
+			root, module = "", info.Main.Path
+		}
+	}
+
+	modules.Store(dir, [2]string{root, module})
+	return root, module
+}
+
+// modulePath returns the path declared by the go.mod file passed
+func modulePath(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if name, ok := strings.CutPrefix(line, "module "); ok {
+			return strings.Trim(strings.TrimSpace(name), `"`)
+		}
+	}
+	return ""
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Tagliapietra96/logger/v2"
+)
+
+// runAssert exits with exitFailure if the database contains logs
+// with the level passed or a higher one since the time passed
+func runAssert(l *logger.Logger, args []string) int {
+	fs := flag.NewFlagSet("assert", flag.ExitOnError)
+	levelFlag := fs.String("level", "error", "the minimum level of the logs to look for")
+	sinceFlag := fs.String("since", "24h", "the start time, as a duration before now (1h) or a timestamp (2006-01-02 15:04:05)")
+	fs.Parse(args)
+
+	level, err := logger.ParseLevel(*levelFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "logger:", err)
+		return exitError
+	}
+
+	since, err := parseSince(*sinceFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "logger:", err)
+		return exitError
+	}
+
+	err = l.AssertNo(level, since)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	return exitOK
+}
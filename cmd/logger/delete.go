@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/queries"
+)
+
+// runDelete deletes the logs of the database matching the flags passed
+// the logs to delete are previewed and a confirmation is asked, unless the -yes flag is passed
+func runDelete(l *logger.Logger, args []string) int {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	levelFlag := fs.String("level", "trace", "the minimum level of the logs to delete")
+	sinceFlag := fs.String("since", "", "the start time, as a duration before now (1h) or a timestamp (2006-01-02 15:04:05)")
+	beforeFlag := fs.String("before", "", "the end time, as a duration before now (1h) or a timestamp (2006-01-02 15:04:05)")
+	tagFlag := fs.String("tag", "", "delete only the logs with the tag")
+	queryFlag := fs.String("q", "", "delete only the logs matching the filter expression (e.g. \"level>=warning AND tag:api\")")
+	yesFlag := fs.Bool("yes", false, "delete the logs without asking for a confirmation")
+	fs.Parse(args)
+
+	level, err := logger.ParseLevel(*levelFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "logger:", err)
+		return exitError
+	}
+
+	queryOptions := []logger.QueryOption{queries.Not(queries.LevelLessThan(level))}
+	if *sinceFlag != "" {
+		since, err := parseSince(*sinceFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "logger:", err)
+			return exitError
+		}
+		queryOptions = append(queryOptions, queries.TimestampGreaterThan(since))
+	}
+
+	if *beforeFlag != "" {
+		before, err := parseSince(*beforeFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "logger:", err)
+			return exitError
+		}
+		queryOptions = append(queryOptions, queries.TimestampLessThan(before))
+	}
+
+	if *tagFlag != "" {
+		queryOptions = append(queryOptions, queries.HasTags(*tagFlag))
+	}
+
+	if *queryFlag != "" {
+		filter, err := queries.Parse(*queryFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "logger:", err)
+			return exitError
+		}
+		queryOptions = append(queryOptions, filter)
+	}
+
+	return deleteConfirmed(l, *yesFlag, queryOptions...)
+}
+
+// runPrune deletes the logs of the database older than the age passed
+// the logs to delete are previewed and a confirmation is asked, unless the -yes flag is passed
+func runPrune(l *logger.Logger, args []string) int {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	olderThanFlag := fs.Duration("older-than", 30*24*time.Hour, "the age of the logs to delete")
+	yesFlag := fs.Bool("yes", false, "delete the logs without asking for a confirmation")
+	fs.Parse(args)
+
+	if *olderThanFlag <= 0 {
+		fmt.Fprintln(os.Stderr, "logger: the -older-than duration must be positive")
+		return exitError
+	}
+
+	return deleteConfirmed(l, *yesFlag, queries.TimestampLessThan(time.Now().Add(-*olderThanFlag)))
+}
+
+// deleteConfirmed previews the logs selected by the query options passed,
+// asks for a confirmation (unless yes is true) and deletes them
+func deleteConfirmed(l *logger.Logger, yes bool, queryOptions ...logger.QueryOption) int {
+	count, sample, err := l.PreviewDelete(queryOptions...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	if count == 0 {
+		fmt.Println("no logs to delete")
+		return exitOK
+	}
+
+	fmt.Printf("%d logs will be deleted:\n", count)
+	for _, e := range sample {
+		fmt.Printf("  %s %-7s %s\n", e.Time.Format("2006-01-02 15:04:05"), e.Level, e.Message)
+	}
+	if count > int64(len(sample)) {
+		fmt.Printf("  ... and %d more\n", count-int64(len(sample)))
+	}
+
+	if !yes && !confirm(os.Stdin, "delete them?") {
+		fmt.Println("no logs deleted")
+		return exitOK
+	}
+
+	deleted, err := l.DeleteLogs(queryOptions...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	fmt.Printf("%d logs deleted\n", deleted)
+	return exitOK
+}
+
+// confirm asks the question passed and reports if the answer read is yes
+func confirm(r io.Reader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/server"
+)
+
+// runForward ships the logs of the database to a central server (see server.Forwarder)
+// until it is interrupted, or once with -once
+func runForward(l *logger.Logger, args []string) int {
+	fs := flag.NewFlagSet("forward", flag.ExitOnError)
+	toFlag := fs.String("to", "", "the URL of the central server (required)")
+	stateFlag := fs.String("state", "", "the file where the id of the last log forwarded is saved")
+	batchFlag := fs.Int("batch", 500, "the number of logs sent by a request")
+	intervalFlag := fs.Duration("interval", 10*time.Second, "how often the new logs are forwarded")
+	onceFlag := fs.Bool("once", false, "forward the logs once and exit")
+	fs.Parse(args)
+
+	if *toFlag == "" {
+		fmt.Fprintln(os.Stderr, "logger: the -to flag is required")
+		return exitError
+	}
+
+	f := server.NewForwarder(l, *toFlag)
+	f.BatchSize(*batchFlag)
+	f.Interval(*intervalFlag)
+	if *stateFlag != "" {
+		if err := f.StateFile(*stateFlag); err != nil {
+			fmt.Fprintln(os.Stderr, "logger:", err)
+			return exitError
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *onceFlag {
+		n, err := f.Flush(ctx)
+		fmt.Printf("%d logs forwarded\n", n)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "logger:", err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	fmt.Fprintf(os.Stderr, "logger: forwarding the logs to %s\n", *toFlag)
+	f.Run(ctx)
+	return exitOK
+}
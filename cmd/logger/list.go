@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/queries"
+)
+
+// runList prints the logs of the database matching the flags passed
+// with the -exit-code flag the exit code tells the outcome of the query:
+// 0 if logs were found, 1 if no logs were found and 3 if the logs found include errors
+func runList(l *logger.Logger, args []string) int {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	levelFlag := fs.String("level", "trace", "the minimum level of the logs to print")
+	sinceFlag := fs.String("since", "", "the start time, as a duration before now (1h) or a timestamp (2006-01-02 15:04:05)")
+	tagFlag := fs.String("tag", "", "print only the logs with the tag")
+	queryFlag := fs.String("q", "", "print only the logs matching the filter expression (e.g. \"level>=warning AND tag:api\")")
+	limitFlag := fs.Int("limit", 0, "the maximum number of logs to print (0 for no limit)")
+	lastRunFlag := fs.Bool("last-run", false, "print only the logs of the last execution of the application")
+	groupFlag := fs.String("group", "", "section the logs by day, tag or run")
+	pagerFlag := fs.Bool("pager", true, "show the logs in a pager ($PAGER or less) when they don't fit in the terminal")
+	inlineFlag := fs.Bool("inline", true, "print the logs inline instead of in blocks")
+	exitCodeFlag := fs.Bool("exit-code", false, "exit with 1 if no logs match and 3 if the logs include errors")
+	savedFlag := fs.String("saved", "", "print the logs of the saved query with the name, the other flags add their filters")
+	saveFlag := fs.String("save", "", "save the query of the flags in the database with the name instead of printing the logs")
+	fs.Parse(args)
+
+	level, err := logger.ParseLevel(*levelFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "logger:", err)
+		return exitError
+	}
+
+	var queryOptions []logger.QueryOption
+	if *savedFlag != "" {
+		saved, err := savedQuery(l, *savedFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "logger:", err)
+			return exitError
+		}
+		// the saved query is the first option, so its order and its limit are kept
+		queryOptions = append(queryOptions, queries.CustomQuery(saved))
+	}
+
+	queryOptions = append(queryOptions, queries.Not(queries.LevelLessThan(level)))
+	if *sinceFlag != "" {
+		since, err := parseSince(*sinceFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "logger:", err)
+			return exitError
+		}
+		queryOptions = append(queryOptions, queries.TimestampGreaterThan(since))
+	}
+
+	if *tagFlag != "" {
+		queryOptions = append(queryOptions, queries.HasTags(*tagFlag))
+	}
+
+	if *queryFlag != "" {
+		filter, err := queries.Parse(*queryFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "logger:", err)
+			return exitError
+		}
+		queryOptions = append(queryOptions, filter)
+	}
+
+	if *lastRunFlag {
+		queryOptions = append(queryOptions, queries.LastRun())
+	}
+
+	if *limitFlag > 0 {
+		queryOptions = append(queryOptions, queries.AddLimit(*limitFlag))
+	}
+
+	if *saveFlag != "" {
+		if err := l.SaveQuery(*saveFlag, queryOptions...); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		fmt.Printf("saved query %q\n", *saveFlag)
+		return exitOK
+	}
+
+	group, err := parseGroup(*groupFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "logger:", err)
+		return exitError
+	}
+
+	l.Group(group)
+	l.Pager(*pagerFlag)
+	l.Inline(*inlineFlag)
+	l.ShowTags(true)
+	res, err := l.PrintLogsResult(queryOptions...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	if *exitCodeFlag {
+		return int(res)
+	}
+
+	return exitOK
+}
+
+// savedQuery returns the SQL of the query saved in the database with the name passed
+func savedQuery(l *logger.Logger, name string) (string, error) {
+	saved, err := l.SavedQueries()
+	if err != nil {
+		return "", err
+	}
+
+	for _, q := range saved {
+		if q.Name == name {
+			return q.Query, nil
+		}
+	}
+	return "", fmt.Errorf("the saved query %q doesn't exist", name)
+}
+
+// parseGroup returns the grouping with the name passed (day, tag or run), an empty name means no grouping
+func parseGroup(s string) (logger.GroupBy, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return logger.NoGroup, nil
+	case "day":
+		return logger.GroupByDay, nil
+	case "tag":
+		return logger.GroupByTag, nil
+	case "run":
+		return logger.GroupByRun, nil
+	default:
+		return logger.NoGroup, fmt.Errorf("invalid group %q, use day, tag or run", s)
+	}
+}
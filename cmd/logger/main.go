@@ -0,0 +1,107 @@
+// Command logger inspects the logs database created by the logger package
+// from the command line
+//
+// Usage:
+//
+//	logger [-folder path] <command> [flags]
+//
+// The commands are:
+//
+//	assert   fails if the database contains logs with a level or a higher one since a time
+//	delete   deletes the logs of the database matching the flags, after a confirmation
+//	forward  ships the logs of the database to a central server
+//	list     prints the logs of the database
+//	prune    deletes the logs of the database older than an age, after a confirmation
+//	serve    serves the logs of the database over HTTP as a JSON API
+//
+// Run "logger <command> -h" for the flags of a command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Tagliapietra96/logger/v2"
+)
+
+// exit codes shared by the commands
+const (
+	exitOK      = 0 // the command succeeded
+	exitFailure = 1 // the command ran but its check failed
+	exitError   = 2 // the command could not run
+)
+
+// command represents a subcommand of the CLI
+type command struct {
+	usage string                                    // the short description of the command
+	run   func(l *logger.Logger, args []string) int // runs the command and returns the exit code
+}
+
+var commands = map[string]command{
+	"assert":  {"fails if the database contains logs with a level or a higher one since a time", runAssert},
+	"delete":  {"deletes the logs of the database matching the flags, after a confirmation", runDelete},
+	"forward": {"ships the logs of the database to a central server", runForward},
+	"list":    {"prints the logs of the database", runList},
+	"prune":   {"deletes the logs of the database older than an age, after a confirmation", runPrune},
+	"serve":   {"serves the logs of the database over HTTP as a JSON API", runServe},
+}
+
+func main() {
+	folder := flag.String("folder", ".", "the folder of the logs database")
+	db := flag.String("db", "", "the path of the logs database file (overrides -folder)")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(exitError)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "logger: unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(exitError)
+	}
+
+	l := logger.New()
+	l.Folder(*folder)
+	if *db != "" {
+		l.DatabasePath(*db)
+	}
+	os.Exit(cmd.run(l, flag.Args()[1:]))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: logger [-folder path] <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nflags:")
+	flag.PrintDefaults()
+}
+
+// parseSince returns the time represented by the string passed
+// it can be a duration before now (e.g. 24h) or a timestamp (2006-01-02 15:04:05 or 2006-01-02)
+func parseSince(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q, use a duration (24h) or a timestamp (2006-01-02 15:04:05)", s)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/server"
+)
+
+// runServe serves the JSON API of the database (see the server package) until it fails
+func runServe(l *logger.Logger, args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addrFlag := fs.String("addr", "localhost:8080", "the address to listen on")
+	readOnlyFlag := fs.Bool("read-only", true, "reject the requests deleting or sending logs")
+	ingestFlag := fs.Bool("ingest", false, "store the logs sent by the forwarders (requires -read-only=false)")
+	fs.Parse(args)
+
+	s := server.New(l)
+	s.ReadOnly(*readOnlyFlag)
+	s.AllowDelete(!*readOnlyFlag)
+	s.Ingest(*ingestFlag)
+
+	fmt.Fprintf(os.Stderr, "logger: serving the logs on http://%s\n", *addrFlag)
+	err := http.ListenAndServe(*addrFlag, s)
+	fmt.Fprintln(os.Stderr, "logger:", err)
+	return exitError
+}
@@ -0,0 +1,496 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// LoadConfig applies the configuration file passed to the logger, in TOML (.toml)
+// or YAML (.yaml, .yml) format, the options missing from the file keep their current values
+// The file supports the following options:
+//
+//	level = "debug"          # Level: trace, debug, info, notice, warning, error, fatal
+//	store_level = "warning"  # StoreLevel
+//	print_level = "debug"    # PrintLevel, "none" disables it
+//	folder = "/var/log/app"  # Folder
+//	database = "app.db"      # DatabasePath
+//	format = "styled"        # Format: styled, plain, json, logfmt
+//	inline = true            # Inline
+//	show_tags = true         # ShowTags
+//	caller = "line"          # Caller: hide, file, line, function
+//	timestamp = "datetime"   # Timestamp: hide, date, datetime, full, relative
+//	time_layout = "15:04:05" # TimestampFormat
+//	utc = true               # UTC
+//	retention = "720h"       # Retention, the older logs are pruned when the file is loaded
+//
+//	[theme]                  # SetTheme, the colors are lipgloss colors ("#0057B8", "12")
+//	info = "#0057B8"         # trace, debug, info, notice, warning, error, fatal, border, muted, light_muted
+//
+//	[notifier]               # SetNotifier and NotifyWindow
+//	webhook = "https://hooks.example.com/logs" # or desktop = true
+//	level = "error"
+//	window = "5m"
+//	threshold = 3
+//
+//	[sinks.slack]            # AddSink, one section for every sink
+//	type = "webhook"         # console, webhook (url) or store (folder)
+//	url = "https://hooks.slack.com/services/..."
+//	level = "error"
+//	tags = ["payments"]
+//
+// the YAML files have the same keys, with the sections as nested maps (notifier:, sinks: slack:)
+// only the scalar values and the inline lists ([a, b]) are supported
+// the sinks of the file replace the ones of the previous file, the sinks added with AddSink are kept
+// this method returns an error if it fails to read the file or if an option is not valid,
+// in which case no option is applied
+func (opts *Logger) LoadConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to read the config file: " + err.Error())
+	}
+
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		values, err = parseTOML(data)
+	case ".yaml", ".yml":
+		values, err = parseYAML(data)
+	default:
+		return fmt.Errorf("[logger-pkg] unsupported config file %q, use a .toml, .yaml or .yml file", path)
+	}
+	if err != nil {
+		return errors.New("[logger-pkg] failed to parse the config file: " + err.Error())
+	}
+
+	apply, err := configOptions(values)
+	if err != nil {
+		return errors.New("[logger-pkg] invalid config file: " + err.Error())
+	}
+
+	for _, fn := range apply {
+		fn(opts)
+	}
+
+	if _, ok := values["retention"]; ok {
+		if _, err := opts.Prune(); err != nil && !errors.Is(err, ErrNoStore) {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchConfig loads the configuration file passed (see LoadConfig) and reloads it
+// every time it changes, until the context is done, so a long-running service can change
+// its level or its sinks without a restart
+// the file is checked every interval (every 5 seconds if the interval is not positive),
+// the reload errors are logged as Error logs tagged "config", the previous options are kept
+// this method returns an error if it fails to load the file the first time
+// Example:
+//
+//	err := log.WatchConfig(ctx, "/etc/my-app/logger.toml", 0)
+func (opts *Logger) WatchConfig(ctx context.Context, path string, interval time.Duration) error {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	if err := opts.LoadConfig(path); err != nil {
+		return err
+	}
+
+	last, _ := os.Stat(path)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil || (last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size()) {
+				continue
+			}
+			last = info
+
+			if err := opts.LoadConfig(path); err != nil {
+				if l, logErr := newLog(Error, append(opts.getTags(), "config"), err.Error()); logErr == nil {
+					opts.writeLog(l)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// configOptions returns the functions applying the options of the config values passed
+// it returns an error if a key is unknown or a value is not valid
+func configOptions(values map[string]string) ([]func(*Logger), error) {
+	var apply []func(*Logger)
+	sinks := make(map[string]map[string]string)
+	theme := Theme{}
+	themeSet := false
+	notifier := make(map[string]string)
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		var err error
+		switch key {
+		case "level", "store_level":
+			var level LogLevel
+			level, err = ParseLevel(value)
+			if key == "level" {
+				apply = append(apply, func(l *Logger) { l.Level(level) })
+			} else {
+				apply = append(apply, func(l *Logger) { l.StoreLevel(level) })
+			}
+		case "print_level":
+			level := Fatal + 1
+			if value != "none" {
+				level, err = ParseLevel(value)
+			}
+			apply = append(apply, func(l *Logger) { l.PrintLevel(level) })
+		case "folder":
+			apply = append(apply, func(l *Logger) { l.Folder(value) })
+		case "database":
+			apply = append(apply, func(l *Logger) { l.DatabasePath(value) })
+		case "format":
+			var format ConsoleFormat
+			format, err = parseChoice(value, map[string]ConsoleFormat{"styled": StyledFormat, "plain": PlainFormat, "json": JSONFormat, "logfmt": LogfmtFormat})
+			apply = append(apply, func(l *Logger) { l.Format(format) })
+		case "inline", "show_tags", "utc":
+			var enabled bool
+			enabled, err = strconv.ParseBool(value)
+			switch key {
+			case "inline":
+				apply = append(apply, func(l *Logger) { l.Inline(enabled) })
+			case "show_tags":
+				apply = append(apply, func(l *Logger) { l.ShowTags(enabled) })
+			default:
+				apply = append(apply, func(l *Logger) { l.UTC(enabled) })
+			}
+		case "caller":
+			var level ShowCallerLevel
+			level, err = parseChoice(value, map[string]ShowCallerLevel{"hide": HideCaller, "file": ShowCallerFile, "line": ShowCallerLine, "function": ShowCallerFunction})
+			apply = append(apply, func(l *Logger) { l.Caller(level) })
+		case "timestamp":
+			var level ShowTimestampLevel
+			level, err = parseChoice(value, map[string]ShowTimestampLevel{"hide": HideTimestamp, "date": ShowDate, "datetime": ShowDateTime, "full": ShowFullTimestamp, "relative": ShowRelativeTime})
+			apply = append(apply, func(l *Logger) { l.Timestamp(level) })
+		case "time_layout":
+			apply = append(apply, func(l *Logger) { l.TimestampFormat(value) })
+		case "retention":
+			var d time.Duration
+			d, err = time.ParseDuration(value)
+			apply = append(apply, func(l *Logger) { l.Retention(d) })
+		default:
+			section, name, _ := strings.Cut(key, ".")
+			switch section {
+			case "theme":
+				themeSet = true
+				err = setThemeColor(&theme, name, value)
+			case "notifier":
+				notifier[name] = value
+			case "sinks":
+				sink, option, ok := strings.Cut(name, ".")
+				if !ok {
+					err = errors.New("a sink must be a section")
+					break
+				}
+				if sinks[sink] == nil {
+					sinks[sink] = make(map[string]string)
+				}
+				sinks[sink][option] = value
+			default:
+				err = errors.New("unknown option")
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", key, err.Error())
+		}
+	}
+
+	if themeSet {
+		apply = append(apply, func(l *Logger) { l.SetTheme(theme) })
+	}
+
+	if len(notifier) > 0 {
+		fn, err := configNotifier(notifier)
+		if err != nil {
+			return nil, fmt.Errorf("notifier: %s", err.Error())
+		}
+		apply = append(apply, fn)
+	}
+
+	routes := make([]route, 0, len(sinks))
+	names := make([]string, 0, len(sinks))
+	for name := range sinks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		r, err := configSink(sinks[name])
+		if err != nil {
+			return nil, fmt.Errorf("sinks.%s: %s", name, err.Error())
+		}
+		routes = append(routes, r)
+	}
+	apply = append(apply, func(l *Logger) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.sinks = slices.DeleteFunc(l.sinks, func(r route) bool { return r.config })
+		l.sinks = append(l.sinks, routes...)
+	})
+
+	return apply, nil
+}
+
+// configNotifier returns the function setting the notifier of the options passed
+func configNotifier(options map[string]string) (func(*Logger), error) {
+	var notifier Notifier
+	level := Error
+	window, threshold := defaultNotifyWindow, defaultNotifyThreshold
+	for name, value := range options {
+		var err error
+		switch name {
+		case "webhook":
+			notifier = WebhookNotifier(value)
+		case "desktop":
+			var enabled bool
+			enabled, err = strconv.ParseBool(value)
+			if enabled && notifier == nil {
+				notifier = DesktopNotifier()
+			}
+		case "level":
+			level, err = ParseLevel(value)
+		case "window":
+			window, err = time.ParseDuration(value)
+		case "threshold":
+			threshold, err = strconv.Atoi(value)
+		default:
+			err = errors.New("unknown option")
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+	}
+
+	// the webhook has priority over the desktop notifications
+	if url, ok := options["webhook"]; ok {
+		notifier = WebhookNotifier(url)
+	}
+
+	return func(l *Logger) {
+		l.SetNotifier(notifier, level)
+		l.NotifyWindow(window, threshold)
+	}, nil
+}
+
+// configSink returns the route of the sink with the options passed
+func configSink(options map[string]string) (route, error) {
+	r := route{level: Trace, config: true}
+	for name, value := range options {
+		var err error
+		switch name {
+		case "type", "url", "folder":
+		case "level":
+			r.level, err = ParseLevel(value)
+		case "tags":
+			r.tags = parseList(value)
+		default:
+			err = errors.New("unknown option")
+		}
+
+		if err != nil {
+			return r, fmt.Errorf("%s: %s", name, err.Error())
+		}
+	}
+
+	switch options["type"] {
+	case "console":
+		r.sink = ConsoleSink()
+	case "webhook":
+		if options["url"] == "" {
+			return r, errors.New("the webhook sinks require the url")
+		}
+		r.sink = NotifierSink(WebhookNotifier(options["url"]))
+	case "store":
+		if options["folder"] == "" {
+			return r, errors.New("the store sinks require the folder")
+		}
+		r.sink = StoreSink(NewSQLiteStore(options["folder"]))
+	default:
+		return r, fmt.Errorf("invalid type %q, use console, webhook or store", options["type"])
+	}
+	return r, nil
+}
+
+// setThemeColor sets the color with the name passed of the theme
+func setThemeColor(theme *Theme, name, value string) error {
+	colors := map[string]*lipgloss.TerminalColor{
+		"trace": &theme.Trace, "debug": &theme.Debug, "info": &theme.Info, "notice": &theme.Notice,
+		"warning": &theme.Warning, "error": &theme.Error,
+		"fatal": &theme.Fatal, "border": &theme.Border, "muted": &theme.Muted, "light_muted": &theme.LightMuted,
+	}
+
+	color, ok := colors[name]
+	if !ok {
+		return errors.New("unknown color")
+	}
+	*color = lipgloss.Color(value)
+	return nil
+}
+
+// parseChoice returns the value of the choice with the name passed
+func parseChoice[T any](s string, choices map[string]T) (T, error) {
+	v, ok := choices[strings.ToLower(s)]
+	if !ok {
+		names := make([]string, 0, len(choices))
+		for name := range choices {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return v, fmt.Errorf("invalid value %q, use one of %s", s, strings.Join(names, ", "))
+	}
+	return v, nil
+}
+
+// parseList returns the items of an inline list ([a, "b"]) or of a comma separated list
+func parseList(s string) []string {
+	s = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "["), "]")
+	items := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = unquote(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseTOML returns the values of the TOML document passed by dotted key (section.key),
+// only the sections, the scalar values and the inline lists are supported
+func parseTOML(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid section %q", n, line)
+			}
+			section = strings.TrimSpace(line[1:len(line)-1]) + "."
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		values[section+strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+	}
+	return values, scanner.Err()
+}
+
+// parseYAML returns the values of the YAML document passed by dotted key (section.key),
+// only the nested maps, the scalar values and the inline lists are supported
+func parseYAML(data []byte) (map[string]string, error) {
+	type level struct {
+		indent int
+		key    string
+	}
+
+	values := make(map[string]string)
+	var parents []level
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		raw := stripComment(scanner.Text())
+		line := strings.TrimSpace(raw)
+		if line == "" || line == "---" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "- ") {
+			return nil, fmt.Errorf("line %d: the block lists are not supported, use an inline list [a, b]", n)
+		}
+
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", n)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		path := make([]string, 0, len(parents)+1)
+		for _, p := range parents {
+			path = append(path, p.key)
+		}
+		path = append(path, key)
+
+		if value == "" {
+			parents = append(parents, level{indent: indent, key: key})
+			continue
+		}
+		values[strings.Join(path, ".")] = unquote(value)
+	}
+	return values, scanner.Err()
+}
+
+// stripComment removes the comment (# ...) outside of the quotes from the line passed
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote removes the quotes around the value passed, if any
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if v, err := strconv.Unquote(s); err == nil {
+			return v
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
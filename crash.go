@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// stackField is the field of the Fatal logs with the stack trace of the goroutine that created them
+const stackField = "stack"
+
+// CrashReport writes in the path passed a zip file to attach to the bug reports, with:
+//   - logs.json: the logs created in the duration passed before now (JSON export format)
+//   - system.txt: the operating system, the architecture, the Go version, the process and the memory
+//   - build.txt: the build info of the binary (module, dependencies and build settings)
+//   - stack.txt: the message and the stack trace of the last Fatal log of the duration passed, if any
+//   - goroutines.txt: the goroutine dump of the last Fatal log, if it was saved (see GoroutineDump)
+//   - heap.pprof, cpu.pprof: the profiles written by the last Fatal log, if they exist (see FatalProfiles)
+//
+// Example:
+//
+//	err := log.CrashReport("crash_report.zip", 24*time.Hour)
+//
+// this method returns an error if it fails to read the logs or to write the zip file
+func (opts *Logger) CrashReport(path string, since time.Duration) error {
+	from := time.Now().Add(-since)
+	logs, err := opts.queryLogs(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf(" WHERE %s >= '%s'", instantColumn, from.UTC().Format("2006-01-02 15:04:05")))
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := createExportFile(path)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to create the crash report: " + err.Error())
+	}
+	defer file.Close()
+
+	layout := opts.Copy().timeLayout
+	z := zip.NewWriter(file)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"logs.json", logsJSON(logs, layout)},
+		{"system.txt", systemInfo()},
+		{"build.txt", buildInfo()},
+	}
+
+	if fatal := lastFatal(logs); fatal != nil {
+		stack, _ := fatal.fields[stackField].(string)
+		files = append(files, struct {
+			name    string
+			content string
+		}{"stack.txt", fmt.Sprintf("%s %s\n\n%s", fatal.timestamp.format(layout), fatal.message, stack)})
+
+		if dump, err := opts.Goroutines(fatal.id); err == nil && dump != "" {
+			files = append(files, struct {
+				name    string
+				content string
+			}{"goroutines.txt", dump})
+		}
+
+		for _, profile := range [][2]string{{heapProfileField, "heap.pprof"}, {cpuProfileField, "cpu.pprof"}} {
+			path, _ := fatal.fields[profile[0]].(string)
+			if data, err := os.ReadFile(path); path != "" && err == nil {
+				files = append(files, struct {
+					name    string
+					content string
+				}{profile[1], string(data)})
+			}
+		}
+	}
+
+	for _, f := range files {
+		w, err := z.Create(f.name)
+		if err == nil {
+			_, err = io.WriteString(w, f.content)
+		}
+		if err != nil {
+			z.Close()
+			return errors.New("[logger-pkg] failed to write the crash report: " + err.Error())
+		}
+	}
+
+	err = z.Close()
+	if err != nil {
+		return errors.New("[logger-pkg] failed to write the crash report: " + err.Error())
+	}
+	return nil
+}
+
+// logsJSON returns the logs passed as a JSON array of the export format
+func logsJSON(logs []*log, layout string) string {
+	if len(logs) == 0 {
+		return "[]"
+	}
+
+	items := make([]string, 0, len(logs))
+	for _, l := range logs {
+		items = append(items, l.toJSON(layout))
+	}
+	return "[\n" + strings.Join(items, ",\n") + "\n]"
+}
+
+// lastFatal returns the most recent Fatal log of the logs passed or nil if there is none
+func lastFatal(logs []*log) *log {
+	var last *log
+	for _, l := range logs {
+		if l.level == Fatal && (last == nil || time.Time(l.timestamp).After(time.Time(last.timestamp))) {
+			last = l
+		}
+	}
+	return last
+}
+
+// systemInfo returns the information of the system and of the process for the crash report
+func systemInfo() string {
+	hostname, _ := os.Hostname()
+	executable, _ := os.Executable()
+	wd, _ := os.Getwd()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "os: %s\n", runtime.GOOS)
+	fmt.Fprintf(&b, "arch: %s\n", runtime.GOARCH)
+	fmt.Fprintf(&b, "go: %s\n", runtime.Version())
+	fmt.Fprintf(&b, "cpus: %d\n", runtime.NumCPU())
+	fmt.Fprintf(&b, "goroutines: %d\n", runtime.NumGoroutine())
+	fmt.Fprintf(&b, "hostname: %s\n", hostname)
+	fmt.Fprintf(&b, "pid: %d\n", os.Getpid())
+	fmt.Fprintf(&b, "executable: %s\n", executable)
+	fmt.Fprintf(&b, "working directory: %s\n", wd)
+	fmt.Fprintf(&b, "memory allocated: %d bytes\n", mem.Alloc)
+	fmt.Fprintf(&b, "memory from the system: %d bytes\n", mem.Sys)
+	return b.String()
+}
+
+// buildInfo returns the build info of the binary for the crash report
+func buildInfo() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "build info not available\n"
+	}
+	return info.String()
+}
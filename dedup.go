@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tagliapietra96/tui"
+	"github.com/Tagliapietra96/tui/opts"
+)
+
+// the statements of the deduplication of the logs, the duplicates are looked up with
+// logs_dedup_index, so the window is compared with a bound in the format of datetime (UTC)
+const (
+	findDuplicateQuery = `SELECT id FROM logs
+WHERE level = ? AND caller_file = ? AND caller_line = ? AND caller_function = ? AND message = ? AND correlation = ? AND datetime(timestamp) >= ?
+ORDER BY id DESC LIMIT 1;`
+	incrementCountQuery = "UPDATE logs SET count = count + ? WHERE id = ?;"
+)
+
+// occurrences returns the number of occurrences of the log, at least 1
+func (l *log) occurrences() int {
+	if l.count < 1 {
+		return 1
+	}
+	return l.count
+}
+
+// getOccurrences returns the "×N" suffix of the messages of the logs with more than one occurrence
+func (l *log) getOccurrences(th Theme) string {
+	if l.count <= 1 {
+		return ""
+	}
+	return " " + tui.Render(fmt.Sprintf("×%d", l.count), opts.Bold, th.muted())
+}
+
+// mergeDuplicate adds the occurrences of the log passed to the last identical log (same level,
+// caller and message) created in the window before it, using the transaction passed
+// it returns the id of the duplicated log or 0 if there is no duplicate
+func mergeDuplicate(tx *sql.Tx, l *log, window time.Duration) (int64, error) {
+	since := time.Time(l.timestamp).Add(-window)
+
+	var id int64
+	err := tx.QueryRow(findDuplicateQuery,
+		int(l.level), l.callerFile, l.callerLine, l.callerFunction, l.message, l.correlation, since.UTC().Format("2006-01-02 15:04:05"),
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(incrementCountQuery, l.occurrences(), id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
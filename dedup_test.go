@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindDuplicateUsesIndex(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSchema(db, 0, encryption{}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query("EXPLAIN QUERY PLAN "+findDuplicateQuery, 0, "", 0, "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			t.Fatal(err)
+		}
+		plan = append(plan, detail)
+	}
+	if !strings.Contains(strings.Join(plan, "\n"), "logs_dedup_index") {
+		t.Errorf("the plan of findDuplicateQuery is %q, want the search of logs_dedup_index", plan)
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	l := newTestLogger(t)
+	l.Location(time.FixedZone("UTC-7", -7*60*60))
+	l.Dedup(time.Minute)
+	for i := 0; i < 3; i++ {
+		if _, err := l.Warn("connection refused"); err != nil {
+			t.Fatalf("Warn() = %v", err)
+		}
+	}
+
+	entries, err := l.GetLogs()
+	if err != nil {
+		t.Fatalf("GetLogs() = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Count != 3 {
+		t.Errorf("GetLogs() = %v, want one log with the count 3", entries)
+	}
+}
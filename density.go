@@ -0,0 +1,14 @@
+package logger
+
+// DensityLevel is an enum to define how much space the logs take when printed in block mode
+// the level can be:
+//   - Comfortable: every log is a card with the title and the message separated by a line (default)
+//   - Compact: every log is a card with the title in a single row and no blank lines
+//   - Dense: every log is a single title row followed by the message, marked with the color of the level
+type DensityLevel int
+
+const (
+	Comfortable DensityLevel = iota // every log is a card with the title and the message separated by a line
+	Compact                         // every log is a card with the title in a single row and no blank lines
+	Dense                           // every log is a title row followed by the message, without the card
+)
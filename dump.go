@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tagliapietra96/tui"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxDumpSize is the maximum size in bytes of the JSON of a dumped value,
+// the bigger values are truncated
+const maxDumpSize = 64 << 10
+
+// the keys of the fields of a dumped value
+const (
+	dumpField          = "dump"           // the value marshaled as JSON
+	dumpTruncatedField = "dump_truncated" // true if the JSON of the value exceeded maxDumpSize
+	dumpErrorField     = "dump_error"     // the reason the value couldn't be marshaled
+)
+
+// Dump creates a log in the database with the level passed, the label as message
+// and the value passed marshaled as JSON in the dump field of the log,
+// so maps and structs can be logged without formatting them in the message
+// in block mode the value is printed indented and syntax highlighted
+// Example:
+//
+//	log.Dump(logger.Debug, "request payload", payload)
+//
+// the value is guarded against the values that can't be logged:
+//   - the values with cycles or that can't be marshaled (e.g. channels and functions)
+//     are replaced by the reason in the dump_error field
+//   - the values bigger than 64KB are truncated and the dump_truncated field is set
+//
+// if the level is not valid or it fails to create the log it will return an error
+func (opts *Logger) Dump(level LogLevel, label string, v any) error {
+	if !level.valid() {
+		return fmt.Errorf("[logger-pkg] invalid log level %d", level)
+	}
+
+	l, err := newLog(level, opts.getTags(), label)
+	if err != nil {
+		return err
+	}
+
+	l.fields = dumpFields(v)
+	return opts.writeLog(l)
+}
+
+// dumpFields returns the fields of the dumped value passed
+func dumpFields(v any) map[string]any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return map[string]any{
+			dumpField:      nil,
+			dumpErrorField: fmt.Sprintf("%T: %s", v, err.Error()),
+		}
+	}
+
+	if len(b) > maxDumpSize {
+		return map[string]any{
+			dumpField:          strings.ToValidUTF8(string(b[:maxDumpSize]), ""),
+			dumpTruncatedField: true,
+		}
+	}
+
+	var value any
+	if err := decodeJSON(b, &value); err != nil {
+		return map[string]any{
+			dumpField:      string(b),
+			dumpErrorField: err.Error(),
+		}
+	}
+
+	return map[string]any{dumpField: value}
+}
+
+// blockFields returns the fields of a log printed in block mode
+// the dumped value is indented and syntax highlighted, the other fields
+// are printed as a list of key=value pairs
+func blockFields(fields map[string]any, th Theme) string {
+	dump, ok := fields[dumpField]
+	if !ok {
+		return tui.Render(fieldsString(fields), th.muted())
+	}
+
+	others := make(map[string]any, len(fields))
+	for k, v := range fields {
+		if k != dumpField {
+			others[k] = v
+		}
+	}
+
+	var result string
+	if s, ok := dump.(string); ok {
+		result = s
+	} else if b, err := json.MarshalIndent(dump, "", "  "); err == nil {
+		result = highlightJSON(string(b), th)
+	}
+
+	if len(others) > 0 {
+		result += "\n" + tui.Render(fieldsString(others), th.muted())
+	}
+	return result
+}
+
+// highlightJSON returns the JSON passed with the keys, the strings,
+// the numbers and the literals colored, the JSON must be valid
+func highlightJSON(s string, th Theme) string {
+	key := lipgloss.NewStyle().Foreground(tui.ColorInfo)
+	str := lipgloss.NewStyle().Foreground(tui.ColorSuccess)
+	num := lipgloss.NewStyle().Foreground(tui.ColorWarning)
+	lit := lipgloss.NewStyle().Foreground(tui.ColorAccent)
+	punct := lipgloss.NewStyle().Foreground(th.Muted)
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(s))
+
+			rest := strings.TrimLeft(s[j:], " ")
+			if strings.HasPrefix(rest, ":") {
+				b.WriteString(key.Render(s[i:j]))
+			} else {
+				b.WriteString(str.Render(s[i:j]))
+			}
+			i = j
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(s) && strings.IndexByte("0123456789.eE+-", s[j]) >= 0 {
+				j++
+			}
+			b.WriteString(num.Render(s[i:j]))
+			i = j
+		case c >= 'a' && c <= 'z':
+			j := i + 1
+			for j < len(s) && s[j] >= 'a' && s[j] <= 'z' {
+				j++
+			}
+			b.WriteString(lit.Render(s[i:j]))
+			i = j
+		case strings.IndexByte("{}[]:,", c) >= 0:
+			b.WriteString(punct.Render(string(c)))
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
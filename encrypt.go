@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// encryptedPrefix is the prefix of the encrypted values stored in the database
+const encryptedPrefix = "enc:v1:"
+
+// encryption encrypts the messages and the fields of the logs stored in the SQLite database
+// the zero value doesn't encrypt, so the values are stored as they are
+type encryption struct {
+	aead cipher.AEAD // the AES-GCM cipher of the key set with EncryptionKey, nil if disabled
+}
+
+// EncryptionKey sets the key used to encrypt the messages and the fields of the logs
+// saved in the SQLite database with AES-GCM, since the logs often contain sensitive data and
+// the database file is readable by anyone with access to the folder
+// the key must be 16, 24 or 32 bytes long (AES-128, AES-192 or AES-256), a nil key disables the encryption
+// Example:
+//
+//	err := log.EncryptionKey([]byte(os.Getenv("LOGS_KEY")))
+//
+// the logs stored before the key is set are still read, the encrypted logs are read only with the same key
+// Note: the encrypted messages can't be matched by the message filters (queries.MessageLike,
+// queries.Search, ...) and they are not merged by Dedup, the other columns are stored as they are
+// this method returns an error if the key is not valid
+func (opts *Logger) EncryptionKey(key []byte) error {
+	var e encryption
+	if key != nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return errors.New("[logger-pkg] invalid encryption key: " + err.Error())
+		}
+
+		e.aead, err = cipher.NewGCM(block)
+		if err != nil {
+			return errors.New("[logger-pkg] invalid encryption key: " + err.Error())
+		}
+	}
+
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.encryption = e
+	return nil
+}
+
+// seal returns the value passed encrypted, or the value itself if the encryption is disabled
+// the empty values are not encrypted
+func (e encryption) seal(value string) string {
+	if e.aead == nil || value == "" {
+		return value
+	}
+
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return value
+	}
+
+	sealed := e.aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed)
+}
+
+// open returns the value passed decrypted, the values not encrypted are returned as they are
+// as the encrypted values when the encryption is disabled or the key is not the right one
+func (e encryption) open(value string) string {
+	encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok || e.aead == nil {
+		return value
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < e.aead.NonceSize() {
+		return value
+	}
+
+	size := e.aead.NonceSize()
+	plain, err := e.aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return value
+	}
+	return string(plain)
+}
@@ -0,0 +1,56 @@
+package logger
+
+import "time"
+
+// Entry represents a log stored in the database
+// it is a plain value: the entries returned by the read methods
+// (GetLogs, Tail) are copies that don't share any memory with the
+// logger or with the other entries, so they can be freely shared
+// across goroutines and modified without data races
+type Entry struct {
+	ID             int64          `json:"id"`                    // the id of the log in the database
+	Level          LogLevel       `json:"level"`                 // the level of the log
+	Tags           []string       `json:"tags"`                  // the tags of the log
+	CallerFile     string         `json:"caller_file"`           // the file where the log was created
+	CallerLine     int            `json:"caller_line"`           // the line where the log was created
+	CallerFunction string         `json:"caller_function"`       // the function where the log was created
+	Message        string         `json:"message"`               // the message of the log
+	Time           time.Time      `json:"time"`                  // the time when the log was created
+	Fields         map[string]any `json:"fields,omitempty"`      // the structured fields of the log (e.g. error_chain), the numbers are json.Number
+	RunID          string         `json:"run_id,omitempty"`      // the id of the execution of the process that created the log (see RunID)
+	Hostname       string         `json:"hostname,omitempty"`    // the name of the machine that created the log (see Logger.CaptureProcess)
+	PID            int            `json:"pid,omitempty"`         // the id of the process that created the log (see Logger.CaptureProcess)
+	Goroutine      int64          `json:"goroutine,omitempty"`   // the id of the goroutine that created the log (see Logger.CaptureProcess)
+	Count          int            `json:"count,omitempty"`       // the occurrences of the log merged in this entry (see Logger.Dedup), 0 or 1 for a single log
+	Correlation    string         `json:"correlation,omitempty"` // the correlation id of the request or job of the log (see Logger.WithCorrelation)
+}
+
+// entry returns a copy of the log as an Entry
+func (l *log) entry() Entry {
+	return Entry{
+		ID:             l.id,
+		Level:          l.level,
+		Tags:           append(make([]string, 0, len(l.tags)), l.tags...),
+		CallerFile:     l.callerFile,
+		CallerLine:     l.callerLine,
+		CallerFunction: l.callerFunction,
+		Message:        l.message,
+		Time:           time.Time(l.timestamp),
+		Fields:         copyFields(l.fields),
+		RunID:          l.runID,
+		Hostname:       l.hostname,
+		PID:            l.pid,
+		Goroutine:      l.goroutine,
+		Count:          l.count,
+		Correlation:    l.correlation,
+	}
+}
+
+// toEntries returns a copy of the logs as a slice of Entry
+func toEntries(logs []*log) []Entry {
+	entries := make([]Entry, 0, len(logs))
+	for _, l := range logs {
+		entries = append(entries, l.entry())
+	}
+	return entries
+}
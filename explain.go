@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// QueryPlan represents the plan of a query of the logs returned by ExplainQuery
+type QueryPlan struct {
+	SQL   string          `json:"sql"`   // the final SQL of the query
+	Steps []QueryPlanStep `json:"steps"` // the steps of the plan, in the order returned by SQLite
+}
+
+// QueryPlanStep represents a step of the plan of a query (a row of EXPLAIN QUERY PLAN)
+type QueryPlanStep struct {
+	ID     int    `json:"id"`     // the id of the step
+	Parent int    `json:"parent"` // the id of the parent step, 0 for the top-level steps
+	Detail string `json:"detail"` // the description of the step (e.g. "SEARCH logs USING INDEX logs_level_index (level>?)")
+}
+
+// String returns the SQL of the query followed by the steps of the plan as a tree
+func (p QueryPlan) String() string {
+	var sb strings.Builder
+	sb.WriteString(p.SQL)
+	sb.WriteString("\n")
+
+	depths := make(map[int]int, len(p.Steps))
+	for _, step := range p.Steps {
+		depth := 0
+		if d, ok := depths[step.Parent]; ok {
+			depth = d + 1
+		}
+		depths[step.ID] = depth
+		sb.WriteString(strings.Repeat("  ", depth) + "- " + step.Detail + "\n")
+	}
+	return sb.String()
+}
+
+// DebugSQL prints the final SQL of the queries built from the query options (GetLogs, PrintLogs,
+// Export, DeleteLogs, GetLogsPage, Archive, ...) in the output of the logger before they run,
+// to diagnose the filters that are slow or don't select the expected logs
+// the values of the filters are part of the SQL, the queries have no bound parameters
+// Example:
+//
+//	log.DebugSQL(true)
+//	log.GetLogs(queries.LevelEqual(logger.Error), queries.HasTags("api"))
+//	// [logger-pkg] SQL: SELECT DISTINCT logs.id, ... WHERE logs.level = 30 AND logs.id IN (SELECT log_tags.log_id ... WHERE (tags.name LIKE '%api%'));
+//
+// the SQL of the queries written by the logger (the logs, the tags, ...) is not printed
+// Note: the custom stores ignore this option
+func (opts *Logger) DebugSQL(enabled bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.debugSQL = enabled
+}
+
+// ExplainQuery returns the final SQL of the query built from the query options passed and its plan,
+// the result of EXPLAIN QUERY PLAN, without running the query, e.g. to check if the filters use an index
+// Example:
+//
+//	plan, err := log.ExplainQuery(queries.MessageLike("timeout"), queries.SortTimestamp("DESC"))
+//	fmt.Print(plan)
+//
+// the plan of the databases attached with AttachSources and of the rotated files is the one of
+// the database of the logger, which has the same tables and indexes
+// this method returns ErrNotSupported if the store is not a SQLite store
+// and an error if the query options are not valid or if it fails to explain the query
+func (opts *Logger) ExplainQuery(queryOptions ...QueryOption) (QueryPlan, error) {
+	s := opts.getStore()
+	store, ok := sqliteStoreOf(s)
+	if !ok {
+		return QueryPlan{}, unsupported(s)
+	}
+
+	query, err := buildQuery(queryOptions...)
+	if err != nil {
+		return QueryPlan{}, err
+	}
+
+	db, err := getDBConnection(store)
+	if err != nil {
+		return QueryPlan{}, err
+	}
+	defer releaseDBConnection(store, db)
+
+	query, err = expandSavedQueries(context.Background(), db, query)
+	if err != nil {
+		return QueryPlan{}, err
+	}
+
+	rows, err := db.QueryContext(context.Background(), "EXPLAIN QUERY PLAN "+query+";")
+	if err != nil {
+		return QueryPlan{}, errors.New("[logger-pkg] failed to explain the query: " + err.Error())
+	}
+	defer rows.Close()
+
+	plan := QueryPlan{SQL: compactSQL(query) + ";", Steps: make([]QueryPlanStep, 0)}
+	for rows.Next() {
+		var step QueryPlanStep
+		var notUsed int
+		err = rows.Scan(&step.ID, &step.Parent, &notUsed, &step.Detail)
+		if err != nil {
+			return QueryPlan{}, errors.New("[logger-pkg] failed to explain the query: " + err.Error())
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return QueryPlan{}, errors.New("[logger-pkg] failed to explain the query: " + err.Error())
+	}
+	return plan, nil
+}
+
+// debugWriter returns the writer of the SQL of the queries of the store of the logger, nil if DebugSQL is disabled
+// the logger must be locked
+func (opts *Logger) debugWriter() io.Writer {
+	if !opts.debugSQL {
+		return nil
+	}
+	if opts.output == nil {
+		return os.Stdout
+	}
+	return opts.output
+}
+
+// logSQL prints the SQL passed in the debug writer of the store, if DebugSQL is enabled
+func (s *sqliteStore) logSQL(query string) {
+	if s.debug != nil {
+		fmt.Fprintf(s.debug, "[logger-pkg] SQL: %s;\n", compactSQL(query))
+	}
+}
+
+// compactSQL returns the SQL passed in a single line, without the repeated spaces
+func compactSQL(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
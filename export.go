@@ -0,0 +1,300 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultExportName is the file name pattern of the exports when ExportOptions doesn't set one
+const defaultExportName = "{timestamp}_logs"
+
+// ExportOptions represents where and how the exports (see Export) write their files
+// the zero value writes the files in the folder of the logger, named as 20240102150405_logs.json,
+// and adds a unique suffix to the names of the files that already exist
+type ExportOptions struct {
+	// FileName is the pattern of the name of the files, without the extension (added by the export type),
+	// it supports the following placeholders:
+	//   - {timestamp}: the time of the export (20240102150405)
+	//   - {date}: the date of the export (2024-01-02)
+	//   - {time}: the time of the day of the export (150405)
+	//   - {tags}: the tags of the logger joined by "-" ("untagged" if the logger has no tags)
+	//
+	// the characters not allowed in the file names are replaced by "_", if empty "{timestamp}_logs" is used
+	FileName string
+	// Folder is the folder of the files, it is created if it doesn't exist,
+	// if empty the folder of the logger is used
+	Folder string
+	// Overwrite replaces the files with the same name, by default the name of a new file
+	// that already exists gets a numeric suffix (e.g. 20240102150405_logs_1.json)
+	Overwrite bool
+	// Compress writes the files compressed with gzip while they are exported, the ".gz" extension
+	// is added to the name (e.g. 20240102150405_logs.json.gz), Import reads the compressed exports
+	Compress bool
+	// SplitByDay writes the logs of every day in a different file (e.g. 20240102150405_logs_2024-01-02.json)
+	SplitByDay bool
+	// MaxRows is the maximum number of logs of every file, the exceeding logs are written in more files
+	// (e.g. 20240102150405_logs_0001.json, 20240102150405_logs_0002.json), if 0 the files have no limit
+	MaxRows int
+	// MaxSize is the maximum size in bytes of every file, before the compression, if 0 the files have no limit
+	// a file can exceed it only if a single log is bigger
+	MaxSize int64
+}
+
+// SetExportOptions sets the name pattern and the folder of the files written by Export
+// and by the exports of StartMaintenance, passing an empty ExportOptions restores the default ones
+// when the options split the exports (SplitByDay, MaxRows, MaxSize) the exports write more files
+// and a manifest listing them (see ExportManifest), and they return the path of the manifest
+// Example:
+//
+//	log.SetExportOptions(logger.ExportOptions{
+//		FileName: "{tags}_{date}",
+//		Folder:   "/var/backups/logs",
+//	})
+//	path, _ := log.Export(logger.JSON) // /var/backups/logs/api-2024-01-02.json
+func (opts *Logger) SetExportOptions(options ExportOptions) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.exportOptions = options
+}
+
+// exportPath returns the path of a new export file with the extension passed,
+// created at the time passed by the logger with the tags passed
+func (o ExportOptions) exportPath(folder, ext string, tags []string, now time.Time) string {
+	if o.Folder != "" {
+		folder = o.Folder
+	}
+
+	pattern := o.FileName
+	if pattern == "" {
+		pattern = defaultExportName
+	}
+
+	tagNames := strings.Join(tags, "-")
+	if tagNames == "" {
+		tagNames = "untagged"
+	}
+
+	name := strings.NewReplacer(
+		"{timestamp}", now.Format("20060102150405"),
+		"{date}", now.Format("2006-01-02"),
+		"{time}", now.Format("150405"),
+		"{tags}", tagNames,
+	).Replace(pattern)
+	if o.Compress {
+		ext += ".gz"
+	}
+	return filepath.Join(folder, sanitizeFileName(name)+ext)
+}
+
+// create creates the export file with the path passed, replacing the existing one if
+// the options overwrite the files, otherwise adding a numeric suffix to the name of the file
+// it returns the file and its path
+func (o ExportOptions) create(filePath string) (*os.File, string, error) {
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return nil, "", errors.New("[logger-pkg] failed to create the export folder: " + err.Error())
+	}
+
+	if o.Overwrite {
+		file, err := createExportFile(filePath)
+		return file, filePath, err
+	}
+
+	ext := filepath.Ext(filePath)
+	if ext == ".gz" {
+		ext = filepath.Ext(strings.TrimSuffix(filePath, ext)) + ext
+	}
+	base := strings.TrimSuffix(filePath, ext)
+	path := filePath
+	for i := 1; ; i++ {
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if err == nil {
+			return file, path, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, "", err
+		}
+		path = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+}
+
+// compressed returns the write function passed wrapped to compress its output with gzip
+// if the options compress the files, otherwise it returns the write function as it is
+func (o ExportOptions) compressed(write func(io.Writer, []*log, string) error) func(io.Writer, []*log, string) error {
+	if !o.Compress {
+		return write
+	}
+
+	return func(w io.Writer, logs []*log, layout string) error {
+		z := gzip.NewWriter(w)
+		if err := write(z, logs, layout); err != nil {
+			z.Close()
+			return err
+		}
+		return z.Close()
+	}
+}
+
+// readExport returns the content of the export file passed,
+// decompressing the gzip-compressed exports (.gz) and archives
+func readExport(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.EqualFold(filepath.Ext(path), ".gz") {
+		return data, err
+	}
+
+	z, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	return io.ReadAll(z)
+}
+
+// exportExt returns the extension of the export file passed in lowercase,
+// without the ".gz" extension of the compressed exports
+func exportExt(path string) string {
+	ext := filepath.Ext(path)
+	if strings.EqualFold(ext, ".gz") {
+		ext = filepath.Ext(strings.TrimSuffix(path, ext))
+	}
+	return strings.ToLower(ext)
+}
+
+// sanitizeFileName replaces the characters not allowed in the file names with "_"
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// ExportTemplate represents the templates of the Template exports (see SetExportTemplate)
+// the templates use the text/template syntax with the following functions:
+//   - join: joins a list of strings with a separator ({{join .Tags ", "}})
+//   - upper, lower: change the case of a string ({{lower .Level.String}})
+//   - json: marshals a value as JSON ({{json .Fields}})
+//   - time: formats a time with a Go layout ({{time .Time "2006-01-02"}})
+type ExportTemplate struct {
+	Log       string // the template of every log, executed with the Entry of the log
+	Header    string // the template written before the logs, executed with the []Entry of the export (optional)
+	Footer    string // the template written after the logs, executed with the []Entry of the export (optional)
+	Extension string // the extension of the files (e.g. ".md"), if empty ".txt" is used
+}
+
+// exportTemplate is an ExportTemplate with the templates parsed
+type exportTemplate struct {
+	log, header, footer *template.Template
+	ext                 string
+}
+
+// templateFuncs are the functions available in the export templates
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"time": func(t time.Time, layout string) string {
+		return t.Format(layout)
+	},
+}
+
+// SetExportTemplate sets the templates of the exports with the Template type, so the logs
+// can be exported in any text format (reports, Jira markup, custom syslog lines, ...)
+// the Log template is executed for every log, the logs are not separated, so the template
+// usually ends with a new line
+// Example:
+//
+//	err := log.SetExportTemplate(logger.ExportTemplate{
+//		Header:    "|| Time || Level || Message ||\n",
+//		Log:       "| {{time .Time \"2006-01-02 15:04\"}} | {{.Level}} | {{.Message}} |\n",
+//		Footer:    "{{len .}} logs\n",
+//		Extension: ".jira",
+//	})
+//	path, err := log.Export(logger.Template, queries.LevelEqual(logger.Error))
+//
+// this method returns an error if the Log template is empty or if a template is not valid,
+// in which case the previous templates are kept
+func (opts *Logger) SetExportTemplate(t ExportTemplate) error {
+	if t.Log == "" {
+		return errors.New("[logger-pkg] the template of the logs can't be empty")
+	}
+
+	parsed := &exportTemplate{ext: t.Extension}
+	if parsed.ext == "" {
+		parsed.ext = ".txt"
+	} else if !strings.HasPrefix(parsed.ext, ".") {
+		parsed.ext = "." + parsed.ext
+	}
+
+	for _, tmpl := range []struct {
+		name, text string
+		dst        **template.Template
+	}{
+		{"log", t.Log, &parsed.log}, {"header", t.Header, &parsed.header}, {"footer", t.Footer, &parsed.footer},
+	} {
+		if tmpl.text == "" {
+			continue
+		}
+
+		parsedTemplate, err := template.New(tmpl.name).Funcs(templateFuncs).Parse(tmpl.text)
+		if err != nil {
+			return fmt.Errorf("[logger-pkg] invalid %s template: %s", tmpl.name, err.Error())
+		}
+		*tmpl.dst = parsedTemplate
+	}
+
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.exportTemplate = parsed
+	return nil
+}
+
+// format returns the extension of the files of the template and the function writing the logs with it,
+// the function fails if no template is set
+func (t *exportTemplate) format() (string, func(io.Writer, []*log, string) error) {
+	if t == nil {
+		return ".txt", func(io.Writer, []*log, string) error {
+			return errors.New("[logger-pkg] no export template set, use SetExportTemplate")
+		}
+	}
+	return t.ext, t.write
+}
+
+// write writes the header, the logs and the footer passed with the templates
+func (t *exportTemplate) write(w io.Writer, logs []*log, layout string) error {
+	entries := toEntries(logs)
+	if t.header != nil {
+		if err := t.header.Execute(w, entries); err != nil {
+			return errors.New("[logger-pkg] failed to execute the header template: " + err.Error())
+		}
+	}
+
+	for _, e := range entries {
+		if err := t.log.Execute(w, e); err != nil {
+			return errors.New("[logger-pkg] failed to execute the log template: " + err.Error())
+		}
+	}
+
+	if t.footer != nil {
+		if err := t.footer.Execute(w, entries); err != nil {
+			return errors.New("[logger-pkg] failed to execute the footer template: " + err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxFederated is the maximum number of databases read by a single query,
+// the limit of the databases attached to a SQLite connection
+const maxFederated = 10
+
+// federatedIDShift is the shift of the ids of the logs and of the tags of every database of a
+// federated query, so the ids of different databases don't collide: the ids of the database i
+// are increased by i << federatedIDShift and the ids of the first existing database are not changed
+const federatedIDShift = 40
+
+// AttachSources adds the SQLite databases passed (e.g. the logs_data.db files collected from
+// multiple servers) to the queries of the logger: PrintLogs, GetLogs, Export and the other queries
+// read them and the database of the logger as a single database; passing no path detaches them
+// Example:
+//
+//	err := log.AttachSources("servers/web-1/logs_data.db", "servers/web-2/logs_data.db")
+//	log.PrintLogs(queries.LevelEqual(logger.Error))
+//
+// the logs are written and deleted only in the database of the logger, the ids of the logs of the sources
+// are shifted so they don't collide and the queries can read at most 10 databases
+// the sources are opened read-only and never migrated: the queries return an error if a source was
+// created by an older version of the package (open it once with a logger to migrate it), or by a newer one
+// unless the read-only compatibility mode is enabled (see ReadOnlyCompat)
+// Note: the queries.Search filter doesn't work with the sources
+// this method returns an error if a database doesn't exist
+func (opts *Logger) AttachSources(paths ...string) error {
+	sources := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return errors.New("[logger-pkg] failed to attach the source: " + err.Error())
+		}
+		sources = append(sources, path)
+	}
+
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.sources = sources
+	return nil
+}
+
+// readPaths returns the paths of the databases read by the queries of the store: its database
+// (or the recent rotated files) and the attached sources, the first one is the database of the store
+// it returns nil if the store reads only its database
+func (s *sqliteStore) readPaths() []string {
+	if s.rotation.policy == NoRotation && len(s.sources) == 0 {
+		return nil
+	}
+
+	paths := []string{s.dbPath()}
+	if s.rotation.policy != NoRotation {
+		paths = s.rotation.files(s.folderPath, s.fileName)
+	}
+	return append(paths, s.sources...)
+}
+
+// selectFederated returns the logs selected by the query options passed in the databases passed as a single one:
+// the databases are attached read-only to an in-memory database where the temporary views logs, tags,
+// log_tags and runs join their tables; the clock offset of the first database is used for every log
+// the databases that don't exist are skipped
+func selectFederated(ctx context.Context, s *sqliteStore, paths []string, configs ...QueryOption) ([]*log, error) {
+	query, err := buildQuery(configs...)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(query, "saved_query(") {
+		// the saved queries are read from the database of the store
+		db, err := getDBConnection(s)
+		if err != nil {
+			return nil, err
+		}
+		query, err = expandSavedQueries(ctx, db, query)
+		releaseDBConnection(s, db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mem, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, errors.New("[logger-pkg] failed to query the logs: " + err.Error())
+	}
+	defer mem.Close()
+	mem.SetMaxOpenConns(1)
+
+	var offset time.Duration
+	views := map[string][]string{}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		o, err := prepareSource(ctx, s, path, slices.Contains(s.sources, path))
+		if err != nil {
+			return nil, err
+		}
+
+		i := len(views["logs"])
+		if i == 0 {
+			offset = o
+		}
+
+		if i >= maxFederated {
+			return nil, fmt.Errorf("[logger-pkg] too many databases to query (the maximum is %d)", maxFederated)
+		}
+
+		_, err = mem.ExecContext(ctx, fmt.Sprintf("ATTACH DATABASE ? AS s%d;", i), readOnlyURI(path))
+		if err != nil {
+			return nil, errors.New("[logger-pkg] failed to attach the database " + path + ": " + err.Error())
+		}
+
+		shift := int64(i) << federatedIDShift
+		views["logs"] = append(views["logs"], fmt.Sprintf("SELECT id + %d AS id, level, caller_file, caller_line, caller_function, message, time, timestamp, fields, run_id, hostname, pid, goroutine, count, hash, correlation FROM s%d.logs", shift, i))
+		views["tags"] = append(views["tags"], fmt.Sprintf("SELECT id + %d AS id, name FROM s%d.tags", shift, i))
+		views["log_tags"] = append(views["log_tags"], fmt.Sprintf("SELECT log_id + %d AS log_id, tag_id + %d AS tag_id, rowid + %d AS rowid FROM s%d.log_tags", shift, shift, shift, i))
+		views["runs"] = append(views["runs"], fmt.Sprintf("SELECT id, start, hostname, pid, version, revision, dirty FROM s%d.runs", i))
+	}
+
+	if len(views) == 0 {
+		return nil, nil
+	}
+
+	for _, name := range []string{"logs", "tags", "log_tags", "runs"} {
+		_, err = mem.ExecContext(ctx, fmt.Sprintf("CREATE TEMP VIEW %s AS %s;", name, strings.Join(views[name], " UNION ALL ")))
+		if err != nil {
+			return nil, errors.New("[logger-pkg] failed to query the logs: " + err.Error())
+		}
+	}
+
+	s.logSQL(query)
+	return scanLogs(ctx, mem, s.encryption, offset, query)
+}
+
+// readOnlyURI returns the URI opening the database file passed read-only
+func readOnlyURI(path string) string {
+	escaped := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(filepath.ToSlash(path))
+	return "file:" + escaped + "?mode=ro"
+}
+
+// prepareSource returns the clock offset of the database passed, the database of the store and its rotated files
+// are opened with the configuration of the store, so their schema is created or migrated, while the attached
+// sources (attached true) are only read: their schema must be the one of this version of the package
+func prepareSource(ctx context.Context, s *sqliteStore, path string, attached bool) (time.Duration, error) {
+	if attached {
+		return readSource(ctx, s, path)
+	}
+
+	source := *s
+	source.folderPath, source.fileName = filepath.Dir(path), filepath.Base(path)
+	source.rotation, source.sources = rotation{}, nil
+
+	db, err := getDBConnection(&source)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseDBConnection(&source, db)
+
+	return getClockOffset(ctx, db)
+}
+
+// readSource returns the clock offset of the attached source passed, read with a read-only connection
+// it returns an error if the schema of the source is older than the one of this version of the package,
+// or newer and the store is not in the read-only compatibility mode
+func readSource(ctx context.Context, s *sqliteStore, path string) (time.Duration, error) {
+	db, err := sql.Open("sqlite3", readOnlyURI(path))
+	if err != nil {
+		return 0, errors.New("[logger-pkg] failed to open the source " + path + ": " + err.Error())
+	}
+	defer db.Close()
+
+	version, err := getSchemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+
+	if version < schemaVersion {
+		return 0, fmt.Errorf("[logger-pkg] the source %s was created by an older version of the package (database version %d, supported version %d), open it with a logger to migrate it", path, version, schemaVersion)
+	}
+
+	if version > schemaVersion && !s.compat {
+		return 0, fmt.Errorf("%w (source %s, database version %d, supported version %d)", ErrNewerSchema, path, version, schemaVersion)
+	}
+
+	return getClockOffset(ctx, db)
+}
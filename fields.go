@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// the keys of the fields added by the package
+const (
+	errorChainField = "error_chain" // the messages of the errors in the chain, from the outer one to the root cause
+	errorTypeField  = "error_type"  // the type of the root cause of the error
+)
+
+// errorFields returns the fields describing the error passed:
+// the messages of its chain (following errors.Unwrap) and the type of its root cause
+func errorFields(err error) map[string]any {
+	chain := make([]any, 0)
+	root := err
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, e.Error())
+		root = e
+	}
+
+	return map[string]any{
+		errorChainField: chain,
+		errorTypeField:  fmt.Sprintf("%T", root),
+	}
+}
+
+// copyFields returns a deep copy of the fields passed
+// it returns nil if there are no fields
+func copyFields(fields map[string]any) map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	result := make(map[string]any, len(fields))
+	for k, v := range fields {
+		result[k] = copyValue(v)
+	}
+	return result
+}
+
+// copyValue returns a deep copy of the slices and maps of a field value
+func copyValue(v any) any {
+	switch v := v.(type) {
+	case []any:
+		result := make([]any, 0, len(v))
+		for _, item := range v {
+			result = append(result, copyValue(item))
+		}
+		return result
+	case []string:
+		return append(make([]string, 0, len(v)), v...)
+	case map[string]any:
+		return copyFields(v)
+	default:
+		return v
+	}
+}
+
+// marshalFields returns the fields as a JSON object, the keys are sorted
+// it returns an empty string if there are no fields
+func marshalFields(fields map[string]any) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// unmarshalFields returns the fields of the JSON object passed
+// it returns nil if the string is empty or it is not a valid object
+func unmarshalFields(s string) map[string]any {
+	if s == "" {
+		return nil
+	}
+
+	var fields map[string]any
+	if err := decodeJSON([]byte(s), &fields); err != nil || len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// fieldsString returns the fields as a sorted list of key=value pairs
+// the strings without spaces are printed as they are, the other values as JSON
+func fieldsString(fields map[string]any) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		var value string
+		if s, ok := fields[k].(string); ok && s != "" && !strings.ContainsAny(s, " \t\n\"") {
+			value = s
+		} else if b, err := json.Marshal(fields[k]); err == nil {
+			value = string(b)
+		} else {
+			value = fmt.Sprintf("%v", fields[k])
+		}
+		pairs = append(pairs, k+"="+value)
+	}
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConsoleFormat is an enum to define how the logs are printed in the console
+// the format can be:
+//   - StyledFormat: the logs are printed as colored cards or table rows (default)
+//   - PlainFormat: every log is a line of text without colors, like the LOG exports
+//   - JSONFormat: every log is a JSON object on a single line, like the NDJSON exports
+//   - LogfmtFormat: every log is a line of key=value pairs
+//
+// the plain, JSON and logfmt formats are meant for the containers and the services
+// whose output is scraped by a log collector
+type ConsoleFormat int
+
+const (
+	StyledFormat ConsoleFormat = iota // the logs are printed as colored cards or table rows
+	PlainFormat                       // every log is a line of text without colors
+	JSONFormat                        // every log is a JSON object on a single line
+	LogfmtFormat                      // every log is a line of key=value pairs
+)
+
+// Format sets the format of the logs printed in the console
+// the options of the styled output (Inline, Density, Header, SetTheme, the render hooks, ...)
+// have effect only with the StyledFormat (default), the other formats print every information
+// of the logs with the times in the location and the custom layout of the logger
+// Example:
+//
+//	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+//		log.Format(logger.JSONFormat)
+//	}
+func (opts *Logger) Format(format ConsoleFormat) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.format = format
+}
+
+// formatLines returns the logs passed as lines in the format of the logger (not the styled one)
+func formatLines(lopts *Logger, logs []*log) string {
+	loc := lopts.getLocation()
+
+	var b strings.Builder
+	for _, l := range logs {
+		c := *l
+		c.timestamp = timestamp(time.Time(l.timestamp).In(loc))
+
+		switch lopts.format {
+		case JSONFormat:
+			b.WriteString(c.toJSONLine(lopts.timeLayout))
+		case LogfmtFormat:
+			b.WriteString(c.toLogfmt(lopts.timeLayout))
+		default: // PlainFormat
+			b.WriteString(c.format(lopts.timeLayout))
+			if c.count > 1 {
+				b.WriteString(fmt.Sprintf(" ×%d", c.count))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// toLogfmt returns the log as a line of key=value pairs, the fields follow the information of the log
+// the time is formatted with the layout passed (the default RFC3339 one if empty)
+func (l *log) toLogfmt(layout string) string {
+	t := l.timestamp.rfc3339()
+	if layout != "" {
+		t = l.timestamp.format(layout)
+	}
+
+	pairs := []string{
+		"time=" + logfmtValue(t),
+		"level=" + strings.ToLower(l.level.String()),
+	}
+	if len(l.tags) > 0 {
+		pairs = append(pairs, "tags="+logfmtValue(strings.Join(l.tags, ",")))
+	}
+	pairs = append(pairs,
+		"caller="+logfmtValue(fmt.Sprintf("%s:%d", l.callerFile, l.callerLine)),
+		"msg="+logfmtValue(l.message),
+	)
+	if l.runID != "" {
+		pairs = append(pairs, "run_id="+logfmtValue(l.runID))
+	}
+	if l.hostname != "" {
+		pairs = append(pairs, "hostname="+logfmtValue(l.hostname))
+	}
+	if l.pid != 0 {
+		pairs = append(pairs, fmt.Sprintf("pid=%d", l.pid))
+	}
+	if l.goroutine != 0 {
+		pairs = append(pairs, fmt.Sprintf("goroutine=%d", l.goroutine))
+	}
+	if l.correlation != "" {
+		pairs = append(pairs, "correlation="+logfmtValue(l.correlation))
+	}
+	if l.count > 1 {
+		pairs = append(pairs, fmt.Sprintf("count=%d", l.count))
+	}
+
+	keys := make([]string, 0, len(l.fields))
+	for k := range l.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var value string
+		if s, ok := l.fields[k].(string); ok {
+			value = s
+		} else if b, err := json.Marshal(l.fields[k]); err == nil {
+			value = string(b)
+		} else {
+			value = fmt.Sprintf("%v", l.fields[k])
+		}
+		pairs = append(pairs, k+"="+logfmtValue(value))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// logfmtValue returns the value passed quoted if it is empty or it contains spaces, quotes or equal signs
+func logfmtValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\r\"=\\") {
+		return strconv.Quote(s)
+	}
+	return s
+}
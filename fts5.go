@@ -0,0 +1,48 @@
+//go:build sqlite_fts5
+
+package logger
+
+import "database/sql"
+
+// fullTextSearch is the schema of the full-text index of the messages
+// logs_fts is an external content FTS5 table (it doesn't duplicate the messages)
+// kept in sync with the logs table by the triggers
+const fullTextSearch = `
+CREATE VIRTUAL TABLE IF NOT EXISTS logs_fts USING fts5(message, content='logs', content_rowid='id');
+
+CREATE TRIGGER IF NOT EXISTS logs_fts_insert AFTER INSERT ON logs BEGIN
+    INSERT INTO logs_fts (rowid, message) VALUES (new.id, new.message);
+END;
+
+CREATE TRIGGER IF NOT EXISTS logs_fts_delete AFTER DELETE ON logs BEGIN
+    INSERT INTO logs_fts (logs_fts, rowid, message) VALUES ('delete', old.id, old.message);
+END;
+
+CREATE TRIGGER IF NOT EXISTS logs_fts_update AFTER UPDATE OF message ON logs BEGIN
+    INSERT INTO logs_fts (logs_fts, rowid, message) VALUES ('delete', old.id, old.message);
+    INSERT INTO logs_fts (rowid, message) VALUES (new.id, new.message);
+END;
+`
+
+// migrateFullTextSearch creates the full-text index of the messages and its triggers
+// if the triggers are missing (a new database, or one used by a build without FTS5)
+// the index is rebuilt from the messages of the logs
+func migrateFullTextSearch(tx *sql.Tx) error {
+	var triggers int
+	err := tx.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'logs_fts_%';").Scan(&triggers)
+	if err != nil {
+		return err
+	}
+
+	if triggers == 3 {
+		return nil
+	}
+
+	_, err = tx.Exec(fullTextSearch)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO logs_fts (logs_fts) VALUES ('rebuild');")
+	return err
+}
@@ -0,0 +1,17 @@
+//go:build !sqlite_fts5
+
+package logger
+
+import "database/sql"
+
+// migrateFullTextSearch removes the triggers of the full-text index created by a build
+// with FTS5 (the sqlite_fts5 build tag), without the FTS5 module they would make every
+// insert fail; the index is rebuilt by the next build with FTS5 opening the database
+func migrateFullTextSearch(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+DROP TRIGGER IF EXISTS logs_fts_insert;
+DROP TRIGGER IF EXISTS logs_fts_delete;
+DROP TRIGGER IF EXISTS logs_fts_update;
+`)
+	return err
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// the statements of the goroutine dumps of the Fatal logs
+const (
+	insertGoroutinesQuery = "INSERT OR REPLACE INTO log_goroutines (log_id, dump) VALUES (?, ?);"
+	selectGoroutinesQuery = "SELECT dump FROM log_goroutines WHERE log_id = ?;"
+)
+
+// maxGoroutineDump is the maximum size in bytes of the goroutine dumps
+const maxGoroutineDump = 64 << 20
+
+// GoroutineDump sets the Fatal logs (created by Fatal, HandlePanics and Go) to save the stack traces
+// of every goroutine of the process if the enabled parameter is true, otherwise only the stack trace
+// of the goroutine that created the log is saved (default)
+// the process exits right after a Fatal log, so the dump is the only way to know what the other goroutines
+// were doing (e.g. a deadlock or a leak), it is kept in a separate table of the SQLite store and it can
+// be read with Goroutines, CrashReport adds it to the report as goroutines.txt
+// Example:
+//
+//	log.GoroutineDump(true)
+//	defer log.HandlePanics()
+//
+// Note: the dump stops the world while it is taken and it can be large with many goroutines
+func (opts *Logger) GoroutineDump(enabled bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.goroutineDump = enabled
+}
+
+// Goroutines returns the goroutine dump saved with the Fatal log with the id passed (see GoroutineDump),
+// it is empty if the log has no dump
+// this method returns ErrNotSupported if the store is not a SQLite store or a snapshot
+func (opts *Logger) Goroutines(id int64) (string, error) {
+	s := opts.getStore()
+	store, ok := sqliteStoreOf(s)
+	if !ok {
+		return "", unsupported(s)
+	}
+
+	db, err := getDBConnection(store)
+	if err != nil {
+		return "", err
+	}
+	defer releaseDBConnection(store, db)
+
+	var dump string
+	err = db.QueryRowContext(context.Background(), selectGoroutinesQuery, id).Scan(&dump)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("[logger-pkg] failed to read the goroutine dump of the log %d: %s", id, err.Error())
+	}
+	return store.encryption.open(dump), nil
+}
+
+// captureGoroutines adds to the log passed the stack traces of every goroutine
+// if the logger dumps them (see GoroutineDump)
+func (opts *Logger) captureGoroutines(l *log) {
+	opts.mu.RLock()
+	dump := opts.goroutineDump
+	opts.mu.RUnlock()
+	if !dump {
+		return
+	}
+
+	l.goroutines = goroutineDump()
+}
+
+// goroutineDump returns the stack traces of every goroutine of the process
+// the buffer grows until the dump fits in it or it reaches maxGoroutineDump
+func goroutineDump() string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxGoroutineDump {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// insertGoroutines saves the goroutine dump of the log passed, using the transaction
+// and the encryption passed
+func insertGoroutines(tx *sql.Tx, enc encryption, logId int64, l *log) error {
+	if l.goroutines == "" {
+		return nil
+	}
+
+	_, err := tx.Exec(insertGoroutinesQuery, logId, enc.seal(l.goroutines))
+	return err
+}
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Tagliapietra96/tui"
+	"github.com/Tagliapietra96/tui/opts"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// GroupBy is an enum to define how the printed logs are sectioned
+// the grouping can be:
+//   - NoGroup: the logs are printed as a flat list (default)
+//   - GroupByDay: the logs are sectioned by the day they were created (in the location of the logger)
+//   - GroupByTag: the logs are sectioned by their first tag
+//   - GroupByRun: the logs are sectioned by the execution of the process that created them
+//
+// every section starts with a header with its name and the number of its logs,
+// the sections follow the order of the first log of each one
+type GroupBy int
+
+const (
+	NoGroup    GroupBy = iota // the logs are printed as a flat list
+	GroupByDay                // the logs are sectioned by day
+	GroupByTag                // the logs are sectioned by their first tag
+	GroupByRun                // the logs are sectioned by run
+)
+
+// Group sets how the logs printed by the logger are sectioned, it is useful to scan long histories
+// this option has effect only with the styled format (see Format)
+// Example:
+//
+//	log.Group(logger.GroupByDay)
+//	log.PrintLogs(queries.InstantAfter(time.Now().AddDate(0, 0, -7)))
+func (opts *Logger) Group(by GroupBy) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.groupBy = by
+}
+
+// logGroup is a section of the printed logs
+type logGroup struct {
+	title string
+	logs  []*log
+}
+
+// groupLogs returns the logs passed sectioned by the grouping of the logger
+// without grouping it returns a single group without title
+func groupLogs(lopts *Logger, logs []*log) []logGroup {
+	if lopts.groupBy == NoGroup {
+		return []logGroup{{logs: logs}}
+	}
+
+	loc := lopts.getLocation()
+	groups := make([]logGroup, 0)
+	index := make(map[string]int)
+	for _, l := range logs {
+		var title string
+		switch lopts.groupBy {
+		case GroupByDay:
+			title = time.Time(l.timestamp).In(loc).Format("Monday 2006-01-02")
+		case GroupByTag:
+			title = "no tags"
+			if len(l.tags) > 0 {
+				title = "🔖" + l.tags[0]
+			}
+		case GroupByRun:
+			title = "unknown run"
+			if l.runID != "" {
+				title = "run " + l.runID
+			}
+		}
+
+		i, ok := index[title]
+		if !ok {
+			i = len(groups)
+			index[title] = i
+			groups = append(groups, logGroup{title: title})
+		}
+		groups[i].logs = append(groups[i].logs, l)
+	}
+	return groups
+}
+
+// groupHeader returns the header of a section of the printed logs, with its title and the number of its logs
+func groupHeader(w int, g logGroup, th Theme) string {
+	count := fmt.Sprintf("%d logs", len(g.logs))
+	if len(g.logs) == 1 {
+		count = "1 log"
+	}
+
+	style := tui.NewStyle(opts.Width(w), opts.Margin(1, 0, 0, 0), opts.Color(nil, nil, th.Border))
+	style = style.Border(lipgloss.NormalBorder(), false, false, true, false)
+	return style.Render(tui.Render(g.title, opts.Bold) + "  " + tui.Render(count, th.muted()))
+}
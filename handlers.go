@@ -1,11 +1,13 @@
 package logger
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -47,22 +49,120 @@ CREATE TABLE IF NOT EXISTS log_tags (
 
 CREATE INDEX IF NOT EXISTS lt_log_id_index ON log_tags (log_id);
 CREATE INDEX IF NOT EXISTS lt_tag_id_index ON log_tags (tag_id);
+
+CREATE TABLE IF NOT EXISTS metadata (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS runs (
+    id TEXT PRIMARY KEY,
+    start TEXT NOT NULL,
+    hostname TEXT NOT NULL DEFAULT '',
+    pid INTEGER NOT NULL DEFAULT 0,
+    version TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS runs_start_index ON runs (start);
+
+CREATE TABLE IF NOT EXISTS log_overflow (
+    log_id INTEGER PRIMARY KEY,
+    message TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (log_id) REFERENCES logs(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS log_goroutines (
+    log_id INTEGER PRIMARY KEY,
+    dump TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (log_id) REFERENCES logs(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS log_attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    log_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    data BLOB NOT NULL,
+    size INTEGER NOT NULL DEFAULT 0,
+    time TEXT NOT NULL,
+    UNIQUE (log_id, name),
+    FOREIGN KEY (log_id) REFERENCES logs(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS saved_queries (
+    name TEXT PRIMARY KEY,
+    query TEXT NOT NULL DEFAULT '',
+    updated TEXT NOT NULL
+);
 `
 
+// schemaVersion is the version of the database schema created by this version of the package
+// it is stored in the user_version pragma of the database and it must be increased
+// every time the schema changes, so the older versions of the package recognize the newer databases
+const schemaVersion = 11
+
+// ErrNewerSchema is returned when the database was created by a newer version of the package
+// the database can still be read enabling the read-only compatibility mode (see Logger.ReadOnlyCompat)
+var ErrNewerSchema = errors.New("[logger-pkg] the logs database was created by a newer version of the package")
+
+// the keys of the metadata table
+const (
+	clockOffsetKey = "clock_offset" // the correction added to the times of the logs of the database
+)
+
+// column represents a column added to an existing table after the first release of the package
+// the column is added to the databases created with older versions when the connection is opened
+type column struct {
+	table      string // the table to alter
+	name       string // the name of the column
+	definition string // the type and constraints of the column
+	index      string // the index to create on the column, empty if none
+}
+
+// columns lists the columns added to the schema after the first release, in order
+var columns = []column{
+	{"logs", "hash", "TEXT NOT NULL DEFAULT ''", "CREATE INDEX IF NOT EXISTS logs_hash_index ON logs (hash);"},
+	{"logs", "timestamp", "TEXT NOT NULL DEFAULT ''", "CREATE INDEX IF NOT EXISTS logs_timestamp_index ON logs (timestamp);"},
+	{"logs", "fields", "TEXT NOT NULL DEFAULT ''", ""},
+	{"logs", "run_id", "TEXT NOT NULL DEFAULT ''", "CREATE INDEX IF NOT EXISTS logs_run_id_index ON logs (run_id);"},
+	{"logs", "hostname", "TEXT NOT NULL DEFAULT ''", "CREATE INDEX IF NOT EXISTS logs_hostname_index ON logs (hostname);"},
+	{"logs", "pid", "INTEGER NOT NULL DEFAULT 0", "CREATE INDEX IF NOT EXISTS logs_pid_index ON logs (pid);"},
+	{"logs", "goroutine", "INTEGER NOT NULL DEFAULT 0", ""},
+	{"runs", "revision", "TEXT NOT NULL DEFAULT ''", "CREATE INDEX IF NOT EXISTS runs_revision_index ON runs (revision);"},
+	{"runs", "dirty", "INTEGER NOT NULL DEFAULT 0", ""},
+	{"logs", "count", "INTEGER NOT NULL DEFAULT 1", ""},
+	{"logs", "correlation", "TEXT NOT NULL DEFAULT ''", "CREATE INDEX IF NOT EXISTS logs_correlation_index ON logs (correlation);"},
+}
+
+// indexes lists the indexes on more than one of the columns added after the first release,
+// they are created once the columns exist
+var indexes = []string{
+	// the lookup of the duplicates of a log in the window of Dedup (see findDuplicateQuery)
+	"CREATE INDEX IF NOT EXISTS logs_dedup_index ON logs (caller_file, caller_line, datetime(timestamp));",
+}
+
 const defaultQuery = `
-SELECT DISTINCT logs.id, logs.level, logs.caller_file, logs.caller_line, logs.caller_function, logs.message, logs.time
+SELECT DISTINCT logs.id, logs.level, logs.caller_file, logs.caller_line, logs.caller_function, logs.message, logs.time, logs.timestamp, logs.fields, logs.run_id, logs.hostname, logs.pid, logs.goroutine, logs.count, logs.correlation
 FROM logs
-INNER JOIN log_tags ON logs.id = log_tags.log_id
-INNER JOIN tags ON log_tags.tag_id = tags.id
+LEFT JOIN log_tags ON logs.id = log_tags.log_id
+LEFT JOIN tags ON log_tags.tag_id = tags.id
 `
 
+// instantColumn is the SQL expression of the UTC time of a log, used to compare the instants
+// the logs without the offset timestamp are converted from the local time
+const instantColumn = `(CASE WHEN logs.timestamp != '' THEN datetime(logs.timestamp) ELSE datetime(logs.time, 'utc') END)`
+
+// QueryOption represents an option to filter, sort or limit the logs
+// returned by a query, it appends its SQL clause to the base query
 type QueryOption func(*strings.Builder)
 
-func getDBConnection(folderPath string) (*sql.DB, error) {
+// openDBConnection opens the database of the store passed, creating the file if it
+// doesn't exist, and creates or migrates its schema (once per process and database)
+// the connections are shared by the stores through getDBConnection, the pool must be locked
+func openDBConnection(s *sqliteStore) (*sql.DB, error) {
 	var db *sql.DB
 	var err error
 
-	dbFilePath := filepath.Join(folderPath, "logs_data.db")
+	dbFilePath := s.dbPath()
 	_, err = os.Stat(dbFilePath)
 
 	if os.IsNotExist(err) {
@@ -76,117 +176,520 @@ func getDBConnection(folderPath string) (*sql.DB, error) {
 		return nil, errors.New("[logger-pkg] failed to check the logs database file: " + err.Error())
 	}
 
-	db, err = sql.Open("sqlite3", dbFilePath)
+	db, err = sql.Open("sqlite3", dbFilePath+s.dsnParams())
 	if err != nil {
 		return nil, errors.New("[logger-pkg] failed to open the logs database: " + err.Error())
 	}
 
 	err = db.Ping()
 	if err != nil {
+		db.Close()
 		return nil, errors.New("[logger-pkg] failed to get a connection to the logs database: " + err.Error())
 	}
 
-	tx, err := db.Begin()
+	version, err := getSchemaVersion(db)
 	if err != nil {
-		return nil, errors.New("[logger-pkg] failed to generate the logs table: " + err.Error())
+		db.Close()
+		return nil, err
 	}
 
-	_, err = tx.Exec(table)
-	if err != nil {
-		tx.Rollback()
-		return nil, errors.New("[logger-pkg] failed to generate the logs table: " + err.Error())
+	if version > schemaVersion {
+		if s.compat {
+			// the newer schema is never migrated, the database is only read
+			return db, nil
+		}
+
+		db.Close()
+		return nil, fmt.Errorf("%w (database version %d, supported version %d)", ErrNewerSchema, version, schemaVersion)
 	}
 
-	err = tx.Commit()
+	if version == schemaVersion && pool.migrated[dbFilePath] {
+		// the schema was already ensured by this process, it's not created again
+		return db, nil
+	}
+
+	err = migrateSchema(db, version, s.encryption)
 	if err != nil {
-		tx.Rollback()
-		return nil, errors.New("[logger-pkg] failed to generate the logs table: " + err.Error())
+		db.Close()
+		return nil, err
 	}
 
+	pool.migrated[dbFilePath] = true
 	return db, nil
 }
 
-func createNewLog(opts *Logger, log *log) error {
-	db, err := getDBConnection(opts.folderPath)
+// migrateSchema creates the tables of the database and migrates the ones
+// created by the older versions of the package (version is the current one),
+// the encryption passed decrypts the logs whose checksums are computed again
+func migrateSchema(db *sql.DB, version int, enc encryption) error {
+	tx, err := db.Begin()
 	if err != nil {
-		return err
+		return errors.New("[logger-pkg] failed to generate the logs table: " + err.Error())
 	}
-	defer db.Close()
 
-	tx, err := db.Begin()
+	_, err = tx.Exec(table)
 	if err != nil {
-		return errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+		tx.Rollback()
+		return errors.New("[logger-pkg] failed to generate the logs table: " + err.Error())
 	}
 
-	logstmt, err := tx.Prepare("INSERT INTO logs (level, caller_file, caller_line, caller_function, message) VALUES (?, ?, ?, ?, ?);")
+	err = migrateColumns(tx)
 	if err != nil {
-		return errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+		tx.Rollback()
+		return errors.New("[logger-pkg] failed to migrate the logs table: " + err.Error())
 	}
-	defer logstmt.Close()
 
-	result, err := logstmt.Exec(int(log.level), log.callerFile, log.callerLine, log.callerFunction, log.message)
+	err = migrateFullTextSearch(tx)
 	if err != nil {
 		tx.Rollback()
-		return errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+		return errors.New("[logger-pkg] failed to generate the full-text index: " + err.Error())
 	}
 
-	logId, err := result.LastInsertId()
-	if err != nil || logId < 1 {
+	if version < 9 {
+		// the levels of v1 were numbered from 0 to 4, v2 spaces them to make room for Trace,
+		// Notice and the custom levels; the migration is one-way (see LogLevel)
+		_, err = tx.Exec("UPDATE logs SET level = level * 10;")
+		if err != nil {
+			tx.Rollback()
+			return errors.New("[logger-pkg] failed to migrate the levels of the logs: " + err.Error())
+		}
+	}
+
+	if version < 11 {
+		err = migrateHashes(tx, enc)
+		if err != nil {
+			tx.Rollback()
+			return errors.New("[logger-pkg] failed to migrate the checksums of the logs: " + err.Error())
+		}
+	}
+
+	if version < schemaVersion {
+		_, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d;", schemaVersion))
+		if err != nil {
+			tx.Rollback()
+			return errors.New("[logger-pkg] failed to set the schema version: " + err.Error())
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
 		tx.Rollback()
-		return errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+		return errors.New("[logger-pkg] failed to generate the logs table: " + err.Error())
 	}
 
-	if len(log.tags) > 0 {
-		for _, tag := range log.tags {
-			tagstmt, err := tx.Prepare("INSERT OR IGNORE INTO tags (name) VALUES (?);")
+	return nil
+}
+
+// hashBatchSize is the number of logs whose checksums are computed again by a single query of migrateHashes
+const hashBatchSize = 1000
+
+// migrateHashes computes again the checksums of the logs stored by the older versions of the package,
+// the oldest logs have no checksum and the others hash the local time of the logs instead of their instant
+func migrateHashes(tx *sql.Tx, enc encryption) error {
+	var last int64
+	for {
+		query := fmt.Sprintf("%s WHERE logs.id > %d ORDER BY logs.id LIMIT %d", defaultQuery, last, hashBatchSize)
+		logs, err := scanLogs(context.Background(), tx, enc, 0, query)
+		if err != nil || len(logs) == 0 {
+			return err
+		}
+
+		for _, l := range logs {
+			_, err = tx.Exec("UPDATE logs SET hash = ? WHERE id = ?;", l.checksum(), l.id)
 			if err != nil {
-				return errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+				return err
 			}
-			defer tagstmt.Close()
+			last = l.id
+		}
+	}
+}
+
+// getWritableDBConnection returns a connection to the database like getDBConnection
+// it returns ErrReadOnly if the database is newer than the package and it is
+// opened in the read-only compatibility mode
+func getWritableDBConnection(s *sqliteStore) (*sql.DB, error) {
+	db, err := getDBConnection(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.compat {
+		return db, nil
+	}
+
+	version, err := getSchemaVersion(db)
+	if err != nil {
+		releaseDBConnection(s, db)
+		return nil, err
+	}
+
+	if version > schemaVersion {
+		releaseDBConnection(s, db)
+		return nil, ErrReadOnly
+	}
+
+	return db, nil
+}
+
+// getSchemaVersion returns the version of the schema stored in the database
+func getSchemaVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("PRAGMA user_version;").Scan(&version)
+	if err != nil {
+		return 0, errors.New("[logger-pkg] failed to read the schema version: " + err.Error())
+	}
+	return version, nil
+}
 
-			_, err = tagstmt.Exec(tag)
+// migrateColumns adds to the tables the columns that are missing
+// because the database was created with an older version of the package
+// and creates the indexes on them
+func migrateColumns(tx *sql.Tx) error {
+	existing := make(map[string]bool)
+	for _, c := range columns {
+		if _, ok := existing[c.table+"."]; !ok {
+			rows, err := tx.Query("SELECT name FROM pragma_table_info(?);", c.table)
 			if err != nil {
-				tx.Rollback()
-				return errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+				return err
 			}
 
-			linkstmt, err := tx.Prepare("INSERT INTO log_tags (log_id, tag_id) VALUES (?, (SELECT id FROM tags WHERE name = ?));")
+			for rows.Next() {
+				var name string
+				if err := rows.Scan(&name); err != nil {
+					rows.Close()
+					return err
+				}
+				existing[c.table+"."+name] = true
+			}
+			rows.Close()
+			existing[c.table+"."] = true
+		}
+
+		if !existing[c.table+"."+c.name] {
+			_, err := tx.Exec("ALTER TABLE " + c.table + " ADD COLUMN " + c.name + " " + c.definition + ";")
 			if err != nil {
-				return errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+				return err
 			}
-			defer linkstmt.Close()
+			existing[c.table+"."+c.name] = true
+		}
 
-			_, err = linkstmt.Exec(logId, tag)
+		if c.index != "" {
+			_, err := tx.Exec(c.index)
 			if err != nil {
-				tx.Rollback()
-				return errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+				return err
 			}
 		}
 	}
 
+	for _, index := range indexes {
+		_, err := tx.Exec(index)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createNewLog(ctx context.Context, s *sqliteStore, log *log) (int64, error) {
+	db, err := getWritableDBConnection(s)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseDBConnection(s, db)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+	}
+
+	err = registerRun(tx, s)
+	if err != nil {
+		tx.Rollback()
+		return 0, errors.New("[logger-pkg] failed to save the run: " + err.Error())
+	}
+
+	var logId int64
+	if s.dedup > 0 {
+		logId, err = mergeDuplicate(tx, log, s.dedup)
+		if err != nil {
+			tx.Rollback()
+			return 0, errors.New("[logger-pkg] failed to deduplicate the log: " + err.Error())
+		}
+	}
+
+	if logId == 0 {
+		logId, err = insertLog(tx, getStatements(db), s.encryption, log)
+		if err != nil {
+			tx.Rollback()
+			return 0, errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+		}
+
+		err = insertOverflow(tx, s.encryption, logId, log)
+		if err != nil {
+			tx.Rollback()
+			return 0, errors.New("[logger-pkg] failed to save the overflow of the log: " + err.Error())
+		}
+
+		err = insertGoroutines(tx, s.encryption, logId, log)
+		if err != nil {
+			tx.Rollback()
+			return 0, errors.New("[logger-pkg] failed to save the goroutine dump of the log: " + err.Error())
+		}
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		tx.Rollback()
-		return errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+		return 0, errors.New("[logger-pkg] failed to create a new log: " + err.Error())
+	}
+	markRunRegistered(s)
+
+	return logId, nil
+}
+
+// the statements inserting the logs
+const (
+	insertLogQuery    = "INSERT INTO logs (level, caller_file, caller_line, caller_function, message, time, timestamp, fields, run_id, hostname, pid, goroutine, count, hash, correlation) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);"
+	importLogQuery    = "INSERT INTO logs (level, caller_file, caller_line, caller_function, message, time, timestamp, fields, run_id, hostname, pid, goroutine, count, hash, correlation) SELECT ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14, ?15 WHERE NOT EXISTS (SELECT 1 FROM logs WHERE hash = ?14);"
+	insertTagQuery    = "INSERT OR IGNORE INTO tags (name) VALUES (?);"
+	insertLogTagQuery = "INSERT OR IGNORE INTO log_tags (log_id, tag_id) VALUES (?, (SELECT id FROM tags WHERE name = ?));"
+)
+
+// insertLog inserts the log and its tags in the database using the transaction
+// and the prepared statements passed (if nil the statements are prepared by the transaction)
+// the message and the fields are encrypted with the encryption passed
+// it returns the id of the inserted log
+func insertLog(tx *sql.Tx, stmts statements, enc encryption, log *log) (int64, error) {
+	return insertLogWith(tx, stmts, enc, insertLogQuery, log)
+}
+
+// importLog inserts the log and its tags like insertLog, unless a log with the same checksum
+// is already stored, it returns the id of the inserted log or 0 if the log is skipped
+func importLog(tx *sql.Tx, stmts statements, enc encryption, log *log) (int64, error) {
+	return insertLogWith(tx, stmts, enc, importLogQuery, log)
+}
+
+// insertLogWith inserts the log and its tags with the insert query passed
+// it returns the id of the inserted log or 0 if the query doesn't insert it
+func insertLogWith(tx *sql.Tx, stmts statements, enc encryption, query string, log *log) (int64, error) {
+	result, err := stmts.exec(tx, query,
+		int(log.level), log.callerFile, log.callerLine, log.callerFunction, enc.seal(log.message), log.timestamp.String(), log.timestamp.rfc3339(), enc.seal(marshalFields(log.fields)), log.runID, log.hostname, log.pid, log.goroutine, log.occurrences(), log.checksum(), log.correlation,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil || inserted == 0 {
+		return 0, err
+	}
+
+	logId, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if logId < 1 {
+		return 0, errors.New("invalid log id")
+	}
+
+	for _, tag := range log.tags {
+		_, err = stmts.exec(tx, insertTagQuery, tag)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = stmts.exec(tx, insertLogTagQuery, logId, tag)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return logId, nil
+}
+
+// getMetadata returns the value of the metadata key passed
+// it returns an empty string if the key is not set
+func getMetadata(ctx context.Context, db *sql.DB, key string) (string, error) {
+	var value string
+	err := db.QueryRowContext(ctx, "SELECT value FROM metadata WHERE key = ?;", key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", errors.New("[logger-pkg] failed to read the metadata: " + err.Error())
+	}
+
+	return value, nil
+}
+
+// setMetadata sets the value of the metadata key passed
+// an empty value removes the key
+func setMetadata(ctx context.Context, s *sqliteStore, key, value string) error {
+	db, err := getWritableDBConnection(s)
+	if err != nil {
+		return err
+	}
+	defer releaseDBConnection(s, db)
+
+	if value == "" {
+		_, err = db.ExecContext(ctx, "DELETE FROM metadata WHERE key = ?;", key)
+	} else {
+		_, err = db.ExecContext(ctx, "INSERT INTO metadata (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value;", key, value)
+	}
+
+	if err != nil {
+		return errors.New("[logger-pkg] failed to write the metadata: " + err.Error())
 	}
 
 	return nil
 }
 
-func queryLogs(opts *Logger, configs ...QueryOption) ([]*log, error) {
-	db, err := getDBConnection(opts.folderPath)
+// getClockOffset returns the clock offset stored in the metadata of the database
+// it returns 0 if the offset is not set
+func getClockOffset(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	value, err := getMetadata(ctx, db, clockOffsetKey)
+	if err != nil || value == "" {
+		return 0, err
+	}
+
+	offset, err := time.ParseDuration(value)
 	if err != nil {
-		return nil, err
+		return 0, errors.New("[logger-pkg] failed to parse the clock offset: " + err.Error())
+	}
+
+	return offset, nil
+}
+
+// importLogs inserts the logs passed in the database skipping the ones
+// that are already stored (same checksum), it returns the number of imported logs
+func importLogs(ctx context.Context, s *sqliteStore, logs []*log) (int, error) {
+	db, err := getWritableDBConnection(s)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseDBConnection(s, db)
+
+	stmts := getStatements(db)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.New("[logger-pkg] failed to import the logs: " + err.Error())
 	}
-	defer db.Close()
+
+	imported := 0
+	for _, log := range logs {
+		logId, err := importLog(tx, stmts, s.encryption, log)
+		if err != nil {
+			tx.Rollback()
+			return 0, errors.New("[logger-pkg] failed to import the logs: " + err.Error())
+		}
+
+		if logId > 0 {
+			imported++
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return 0, errors.New("[logger-pkg] failed to import the logs: " + err.Error())
+	}
+
+	return imported, nil
+}
+
+// buildQuery returns the select query of the logs built with the query options passed
+// the part appended by the options is validated, the base query can't be changed
+func buildQuery(configs ...QueryOption) (string, error) {
 	query := new(strings.Builder)
 	query.WriteString(defaultQuery)
 	for _, config := range configs {
 		config(query)
 	}
-	query.WriteString(";")
 
-	rows, err := db.Query(query.String())
+	tail, ok := strings.CutPrefix(query.String(), defaultQuery)
+	if !ok {
+		return "", fmt.Errorf("%w: the query options can't change the base query", ErrInvalidQuery)
+	}
+
+	err := validateQueryTail(tail)
+	if err != nil {
+		return "", err
+	}
+	return query.String(), nil
+}
+
+func selectLogs(ctx context.Context, s *sqliteStore, configs ...QueryOption) ([]*log, error) {
+	if paths := s.readPaths(); len(paths) > 0 {
+		return selectFederated(ctx, s, paths, configs...)
+	}
+
+	db, err := getDBConnection(s)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseDBConnection(s, db)
+
+	offset, err := getClockOffset(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := buildQuery(configs...)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err = expandSavedQueries(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logSQL(query)
+	return scanLogs(ctx, db, s.encryption, offset, query)
+}
+
+// countLogs returns the number of logs selected by the query options passed in the database of the store,
+// without reading them; the store must read only its database (see readPaths)
+func countLogs(ctx context.Context, s *sqliteStore, configs ...QueryOption) (int, error) {
+	db, err := getDBConnection(s)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseDBConnection(s, db)
+
+	query, err := buildQuery(configs...)
+	if err != nil {
+		return 0, err
+	}
+
+	query, err = expandSavedQueries(ctx, db, query)
+	if err != nil {
+		return 0, err
+	}
+
+	query = "SELECT COUNT(*) FROM (" + query + ")"
+	s.logSQL(query)
+
+	var count int
+	err = db.QueryRowContext(ctx, query+";").Scan(&count)
+	if err != nil {
+		return 0, errors.New("[logger-pkg] failed to count the logs: " + err.Error())
+	}
+	return count, nil
+}
+
+// queryer runs the queries of the logs, it is implemented by *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// scanLogs runs the select query passed on the database (or the transaction) and returns the logs
+// with their tags, the clock offset passed is added to the times and the encryption passed decrypts the logs
+func scanLogs(ctx context.Context, db queryer, enc encryption, offset time.Duration, query string) ([]*log, error) {
+	rows, err := db.QueryContext(ctx, query+";")
 	if err != nil {
 		return nil, errors.New("[logger-pkg] failed to query the logs: " + err.Error())
 	}
@@ -194,52 +697,181 @@ func queryLogs(opts *Logger, configs ...QueryOption) ([]*log, error) {
 
 	var logs []*log
 	for rows.Next() {
-		var id, level, callerLine int
-		var callerFile, callerFunction, message, time string
+		var id int64
+		var level, callerLine, pid, count int
+		var goroutine int64
+		var callerFile, callerFunction, message, storedTime, storedTimestamp, fields, runID, hostname, correlation string
 
-		err = rows.Scan(&id, &level, &callerFile, &callerLine, &callerFunction, &message, &time)
+		err = rows.Scan(&id, &level, &callerFile, &callerLine, &callerFunction, &message, &storedTime, &storedTimestamp, &fields, &runID, &hostname, &pid, &goroutine, &count, &correlation)
 		if err != nil {
 			return nil, errors.New("[logger-pkg] failed to scan the logs: " + err.Error())
 		}
 
-		tags, err := getTagsForLog(db, id)
-		if err != nil {
-			return nil, errors.New("[logger-pkg] failed to get the tags for the logs: " + err.Error())
-		}
-
 		logs = append(logs, &log{
+			id:             id,
 			level:          LogLevel(level),
-			tags:           tags,
 			callerFile:     callerFile,
 			callerLine:     callerLine,
 			callerFunction: callerFunction,
-			message:        message,
-			timestamp:      newTimestamp(time),
+			message:        enc.open(message),
+			timestamp:      timestamp(time.Time(parseStoredTimestamp(storedTime, storedTimestamp)).Add(offset)),
+			fields:         unmarshalFields(enc.open(fields)),
+			runID:          runID,
+			hostname:       hostname,
+			pid:            pid,
+			goroutine:      goroutine,
+			count:          count,
+			correlation:    correlation,
 		})
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, errors.New("[logger-pkg] failed to query the logs: " + err.Error())
+	}
+	rows.Close()
+
+	ids := make([]int64, 0, len(logs))
+	for _, l := range logs {
+		ids = append(ids, l.id)
+	}
+
+	tags, err := getTagsForLogs(ctx, db, ids)
+	if err != nil {
+		return nil, errors.New("[logger-pkg] failed to get the tags for the logs: " + err.Error())
+	}
+
+	for _, l := range logs {
+		l.tags = tags[l.id]
+	}
+
 	return logs, nil
 }
 
-func getTagsForLog(db *sql.DB, logId int) ([]string, error) {
-	tags := make([]string, 0)
-	rows, err := db.Query("SELECT tags.name FROM tags INNER JOIN log_tags ON tags.id = log_tags.tag_id WHERE log_tags.log_id = ?", logId)
+// deleteLogs deletes the logs selected by the query options passed and the tags
+// links left without a log, it returns the number of deleted logs
+func deleteLogs(ctx context.Context, s *sqliteStore, configs ...QueryOption) (int64, error) {
+	db, err := getWritableDBConnection(s)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
+	defer releaseDBConnection(s, db)
 
-	for rows.Next() {
-		var tag string
-		if err := rows.Scan(&tag); err != nil {
-			return nil, err
-		}
-		tags = append(tags, tag)
+	query, err := buildQuery(configs...)
+	if err != nil {
+		return 0, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+	query, err = expandSavedQueries(ctx, db, query)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.New("[logger-pkg] failed to delete the logs: " + err.Error())
+	}
+
+	s.logSQL(deleteQuery(query))
+	deleted, err := execDeleteLogs(ctx, tx, query)
+	if err != nil {
+		tx.Rollback()
+		return 0, errors.New("[logger-pkg] failed to delete the logs: " + err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return 0, errors.New("[logger-pkg] failed to delete the logs: " + err.Error())
+	}
+
+	return deleted, nil
+}
+
+// deleteQuery returns the statement deleting the logs selected by the query passed
+func deleteQuery(query string) string {
+	return "DELETE FROM logs WHERE id IN (SELECT id FROM (" + query + "))"
+}
+
+// execDeleteLogs deletes the logs selected by the query passed and the tags links, the overflows,
+// the goroutine dumps and the attachments
+// left without a log in the transaction passed, it returns the number of deleted logs
+func execDeleteLogs(ctx context.Context, tx *sql.Tx, query string) (int64, error) {
+	result, err := tx.ExecContext(ctx, deleteQuery(query)+";")
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM log_tags WHERE log_id NOT IN (SELECT id FROM logs);")
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM log_overflow WHERE log_id NOT IN (SELECT id FROM logs);")
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM log_goroutines WHERE log_id NOT IN (SELECT id FROM logs);")
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM log_attachments WHERE log_id NOT IN (SELECT id FROM logs);")
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// tagsBatchSize is the maximum number of logs whose tags are loaded by a single query,
+// it keeps the number of the query parameters under the SQLite limit
+const tagsBatchSize = 500
+
+// getTagsForLogs returns the tags of the logs with the ids passed, by log id
+// the tags are loaded with a query every tagsBatchSize logs instead of one query per log
+// every log passed has an entry, empty if the log has no tags
+func getTagsForLogs(ctx context.Context, db queryer, ids []int64) (map[int64][]string, error) {
+	result := make(map[int64][]string, len(ids))
+	for _, id := range ids {
+		result[id] = make([]string, 0)
+	}
+
+	for start := 0; start < len(ids); start += tagsBatchSize {
+		batch := ids[start:min(start+tagsBatchSize, len(ids))]
+		args := make([]any, 0, len(batch))
+		for _, id := range batch {
+			args = append(args, id)
+		}
+
+		query := "SELECT log_tags.log_id, tags.name FROM tags INNER JOIN log_tags ON tags.id = log_tags.tag_id WHERE log_tags.log_id IN (?" +
+			strings.Repeat(", ?", len(batch)-1) + ") ORDER BY log_tags.rowid;"
+		err := func() error {
+			rows, err := db.QueryContext(ctx, query, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var id int64
+				var tag string
+				if err := rows.Scan(&id, &tag); err != nil {
+					return err
+				}
+				result[id] = append(result[id], tag)
+			}
+			return rows.Err()
+		}()
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return tags, nil
+	return result, nil
 }
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// the filters of the messages whose terms are highlighted in the printed logs:
+// the LIKE patterns of MessageLike and Search and the full-text queries of Search (sqlite_fts5)
+var (
+	messageLikePattern  = regexp.MustCompile(`(NOT\s+)?logs\.message LIKE '((?:[^']|'')*)'`)
+	messageMatchPattern = regexp.MustCompile(`logs_fts MATCH '((?:[^']|'')*)'`)
+)
+
+// the operators of the full-text queries, they are not highlighted
+var matchOperators = map[string]bool{"AND": true, "OR": true, "NOT": true, "NEAR": true}
+
+// highlightStyle is the style of the matches of the search filters in the printed messages
+var highlightStyle = lipgloss.NewStyle().Reverse(true)
+
+// searchTerms returns the terms searched in the messages by the query options passed
+// (MessageLike and Search filters), they are highlighted in the printed logs
+func searchTerms(queryOptions ...QueryOption) []string {
+	query := new(strings.Builder)
+	for _, config := range queryOptions {
+		config(query)
+	}
+	tail := query.String()
+
+	terms := make([]string, 0)
+	for _, m := range messageLikePattern.FindAllStringSubmatch(tail, -1) {
+		if m[1] != "" {
+			continue
+		}
+
+		term := strings.Trim(strings.ReplaceAll(m[2], "''", "'"), "%")
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	for _, m := range messageMatchPattern.FindAllStringSubmatch(tail, -1) {
+		text := strings.ReplaceAll(m[1], "''", "'")
+		for i, part := range strings.Split(text, `"`) {
+			if i%2 == 1 {
+				if part = strings.TrimSpace(part); part != "" {
+					terms = append(terms, part)
+				}
+				continue
+			}
+
+			for _, word := range strings.Fields(part) {
+				word = strings.Trim(word, "*()")
+				if word != "" && !matchOperators[word] {
+					terms = append(terms, word)
+				}
+			}
+		}
+	}
+	return terms
+}
+
+// highlight returns the message passed with the terms (case-insensitive) highlighted
+func highlight(message string, terms []string) string {
+	if len(terms) == 0 {
+		return message
+	}
+
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+
+	re, err := regexp.Compile("(?i)" + strings.Join(quoted, "|"))
+	if err != nil {
+		return message
+	}
+	return re.ReplaceAllStringFunc(message, func(match string) string {
+		return highlightStyle.Render(match)
+	})
+}
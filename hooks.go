@@ -0,0 +1,198 @@
+package logger
+
+import "slices"
+
+// RenderHook customizes how a log is printed in the console
+// it receives a copy of the log and the width available to print it,
+// and returns the rendered log and true, or false to print the log
+// with the default renderer
+type RenderHook func(entry Entry, width int) (string, bool)
+
+// RenderTag registers a render hook for the logs with the tag passed
+// the hook is used by every printing method (PrintLogs, PrintInfo, LogInfo, ...)
+// in inline and block mode, a nil hook removes the hook of the tag
+// if a log has more tags with a hook, the hook of its first tag is used,
+// the tag hooks have priority over the level hooks
+// Example:
+//
+//	log.RenderTag("http", func(e logger.Entry, width int) (string, bool) {
+//		var method, url, status string
+//		if _, err := fmt.Sscanf(e.Message, "%s %s -> %s", &method, &url, &status); err != nil {
+//			return "", false
+//		}
+//		return fmt.Sprintf("%-7s %-60s %s", method, url, status), true
+//	})
+func (opts *Logger) RenderTag(tag string, hook RenderHook) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	if hook == nil {
+		delete(opts.tagHooks, tag)
+		return
+	}
+
+	if opts.tagHooks == nil {
+		opts.tagHooks = make(map[string]RenderHook)
+	}
+	opts.tagHooks[tag] = hook
+}
+
+// RenderLevel registers a render hook for the logs with the level passed
+// the hook is used by every printing method (PrintLogs, PrintInfo, LogInfo, ...)
+// in inline and block mode, a nil hook removes the hook of the level
+// Example:
+//
+//	log.RenderLevel(logger.Debug, func(e logger.Entry, width int) (string, bool) {
+//		return "· " + e.Message, true
+//	})
+func (opts *Logger) RenderLevel(level LogLevel, hook RenderHook) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	if hook == nil {
+		delete(opts.levelHooks, level)
+		return
+	}
+
+	if opts.levelHooks == nil {
+		opts.levelHooks = make(map[LogLevel]RenderHook)
+	}
+	opts.levelHooks[level] = hook
+}
+
+// renderHooks returns the logs rendered by the hooks of the logger, by index
+// the logs without a hook, or whose hook refused to render them, are not in the result
+func (opts *Logger) renderHooks(w int, logs []*log) map[int]string {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+
+	result := make(map[int]string)
+	if len(opts.tagHooks) == 0 && len(opts.levelHooks) == 0 {
+		return result
+	}
+
+	for i, l := range logs {
+		hook := opts.levelHooks[l.level]
+		for _, tag := range l.tags {
+			if h, ok := opts.tagHooks[tag]; ok {
+				hook = h
+				break
+			}
+		}
+
+		if hook == nil {
+			continue
+		}
+
+		if s, ok := hook(l.entry(), w); ok {
+			result[i] = s
+		}
+	}
+	return result
+}
+
+// Hook is an extension point of the logs created by the logger (with Log, Info, Error, ...)
+// The hook must implement the following methods:
+//   - Levels: returns the levels of the logs passed to the hook, if empty every level is passed
+//   - BeforeWrite: inspects and modifies the log before it is stored (e.g. adds fields, redacts the message),
+//     if it returns an error the log is not stored and the error is returned by the log method
+//   - AfterWrite: reacts to the log stored (e.g. pushes it to an external system), it receives the id of the log
+type Hook interface {
+	Levels() []LogLevel
+	BeforeWrite(entry *Entry) error
+	AfterWrite(entry Entry)
+}
+
+// HookFuncs is a Hook made of functions, the nil functions are skipped
+// Example:
+//
+//	log.AddHook(logger.HookFuncs{
+//		LevelList: []logger.LogLevel{logger.Error, logger.Fatal},
+//		Before: func(e *logger.Entry) error {
+//			e.Fields = map[string]any{"release": release}
+//			return nil
+//		},
+//	})
+type HookFuncs struct {
+	LevelList []LogLevel         // the levels of the logs passed to the hook, if empty every level is passed
+	Before    func(*Entry) error // called before the log is stored
+	After     func(Entry)        // called after the log is stored
+}
+
+// Levels returns the levels of the logs passed to the hook
+func (h HookFuncs) Levels() []LogLevel {
+	return h.LevelList
+}
+
+// BeforeWrite calls the Before function, if set
+func (h HookFuncs) BeforeWrite(entry *Entry) error {
+	if h.Before == nil {
+		return nil
+	}
+	return h.Before(entry)
+}
+
+// AfterWrite calls the After function, if set
+func (h HookFuncs) AfterWrite(entry Entry) {
+	if h.After != nil {
+		h.After(entry)
+	}
+}
+
+// AddHook adds a hook to the logs created by the logger, the hooks are called
+// in the order they are added and only for the logs of their levels
+// the logs dropped by the minimum level (see Level) are not passed to the hooks,
+// the copies and the children of the logger inherit its hooks
+func (opts *Logger) AddHook(hook Hook) {
+	if hook == nil {
+		return
+	}
+
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.hooks = append(opts.hooks, hook)
+}
+
+// getHooks returns the hooks of the logger for the level passed
+func (opts *Logger) getHooks(level LogLevel) []Hook {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+
+	result := make([]Hook, 0, len(opts.hooks))
+	for _, h := range opts.hooks {
+		levels := h.Levels()
+		if len(levels) == 0 || slices.Contains(levels, level) {
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+// beforeWrite passes the log to the BeforeWrite method of the hooks passed
+// and returns the log modified by them, with the goroutine dump of the log passed
+func beforeWrite(hooks []Hook, l *log) (*log, error) {
+	if len(hooks) == 0 {
+		return l, nil
+	}
+
+	e := l.entry()
+	for _, h := range hooks {
+		if err := h.BeforeWrite(&e); err != nil {
+			return nil, err
+		}
+	}
+	hooked := fromEntry(e)
+	hooked.goroutines = l.goroutines
+	return hooked, nil
+}
+
+// afterWrite passes the log stored with the id passed to the AfterWrite method of the hooks passed
+func afterWrite(hooks []Hook, l *log, id int64) {
+	if len(hooks) == 0 {
+		return
+	}
+
+	e := l.entry()
+	e.ID = id
+	for _, h := range hooks {
+		h.AfterWrite(e)
+	}
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sensitiveHeaders lists the request headers whose values are redacted in the logs
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+}
+
+// loggingTransport is the http.RoundTripper that logs the outbound requests
+type loggingTransport struct {
+	logger *Logger
+	next   http.RoundTripper
+}
+
+// RoundTripper returns an http.RoundTripper that logs every outbound request
+// sent through the transport passed (if nil it uses http.DefaultTransport)
+// every request creates a log in the database tagged with "http" that contains
+// the method, the URL, the status and the latency of the request followed by the request headers
+// the credentials in the URL and the values of the authentication headers
+// (Authorization, Proxy-Authorization, Cookie, X-Api-Key, X-Auth-Token) are redacted
+// the level of the log depends on the result of the request:
+//   - Info: the request succeeded with a status lower than 400
+//   - Warning: the request succeeded with a 4xx status
+//   - Error: the request failed or the response has a 5xx status
+//
+// Example:
+//
+//	client := &http.Client{Transport: log.RoundTripper(nil)}
+func (opts *Logger) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &loggingTransport{logger: opts, next: next}
+}
+
+// RoundTrip executes the request with the wrapped transport and logs its result
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	latency := time.Since(start)
+
+	var level LogLevel
+	var result string
+	switch {
+	case err != nil:
+		level = Error
+		result = err.Error()
+	case res.StatusCode >= 500:
+		level = Error
+		result = res.Status
+	case res.StatusCode >= 400:
+		level = Warning
+		result = res.Status
+	default:
+		level = Info
+		result = res.Status
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s -> %s (%s)", req.Method, req.URL.Redacted(), result, latency.Round(time.Millisecond)))
+	for _, header := range requestHeaders(req.Header) {
+		b.WriteString("\n")
+		b.WriteString(header)
+	}
+
+	tags := append(t.logger.getTags(), "http")
+	l, logErr := newLog(level, tags, b.String())
+	if logErr == nil {
+		t.logger.writeLog(l)
+	}
+
+	return res, err
+}
+
+// requestHeaders returns the headers passed as sorted "Name: value" lines
+// with the values of the sensitive headers redacted
+func requestHeaders(header http.Header) []string {
+	lines := make([]string, 0, len(header))
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			value = "[REDACTED]"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", name, value))
+	}
+	sort.Strings(lines)
+	return lines
+}
@@ -0,0 +1,294 @@
+// Package batch buffers the logs pushed to the remote sinks (loki, otlp, ...)
+// and sends them in batches from a background goroutine, retrying the failed
+// batches with a doubling wait, so a slow or unreachable backend doesn't slow down the logging
+// with a spool folder (see Batcher.Spool) the failed batches are saved on disk
+// and sent again when the backend is reachable, also after a restart of the process
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tagliapietra96/logger/v2"
+)
+
+// defaults of the batcher
+const (
+	DefaultSize     = 500             // the number of logs that triggers a send
+	DefaultInterval = 5 * time.Second // how often the buffered logs are sent
+	maxBuffered     = 10000           // the logs kept while the backend fails, the oldest ones are dropped
+	maxBackoff      = 5 * time.Minute // the maximum wait after a failure
+)
+
+// SendFunc sends a batch of logs to the backend
+type SendFunc func(ctx context.Context, entries []logger.Entry) error
+
+// Batcher buffers the logs and sends them with its SendFunc when the buffer
+// reaches the size or every interval, the batch is kept and sent again after a failure
+type Batcher struct {
+	send     SendFunc
+	sending  sync.Mutex // serializes the sends, so a batch is not sent twice
+	mu       sync.Mutex
+	size     int
+	interval time.Duration
+	buffer   []logger.Entry
+	dropped  int64  // the logs dropped because the buffer was full
+	removed  int64  // the logs removed from the start of the buffer (sent, spooled or dropped)
+	spool    string // the folder of the batches waiting to be sent again, if empty they are kept in memory
+	spooled  int64  // the sequence of the spool files created by the process
+	lastErr  error  // the error of the last send, nil if it succeeded
+	wake     chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	once     sync.Once
+}
+
+// New returns a Batcher sending the logs with the function passed
+// and starts its background goroutine, Close stops it
+func New(send SendFunc, size int, interval time.Duration) *Batcher {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	b := &Batcher{
+		send:     send,
+		size:     size,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Spool sets the folder where the batches that failed to be sent are saved,
+// they are sent again, oldest first, before the new logs, so no log is lost
+// while the backend is unreachable or when the process exits before it is back
+// the batches saved in the folder by a previous run are sent too
+// the logs are sent at least once: a batch may be sent again if the process stops while sending it
+// this method returns an error if it fails to create the folder
+func (b *Batcher) Spool(folder string) error {
+	err := os.MkdirAll(folder, 0o755)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to create the spool folder: " + err.Error())
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.spool = folder
+	return nil
+}
+
+// Add buffers the entry passed, the buffer is sent when it reaches the size of the batches
+// if the buffer is full (the backend is failing) it is saved in the spool folder,
+// or the oldest log is dropped without a spool folder
+func (b *Batcher) Add(e logger.Entry) {
+	b.mu.Lock()
+	if len(b.buffer) >= maxBuffered {
+		if b.spool != "" && b.writeSpool(b.buffer) == nil {
+			b.removed += int64(len(b.buffer))
+			b.buffer = nil
+		} else {
+			b.buffer = b.buffer[1:]
+			b.dropped++
+			b.removed++
+		}
+	}
+	b.buffer = append(b.buffer, e)
+	full := len(b.buffer) >= b.size
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush sends the spooled batches and the buffered logs now, in batches,
+// and returns the error of the first failed batch
+// the failed batch is saved in the spool folder, if set, the next logs stay in the buffer
+func (b *Batcher) Flush(ctx context.Context) error {
+	b.sending.Lock()
+	defer b.sending.Unlock()
+
+	if err := b.flushSpool(ctx); err != nil {
+		b.mu.Lock()
+		b.lastErr = err
+		b.mu.Unlock()
+		return err
+	}
+
+	for {
+		b.mu.Lock()
+		n := min(len(b.buffer), b.size)
+		batch := append([]logger.Entry(nil), b.buffer[:n]...)
+		start := b.removed
+		b.mu.Unlock()
+
+		if n == 0 {
+			return nil
+		}
+
+		err := b.send(ctx, batch)
+		b.mu.Lock()
+		b.lastErr = err
+		if err == nil || (b.spool != "" && b.writeSpool(batch) == nil) {
+			// the logs removed while sending were the oldest ones, of the batch sent
+			if sent := n - int(b.removed-start); sent > 0 {
+				b.buffer = b.buffer[sent:]
+				b.removed += int64(sent)
+			}
+		}
+		b.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// flushSpool sends the batches of the spool folder, oldest first, and removes them once sent
+// the files that can't be read are renamed with the .corrupt extension and skipped
+func (b *Batcher) flushSpool(ctx context.Context) error {
+	b.mu.Lock()
+	folder := b.spool
+	b.mu.Unlock()
+	if folder == "" {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(folder, "*.json"))
+	if err != nil {
+		return errors.New("[logger-pkg] failed to read the spool folder: " + err.Error())
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return errors.New("[logger-pkg] failed to read the spooled logs: " + err.Error())
+		}
+
+		var batch []logger.Entry
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&batch); err != nil {
+			os.Rename(file, strings.TrimSuffix(file, ".json")+".corrupt")
+			continue
+		}
+
+		if err := b.send(ctx, batch); err != nil {
+			return err
+		}
+
+		if err := os.Remove(file); err != nil {
+			return errors.New("[logger-pkg] failed to remove the spooled logs: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// writeSpool saves the batch passed in a new file of the spool folder, the caller must hold the lock
+// the file is written with a temporary name, so a partial file is never sent
+func (b *Batcher) writeSpool(batch []logger.Entry) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	b.spooled++
+	name := filepath.Join(b.spool, fmt.Sprintf("%020d-%06d.json", time.Now().UnixNano(), b.spooled))
+	err = os.WriteFile(name+".tmp", data, 0o644)
+	if err == nil {
+		err = os.Rename(name+".tmp", name)
+	}
+	return err
+}
+
+// Err returns the error of the last batch sent, nil if it succeeded
+func (b *Batcher) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastErr
+}
+
+// Dropped returns the number of logs dropped because the backend failed for too long
+func (b *Batcher) Dropped() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// Close stops the background goroutine and sends the buffered logs,
+// the logs that could not be sent are saved in the spool folder, if set
+// this method returns the error of the last send, if the logs could not be sent
+func (b *Batcher) Close(ctx context.Context) error {
+	b.once.Do(func() {
+		close(b.done)
+		<-b.stopped
+	})
+
+	err := b.Flush(ctx)
+	if err == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.spool != "" && len(b.buffer) > 0 && b.writeSpool(b.buffer) == nil {
+		b.removed += int64(len(b.buffer))
+		b.buffer = nil
+	}
+	return err
+}
+
+// run sends the buffered logs every interval, or when the buffer is full,
+// and waits longer after every failure
+func (b *Batcher) run() {
+	defer close(b.stopped)
+
+	wait := b.interval
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-b.wake:
+			if b.Err() != nil {
+				// the backend is failing, the send waits for the timer
+				continue
+			}
+		case <-timer.C:
+		}
+
+		if err := b.Flush(context.Background()); err != nil {
+			wait = min(wait*2, maxBackoff)
+		} else {
+			wait = b.interval
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+	}
+}
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// jobField is the field with the name of the job of the logs created by RunJob
+const jobField = "job"
+
+// newCorrelationID returns a new random correlation id, for the executions of the jobs
+func newCorrelationID() string {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// RunJob runs the job passed and logs its execution in the database
+// every execution creates the following logs:
+//   - Info: when the job starts
+//   - Info: when the job finishes
+//   - Error: when the job returns an error, with the error chain in the fields (see Err)
+//   - Error: when the job panics, with the panic value and the stack trace in the "stack" field
+//
+// all the logs of an execution are tagged with "job" and "job:<name>" (besides the tags of the logger),
+// have the name of the job in the "job" field and share a new correlation id, unless the logger
+// already has one (see WithCorrelation), so an execution can be queried with queries.Correlation;
+// the logs created when the job ends have its duration in the "duration_ms" field, as the ones of Timer
+// a panic is recovered and returned as an error, so a failing job doesn't crash the scheduler
+// Example:
+//
+//	err := log.RunJob("backup", func() error {
+//		return backup()
+//	})
+func (opts *Logger) RunJob(name string, fn func() error) (err error) {
+	job := opts
+	opts.mu.RLock()
+	correlated := opts.correlation != ""
+	opts.mu.RUnlock()
+	if !correlated {
+		job = opts.WithCorrelation(newCorrelationID())
+	}
+
+	start := time.Now()
+	job.jobLog(Info, name, fmt.Sprintf("job %s started", name), nil)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		duration := time.Since(start)
+		err = fmt.Errorf("job %s panicked: %v", name, r)
+		job.jobLog(Error, name, fmt.Sprintf("job %s panicked after %s: %v", name, duration.Round(time.Millisecond), r), map[string]any{
+			durationField: float64(duration.Microseconds()) / 1000,
+			stackField:    string(debug.Stack()),
+		})
+	}()
+
+	err = fn()
+	duration := time.Since(start)
+	fields := map[string]any{durationField: float64(duration.Microseconds()) / 1000}
+	if err != nil {
+		for k, v := range errorFields(err) {
+			fields[k] = v
+		}
+		job.jobLog(Error, name, fmt.Sprintf("job %s failed after %s: %s", name, duration.Round(time.Millisecond), err.Error()), fields)
+		return err
+	}
+
+	job.jobLog(Info, name, fmt.Sprintf("job %s finished in %s", name, duration.Round(time.Millisecond)), fields)
+	return nil
+}
+
+// jobLog creates a log of the job with the name passed, with the tags and the fields of RunJob
+// and the fields passed
+func (opts *Logger) jobLog(level LogLevel, name, message string, fields map[string]any) {
+	l, err := newLog(level, append(opts.getTags(), "job", "job:"+name), message)
+	if err != nil {
+		return
+	}
+
+	if fields == nil {
+		fields = make(map[string]any, 1)
+	}
+	fields[jobField] = name
+	l.fields = fields
+	opts.writeLog(l)
+}
+
+// WrapJob returns a function that runs the job passed with RunJob
+// the returned function has the signature expected by the most common
+// job schedulers (e.g. cron.AddFunc), the error of the job is only logged
+// Example:
+//
+//	c := cron.New()
+//	c.AddFunc("@hourly", log.WrapJob("cleanup", cleanup))
+func (opts *Logger) WrapJob(name string, fn func() error) func() {
+	return func() {
+		opts.RunJob(name, fn)
+	}
+}
@@ -1,17 +1,26 @@
 package logger
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Tagliapietra96/tui"
-	"github.com/Tagliapietra96/tui/opts"
 	"github.com/charmbracelet/lipgloss"
 )
 
 // log represents the log structure
 type log struct {
+	id             int64
 	level          LogLevel
 	tags           []string
 	callerFile     string
@@ -19,6 +28,16 @@ type log struct {
 	callerFunction string
 	message        string
 	timestamp      timestamp
+	fields         map[string]any
+	runID          string
+	hostname       string // the name of the machine that created the log, if captured
+	pid            int    // the id of the process that created the log, if captured
+	goroutine      int64  // the id of the goroutine that created the log, if captured
+	count          int    // the occurrences of the log merged by the deduplication, 0 is the same as 1
+	correlation    string // the correlation id of the request or job of the log, if any
+	overflow       string // the full message of the log truncated by the maximum message size, if kept
+	goroutines     string // the dump of every goroutine taken by the Fatal logs, if captured
+	callerPath     string // the full path of the caller file, used by the CallerPath modes and not stored
 }
 
 func newLog(level LogLevel, tags []string, message string) (*log, error) {
@@ -27,6 +46,7 @@ func newLog(level LogLevel, tags []string, message string) (*log, error) {
 		tags:      tags,
 		message:   message,
 		timestamp: timestamp(time.Now()),
+		runID:     currentRun.id,
 	}
 
 	err := getCaller(l)
@@ -37,21 +57,29 @@ func newLog(level LogLevel, tags []string, message string) (*log, error) {
 	return l, nil
 }
 
+// getTags returns the printed tags of the log: the plain tags followed
+// by the key:value tags grouped and sorted by key (printed as key=value)
 func (l *log) getTags() []string {
 	result := make([]string, 0, len(l.tags))
+	pairs := make([]string, 0)
 	for _, tag := range l.tags {
+		if key, value, ok := SplitTag(tag); ok {
+			pairs = append(pairs, key+"="+value)
+			continue
+		}
 		result = append(result, "🔖"+tag)
 	}
 
-	return result
+	sort.Strings(pairs)
+	return append(result, pairs...)
 }
 
-func (l *log) getCaller(inline bool, level ShowCallerLevel) string {
+func (l *log) getCaller(inline bool, level ShowCallerLevel, th Theme) string {
 	if level == HideCaller {
 		return ""
 	}
 
-	c := tui.NewStyle(opts.Muted)
+	c := tui.NewStyle(th.muted())
 	if !inline {
 		tui.Concat(&c, "at ")
 	} else {
@@ -77,31 +105,218 @@ func (l *log) getCaller(inline bool, level ShowCallerLevel) string {
 	return c.String()
 }
 
-func (l *log) toJSON() string {
+// toJSON returns the log as an indented JSON object
+// the time is formatted with the layout passed (the default one if empty)
+func (l *log) toJSON(layout string) string {
 	var b strings.Builder
 	b.WriteString("{\n")
-	b.WriteString(fmt.Sprintf("\t\"level\": \"%s\",\n", l.level.String()))
+	b.WriteString(fmt.Sprintf("\t\"level\": %s,\n", jsonString(l.level.String())))
 	b.WriteString("\t\"tags\": [")
 	for i, tag := range l.tags {
 		if i != 0 {
 			b.WriteString(", ")
 		}
-		b.WriteString(fmt.Sprintf("\"%s\"", tag))
+		b.WriteString(jsonString(tag))
 	}
 	b.WriteString("],\n")
-	b.WriteString(fmt.Sprintf("\t\"caller_file\": \"%s\",\n", l.callerFile))
+	b.WriteString(fmt.Sprintf("\t\"caller_file\": %s,\n", jsonString(l.callerFile)))
 	b.WriteString(fmt.Sprintf("\t\"caller_line\": %d,\n", l.callerLine))
-	b.WriteString(fmt.Sprintf("\t\"caller_function\": \"%s\",\n", l.callerFunction))
-	b.WriteString(fmt.Sprintf("\t\"message\": \"%s\",\n", l.message))
-	b.WriteString(fmt.Sprintf("\t\"time\": \"%s\"\n", l.timestamp.String()))
+	b.WriteString(fmt.Sprintf("\t\"caller_function\": %s,\n", jsonString(l.callerFunction)))
+	b.WriteString(fmt.Sprintf("\t\"message\": %s,\n", jsonString(l.message)))
+	b.WriteString(fmt.Sprintf("\t\"time\": %s,\n", jsonString(l.timestamp.format(layout))))
+	b.WriteString(fmt.Sprintf("\t\"timestamp\": %s", jsonString(l.timestamp.rfc3339())))
+	if len(l.fields) > 0 {
+		b.WriteString(fmt.Sprintf(",\n\t\"fields\": %s", marshalFields(l.fields)))
+	}
+	if l.runID != "" {
+		b.WriteString(fmt.Sprintf(",\n\t\"run_id\": %s", jsonString(l.runID)))
+	}
+	if l.hostname != "" {
+		b.WriteString(fmt.Sprintf(",\n\t\"hostname\": %s", jsonString(l.hostname)))
+	}
+	if l.pid != 0 {
+		b.WriteString(fmt.Sprintf(",\n\t\"pid\": %d", l.pid))
+	}
+	if l.goroutine != 0 {
+		b.WriteString(fmt.Sprintf(",\n\t\"goroutine\": %d", l.goroutine))
+	}
+	if l.count > 1 {
+		b.WriteString(fmt.Sprintf(",\n\t\"count\": %d", l.count))
+	}
+	if l.correlation != "" {
+		b.WriteString(fmt.Sprintf(",\n\t\"correlation\": %s", jsonString(l.correlation)))
+	}
+	b.WriteString("\n")
 	b.WriteString("}")
 	return b.String()
 }
 
+// checksum returns the content hash of the log, it is computed from every stored
+// information of the log (tags order excluded) and it is used to recognize duplicated logs
+// the time is hashed as the UTC instant, so the same log has the same hash in every location
+func (l *log) checksum() string {
+	tags := append(make([]string, 0, len(l.tags)), l.tags...)
+	sort.Strings(tags)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%d\x00%s\x00%s\x00%s",
+		l.level.code(),
+		time.Time(l.timestamp).UTC().Format(time.RFC3339),
+		l.callerFile,
+		l.callerLine,
+		l.callerFunction,
+		l.message,
+		strings.Join(tags, "\x00"),
+	)
+	if len(l.fields) > 0 {
+		fmt.Fprintf(h, "\x00%s", marshalFields(l.fields))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// jsonLog represents a log in the JSON export format
+type jsonLog struct {
+	Level          string         `json:"level"`
+	Tags           []string       `json:"tags"`
+	CallerFile     string         `json:"caller_file"`
+	CallerLine     int            `json:"caller_line"`
+	CallerFunction string         `json:"caller_function"`
+	Message        string         `json:"message"`
+	Time           string         `json:"time"`
+	Timestamp      string         `json:"timestamp,omitempty"`
+	Fields         map[string]any `json:"fields,omitempty"`
+	RunID          string         `json:"run_id,omitempty"`
+	Hostname       string         `json:"hostname,omitempty"`
+	PID            int            `json:"pid,omitempty"`
+	Goroutine      int64          `json:"goroutine,omitempty"`
+	Count          int            `json:"count,omitempty"`
+	Correlation    string         `json:"correlation,omitempty"`
+}
+
+// toJSONLine returns the log as a single line JSON object
+// the time is formatted with the layout passed (the default one if empty)
+func (l *log) toJSONLine(layout string) string {
+	b, err := json.Marshal(jsonLog{
+		Level:          l.level.String(),
+		Tags:           append(make([]string, 0, len(l.tags)), l.tags...),
+		CallerFile:     l.callerFile,
+		CallerLine:     l.callerLine,
+		CallerFunction: l.callerFunction,
+		Message:        l.message,
+		Time:           l.timestamp.format(layout),
+		Timestamp:      l.timestamp.rfc3339(),
+		Fields:         l.fields,
+		RunID:          l.runID,
+		Hostname:       l.hostname,
+		PID:            l.pid,
+		Goroutine:      l.goroutine,
+		Count:          l.count,
+		Correlation:    l.correlation,
+	})
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// parseJSONLogs parses the logs exported in JSON or NDJSON format
+func parseJSONLogs(data []byte) ([]*log, error) {
+	var entries []jsonLog
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		err := decodeJSON(trimmed, &entries)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for i, line := range bytes.Split(trimmed, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+
+			var e jsonLog
+			err := decodeJSON(line, &e)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+			}
+			entries = append(entries, e)
+		}
+	}
+
+	logs := make([]*log, 0, len(entries))
+	for _, e := range entries {
+		level, ok := levelFromString(e.Level)
+		if !ok {
+			return nil, fmt.Errorf("invalid log level %q", e.Level)
+		}
+
+		tags := e.Tags
+		if tags == nil {
+			tags = make([]string, 0)
+		}
+
+		logs = append(logs, &log{
+			level:          level,
+			tags:           tags,
+			callerFile:     e.CallerFile,
+			callerLine:     e.CallerLine,
+			callerFunction: e.CallerFunction,
+			message:        e.Message,
+			timestamp:      parseStoredTimestamp(e.Time, e.Timestamp),
+			fields:         copyFields(e.Fields),
+			runID:          e.RunID,
+			hostname:       e.Hostname,
+			pid:            e.PID,
+			goroutine:      e.Goroutine,
+			count:          e.Count,
+			correlation:    e.Correlation,
+		})
+	}
+
+	return logs, nil
+}
+
+// decodeJSON decodes the JSON passed in v keeping the numbers as json.Number,
+// so the big integers of the fields are not rounded to float64
+func decodeJSON(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func jsonString(s string) string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "\"\""
+	}
+	return string(b)
+}
+
 func (l *log) String() string {
+	return l.format("")
+}
+
+// format returns the log as a line of a log file
+// the time is formatted with the layout passed (the default one if empty)
+func (l *log) format(layout string) string {
+	if len(l.fields) > 0 {
+		return fmt.Sprintf(
+			"%s [%s] <%s:%d - %s> %s: %s %s",
+			l.timestamp.format(layout),
+			strings.Join(l.tags, ", "),
+			l.callerFile,
+			l.callerLine,
+			l.callerFunction,
+			l.level.String(),
+			l.message,
+			fieldsString(l.fields),
+		)
+	}
+
 	return fmt.Sprintf(
 		"%s [%s] <%s:%d - %s> %s: %s",
-		l.timestamp.String(),
+		l.timestamp.format(layout),
 		strings.Join(l.tags, ", "),
 		l.callerFile,
 		l.callerLine,
@@ -113,50 +328,237 @@ func (l *log) String() string {
 
 // LogLevel represents the level of the log
 //
+//   - Trace: used for the very detailed messages, it is dropped by default (see Logger.Level)
 //   - Debug: used for debugging purposes
 //   - Info: used for informational messages
+//   - Notice: used for the normal but significant events, between Info and Warning
 //   - Warning: used for warning messages
 //   - Error: used for error messages
 //   - Fatal: used for fatal messages
+//
+// the levels are spaced, so custom levels can be defined between them with SetLevelLabel
+// (e.g. logger.LogLevel(25) between Warning and Error)
+// Note: v1 numbered the levels from 0 (Debug) to 4 (Fatal), the databases written by v1 are migrated
+// to the new numbers when they are opened (see the "Upgrading to v2" section of the README)
 type LogLevel int
 
 const (
-	Debug   LogLevel = iota // debug level
-	Info                    // info level
-	Warning                 // warning level
-	Error                   // error level
-	Fatal                   // fatal level
+	Trace   LogLevel = -10 // trace level
+	Debug   LogLevel = 0   // debug level
+	Info    LogLevel = 10  // info level
+	Notice  LogLevel = 15  // notice level
+	Warning LogLevel = 20  // warning level
+	Error   LogLevel = 30  // error level
+	Fatal   LogLevel = 40  // fatal level
 )
 
+// defaultLabels are the labels of the levels of the package, they are accepted
+// by ParseLevel also when the levels are renamed with SetLevelLabel
+var defaultLabels = map[LogLevel]string{
+	Trace:   "TRACE",
+	Debug:   "DEBUG",
+	Info:    "INFO",
+	Notice:  "NOTICE",
+	Warning: "WARNING",
+	Error:   "ERROR",
+	Fatal:   "FATAL",
+}
+
+// levelLabels are the labels of the levels, the ones of the package and the ones set with SetLevelLabel
+var levelLabels = struct {
+	sync.RWMutex
+	labels map[LogLevel]string
+}{labels: maps.Clone(defaultLabels)}
+
+// SetLevelLabel sets the label of the level passed, it can rename a level of the package
+// (e.g. WARNING to WARN) or define a new level with a value between the ones of the package
+// the labels are used to print, export and parse the levels, the stored logs keep the value of their level,
+// so the filters by level work with the custom levels too; the custom levels are printed with the color
+// of the level of the package below them
+// the labels are shared by every logger of the process, so they should be set at startup
+// Example:
+//
+//	logger.SetLevelLabel(logger.Warning, "WARN")
+//
+//	const Audit = logger.LogLevel(25) // between Warning and Error
+//	logger.SetLevelLabel(Audit, "AUDIT")
+//	log.Log(Audit, "user %s deleted", id)
+//
+// this function returns an error if the label is empty or if it is the label of another level
+func SetLevelLabel(level LogLevel, label string) error {
+	label = strings.ToUpper(strings.TrimSpace(label))
+	if label == "" || strings.ContainsAny(label, " \t\n") {
+		return fmt.Errorf("[logger-pkg] invalid label %q for the level %d", label, int(level))
+	}
+
+	levelLabels.Lock()
+	defer levelLabels.Unlock()
+	for other, l := range levelLabels.labels {
+		if l == label && other != level {
+			return fmt.Errorf("[logger-pkg] the label %q is already used by the level %d", label, int(other))
+		}
+	}
+	levelLabels.labels[level] = label
+	return nil
+}
+
+// Levels returns the levels of the package and the ones defined with SetLevelLabel, from the lowest
+func Levels() []LogLevel {
+	levelLabels.RLock()
+	defer levelLabels.RUnlock()
+	levels := make([]LogLevel, 0, len(levelLabels.labels))
+	for level := range levelLabels.labels {
+		levels = append(levels, level)
+	}
+	slices.Sort(levels)
+	return levels
+}
+
 // String returns the string representation of the LogLevel
-// it returns the label of the level in uppercase
+// it returns the label of the level in uppercase, empty if the level is not defined
 func (ls LogLevel) String() string {
+	levelLabels.RLock()
+	defer levelLabels.RUnlock()
+	return levelLabels.labels[ls]
+}
+
+// levelAliases are the names of the levels accepted by ParseLevel other than their labels
+var levelAliases = map[string]LogLevel{
+	"dbg":         Debug,
+	"information": Info,
+	"warn":        Warning,
+	"err":         Error,
+	"critical":    Fatal,
+	"crit":        Fatal,
+	"panic":       Fatal,
+}
+
+// ParseLevel returns the level with the name passed, so the levels can be read from the flags,
+// the environment variables and the configuration files
+// the name is case-insensitive and the spaces around it are ignored, it can be the label of the level
+// (trace, debug, info, notice, warning, error, fatal or a label set with SetLevelLabel)
+// or a common alias (warn, err, critical, panic, ...)
+// Example:
+//
+//	level, err := logger.ParseLevel(os.Getenv("LOG_LEVEL")) // "warn" is Warning
+//
+// this function returns an error if the name is not a level
+func ParseLevel(name string) (LogLevel, error) {
+	s := strings.ToLower(strings.TrimSpace(name))
+	if level, ok := levelFromString(strings.ToUpper(s)); ok {
+		return level, nil
+	}
+
+	if level, ok := levelAliases[s]; ok {
+		return level, nil
+	}
+	return 0, fmt.Errorf("[logger-pkg] invalid level %q, use trace, debug, info, notice, warning, error or fatal", name)
+}
+
+// MarshalText returns the label of the level (e.g. "WARNING"), so the levels are written as text
+// in JSON and in the other text formats
+// it returns an error if the level is not one of the levels defined by the package
+func (ls LogLevel) MarshalText() ([]byte, error) {
+	if !ls.valid() {
+		return nil, fmt.Errorf("[logger-pkg] invalid level %d", int(ls))
+	}
+	return []byte(ls.String()), nil
+}
+
+// UnmarshalText sets the level with the name passed, it accepts the names of ParseLevel
+// Example:
+//
+//	var cfg struct {
+//		Level logger.LogLevel `json:"level"`
+//	}
+//	json.Unmarshal([]byte(`{"level": "warn"}`), &cfg) // cfg.Level is Warning
+func (ls *LogLevel) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*ls = level
+	return nil
+}
+
+// UnmarshalJSON sets the level with the JSON string (see UnmarshalText) or number passed,
+// the numbers are accepted for the JSON written before the levels were marshaled as text,
+// the numbers of the older versions of the package (1 to 4) are converted to the current levels
+func (ls *LogLevel) UnmarshalJSON(data []byte) error {
+	if n, err := strconv.Atoi(string(data)); err == nil {
+		level := LogLevel(n)
+		if n >= 1 && n <= 4 {
+			level = LogLevel(n * 10)
+		}
+
+		if !level.valid() {
+			return fmt.Errorf("[logger-pkg] invalid level %d", n)
+		}
+		*ls = level
+		return nil
+	}
+
 	var s string
-	switch ls {
-	case Debug:
-		s = "DEBUG"
-	case Info:
-		s = "INFO"
-	case Warning:
-		s = "WARNING"
-	case Error:
-		s = "ERROR"
-	case Fatal:
-		s = "FATAL"
-	default:
-		s = ""
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("[logger-pkg] invalid level %s", data)
+	}
+	return ls.UnmarshalText([]byte(s))
+}
+
+// levelFromString returns the LogLevel with the label passed,
+// the default labels are accepted for the levels renamed with SetLevelLabel
+func levelFromString(s string) (LogLevel, bool) {
+	levelLabels.RLock()
+	defer levelLabels.RUnlock()
+	for level, label := range levelLabels.labels {
+		if label == s {
+			return level, true
+		}
 	}
 
-	return s
+	for level, label := range defaultLabels {
+		if label == s {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// valid reports if the level is one of the levels defined by the package or with SetLevelLabel
+func (ls LogLevel) valid() bool {
+	return ls.String() != ""
+}
+
+// base returns the level of the package equal to or below the level passed,
+// so the custom levels are treated like the level below them (Trace for the lower ones)
+func (ls LogLevel) base() LogLevel {
+	base := Trace
+	for level := range defaultLabels {
+		if level <= ls && level > base {
+			base = level
+		}
+	}
+	return base
+}
+
+// code returns the code of the level used by the checksums of the logs, the levels of the older
+// versions of the package keep their numbers (0 to 4) so the checksums of their logs don't change
+func (ls LogLevel) code() string {
+	if ls >= Debug && ls <= Fatal && ls%10 == 0 {
+		return strconv.Itoa(int(ls) / 10)
+	}
+	return "L" + strconv.Itoa(int(ls))
 }
 
 func (ls LogLevel) color() lipgloss.TerminalColor {
 	var color lipgloss.TerminalColor
-	switch ls {
+	switch ls.base() {
 	case Debug:
 		color = tui.ColorLink
 	case Info:
 		color = tui.ColorInfo
+	case Notice:
+		color = tui.ColorSuccess
 	case Warning:
 		color = tui.ColorWarning
 	case Error:
@@ -170,22 +572,20 @@ func (ls LogLevel) color() lipgloss.TerminalColor {
 	return color
 }
 
-func (ls LogLevel) toString() string {
-	s := ls.String()
-	color := ls.color()
-	return tui.Render(s, opts.Color(color))
-}
-
 // ExportType represents the type of the export
 // it is used to specify the type of the export to be done
 // the type can be:
 //   - JSON: export the logs in JSON format
 //   - CSV: export the logs in CSV format
 //   - LOG: export the logs in LOG format
+//   - NDJSON: export the logs in newline delimited JSON format (one log per line)
+//   - Template: export the logs with the text/template set with Logger.SetExportTemplate
 type ExportType int
 
 const (
-	JSON ExportType = iota // export the logs in JSON
-	CSV                    // export the logs in CSV
-	LOG                    // export the logs in LOG
+	JSON     ExportType = iota // export the logs in JSON
+	CSV                        // export the logs in CSV
+	LOG                        // export the logs in LOG
+	NDJSON                     // export the logs in NDJSON
+	Template                   // export the logs with a custom template
 )
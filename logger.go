@@ -1,11 +1,15 @@
 package logger
 
 import (
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gen2brain/beeep"
@@ -16,47 +20,197 @@ import (
 // with the methods to interact with the logger
 // and log messages
 // The logger can be configured with the following options:
-//   - Folder: (string) the folder path to store the logs data (by default it uses the binary folder)
-//     to store the database file, otherwise it will use the current working directory
+//   - Folder: (string) the folder path to store the logs data (by default it uses the binary folder
+//     if it is writable, otherwise the data folder of the application, see AppName)
+//   - DatabasePath: (string) the path of the SQLite database file (by default logs_data.db in the folder)
+//   - AppName: (string) stores the logs in the data folder of the application with the name passed
+//   - Rotate: (Rotation, int, int) splits the logs in a database file per month or per size
+//   - AttachSources: (...string) the other SQLite databases read by the queries with the database of the logger
 //   - Inline: (bool) if true the logs will be printed inline, otherwise they will be printed in a block
+//   - Header: (bool) if true the inline logs will be printed with a legend and a header row
+//   - Density: (DensityLevel) how much space the logs take when printed in block mode
 //   - Caller: (ShowCallerLevel) the level of caller information to show
+//   - CallerPath: (CallerPathMode) records the caller file as a base name, a full path or a module-relative path
 //   - Timestamp: (ShowTimestampLevel) the level of timestamp information to show
 //   - ShowTags: (bool) if true the logger will show the tags in the logs
+//   - CaptureProcess: (bool) if true the hostname, the pid and the goroutine id are saved with the logs
+//   - ShowProcess: (bool) if true the hostname, the pid and the goroutine id of the logs are printed
 //   - Tags: (string) the tags to add to the logs created with this logger
 //   - SetFatal: (string, string) the title and message to show in the fatal error
 //     alert when the Fatal method is called
+//   - SetStore: (Store) the storage backend of the logs (by default the SQLite database in the folder)
+//   - WAL: (bool) if true the SQLite database uses the WAL journal mode (default)
+//   - BusyTimeout: (time.Duration) how long to wait for the SQLite database locked by another connection
+//   - BusyRetries: (int) how many times to retry the operations failed because the database is locked
+//   - IdleTimeout: (time.Duration) how long to keep the SQLite database open without operations
+//   - Dedup: (time.Duration) the window in which the identical logs increment a counter instead of being inserted
+//   - TimestampFormat: (string) the custom Go layout of the printed and exported times
+//   - UTC: (bool) if true the times are stored and printed in UTC, otherwise in the local time zone
+//   - Location: (*time.Location) the time zone of the stored and printed times
+//   - SetOutput: (io.Writer) the writer of the printed logs (by default the standard output)
+//   - Format: (ConsoleFormat) prints the logs styled (default) or as plain text, JSON or logfmt lines
+//   - Group: (GroupBy) sections the printed logs by day, tag or run with headers and counts
+//   - Pager: (bool) shows the logs printed by PrintLogs in a pager when they don't fit in the terminal
+//   - SetTheme: (Theme) the colors of the levels, the borders and the secondary information of the printed logs
+//   - RenderTag, RenderLevel: (RenderHook) customize how the logs with a tag or a level are printed
+//   - Level: (LogLevel) the minimum level of the logs created and printed, the lower ones are dropped
+//   - MaxMessageSize: (int, bool) the maximum size of the messages and if the full messages are kept
+//   - AddHook: (Hook) inspects and modifies the logs before they are stored and reacts after
+//   - AddSink: (Sink, LogLevel, ...string) writes the logs with the level or a higher one and the tags to another destination
+//   - OnSinkError: (func(error)) receives the errors of the sinks, the logging methods don't return them
+//   - StoreFilter: (LogLevel, ...string) stores only the logs with the level or a higher one and the tags
+//   - StoreLevel, PrintLevel: (LogLevel) the minimum level of the logs created that are stored and that are printed
+//   - Redact, RedactKeys: (patterns, keys) mask the secrets in the messages and the fields of the logs
+//   - SetNotifier: (Notifier, LogLevel) sends the logs with the level or a higher one to a notifier
+//   - NotifyWindow: (time.Duration, int) the aggregation of the notifications in a window
+//   - AddAlert, RemoveAlert: (AlertRule) notify when too many logs with a level and tags are created in a window
+//   - EncryptionKey: ([]byte) the AES key encrypting the messages and the fields stored in the SQLite database
+//   - DebugSQL: (bool) if true the SQL of the queries built from the query options is printed in the output
+//   - ReadOnlyCompat: (bool) if true a database created by a newer version of the package is opened read-only
+//   - Async: (int) the size of the queue of the logs written in the store in background, see Flush
+//   - Retention: (time.Duration) the maximum age of the stored logs, the older ones are deleted by Prune
+//   - LoadConfig, WatchConfig: apply the options of a TOML or YAML file, once or every time it changes
+//   - GoroutineDump: (bool) if true the Fatal logs save the stack traces of every goroutine, see Goroutines
+//   - FatalProfiles: (bool, time.Duration) the Fatal logs write a heap and a CPU profile in the logger folder
+//   - MaxAttachmentSize: (int) the maximum size in bytes of the data saved with Attach (10 MB by default)
+//   - ExportAttachments: (bool) if true Export writes the attachments of the exported logs next to the file
+//   - SetExportTemplate: (ExportTemplate) the text/template of every log, the header and the footer of the Template exports
+//   - SetExportOptions: (ExportOptions) the name pattern, the folder and the overwrite behavior of the export files
+//   - MaintenanceArchive, MaintenanceExport: the archives and the exports written by StartMaintenance
 //   - Copy: creates a copy of the logger with the same configurations
+//   - Child: creates a copy of the logger with additional tags
+//   - WithCorrelation: creates a copy of the logger that stamps the logs with a correlation id
+//
+// The logger is safe for concurrent use: the configuration methods can be called
+// while other goroutines are logging, every operation works on a consistent
+// snapshot of the configuration taken when the operation starts
 //
 // The logger has the following methods to log messages:
+//   - Log: creates a log message with the level passed in the database (it not will be printed)
+//   - Trace: creates a trace log message in the database, below Debug and dropped by default (it not will be printed)
 //   - Debug: creates a debug log message in the database (it not will be printed)
 //   - Info: creates an info log message in the database (it not will be printed)
+//   - Notice: creates a notice log message in the database, between Info and Warning (it not will be printed)
 //   - Warn: creates a warning log message in the database (it not will be printed)
 //   - Error: creates an error log message in the database (it not will be printed)
+//   - Dump: creates a log with a value (map, struct, ...) marshaled as JSON (it not will be printed)
+//   - Err: creates an error log in the database with the error chain as fields (it not will be printed)
 //   - Fatal: creates a fatal log message in the database and exits the program (it not will be printed)
 //     it will show an alert with the title and message set with SetFatal (only if the error passed is not nil)
+//   - HandlePanics, Go: save the panics of the main function and of the goroutines as Fatal logs before exiting
 //   - PrintDebug: prints a debug log message in the console (it not will be saved in the database)
 //   - PrintInfo: prints an info log message in the console (it not will be saved in the database)
 //   - PrintWarn: prints a warning log message in the console (it not will be saved in the database)
 //   - PrintError: prints an error log message in the console (it not will be saved in the database)
+//   - PrintErr: prints an error log in the console with the error chain as fields (it not will be saved in the database)
 //   - PrintFatal: prints a fatal log message in the console and exits the program (it not will be saved in the database)
 //     if the error passed is not nil
+//   - Print: prints a log message with the level passed in the console (it not will be saved in the database)
+//   - LogDebug, LogInfo, LogWarn, LogError: create the log message in the database, print it in the console and return its id
+//   - StdWriter: returns an io.Writer that creates a log for every message written (e.g. by a standard logger)
+//   - RedirectStdLog: sets the output of the standard logger to the logger
+//   - Writer: returns an io.WriteCloser that creates a log for every line written (e.g. by a subprocess)
 //   - PrintLogs: prints the logs in the database based on the query configurations passed
+//   - RenderLogs: returns the logs in the database as PrintLogs prints them
+//   - PrintLogsResult: prints the logs like PrintLogs and returns if they matched and if they include errors
+//   - GetLogs: returns a copy of the logs in the database based on the query configurations passed
+//   - GetLogsPage: returns a page of the logs in the database with the number of logs and of pages
+//   - Tail: returns a copy of the last logs in the database
+//   - SaveQuery, SavedQueries, DeleteSavedQuery: save named queries in the database, see queries.Saved
+//   - PrintSaved: prints the logs of a saved query
+//   - Histogram: returns the number of logs of every level in intervals of time
+//   - TopMessages: returns the most frequent messages of the logs
+//   - Summary, SummaryContext: return the number of the logs by level and by tag, with their time range
+//   - PrintStats: prints the number of logs by level as bars and a sparkline of the error rate over time
+//   - ExplainQuery: returns the SQL of a query and its plan (EXPLAIN QUERY PLAN)
+//   - Export: exports the logs in the database to a file
+//   - GetLogsContext, PrintLogsContext, ExportContext, DeleteLogsContext: the query methods interrupted
+//     when the context passed is done
+//   - Archive: moves the logs older than a duration to a gzip-compressed export file
+//   - Prune: deletes the logs older than the retention set with Retention
+//   - Optimize: runs ANALYZE and VACUUM on the SQLite database
+//   - StartMaintenance: prunes, archives, exports and optimizes the database periodically in background
+//   - Import: imports the logs of a JSON export skipping the duplicated ones
+//   - ImportEntries: imports the entries passed skipping the duplicated ones
+//   - DeleteLogs: deletes the logs in the database based on the query configurations passed
+//   - PreviewDelete: returns the number and a sample of the logs DeleteLogs would delete
+//   - AssertNo: returns an error if the database contains logs with a level since a time
+//   - CrashReport: writes a zip with the recent logs, the system and build info and the Fatal stack trace
+//   - SetClockOffset: stores in the database a correction of the times of its logs
+//   - ListTags, RenameTag, MergeTags, DeleteTag: list the tags of the database with their counts and curate them
+//   - Append, AddTagsToLog: amend a stored log with a note or with new tags
+//   - Attach, GetAttachments: save files (payloads, screenshots, dumps) with a stored log and read them
+//   - Start: starts a timer that logs the duration of an operation when it is done
+//   - Once, If: log a message once per process, or only if a condition is true
 type Logger struct {
-	folderPath    string             // the folder path to store the logs data
-	showTags      bool               // if true the logger will show the tags in the logs
-	inline        bool               // if true the logs will be printed inline, otherwise they will be printed in a block
-	showCaller    ShowCallerLevel    // the level of caller information to show
-	showTimestamp ShowTimestampLevel // the level of timestamp information to show
-	tags          []string           // the tags to add to the logs created with this logger
-	fatalTitle    string             // the title to show in the fatal error alert
-	fatalMessage  string             // the message to show in the fatal error alert
+	folderPath        string                  // the folder path to store the logs data
+	fileName          string                  // the name of the database file, if empty logs_data.db is used
+	showTags          bool                    // if true the logger will show the tags in the logs
+	inline            bool                    // if true the logs will be printed inline, otherwise they will be printed in a block
+	showCaller        ShowCallerLevel         // the level of caller information to show
+	callerPath        CallerPathMode          // how the caller files and functions are recorded
+	showTimestamp     ShowTimestampLevel      // the level of timestamp information to show
+	tags              []string                // the tags to add to the logs created with this logger
+	correlation       string                  // the correlation id of the logs created with this logger, see WithCorrelation
+	fatalTitle        string                  // the title to show in the fatal error alert
+	fatalMessage      string                  // the message to show in the fatal error alert
+	store             Store                   // the store of the logs, if nil the SQLite store in the folder path is used
+	wal               bool                    // if true the SQLite database uses the WAL journal mode
+	busyTimeout       time.Duration           // the time the SQLite database waits for a lock before failing
+	busyRetries       int                     // the number of retries of the operations failed because the database is locked
+	showHeader        bool                    // if true the inline logs are printed with a legend and a header row
+	goroutineDump     bool                    // if true the Fatal logs save the stack traces of every goroutine
+	profiling         profiling               // the pprof profiles written by the Fatal logs, disabled by default
+	maxAttachment     int                     // the maximum size in bytes of the attachments, if 0 the default size is used
+	maintenance       maintenance             // the tasks of the maintenance started with StartMaintenance
+	exportAttachments bool                    // if true the exports write the attachments of the exported logs
+	exportOptions     ExportOptions           // the name pattern and the folder of the export files
+	exportTemplate    *exportTemplate         // the templates of the Template exports, set with SetExportTemplate
+	processInfo       bool                    // if true the hostname, the pid and the goroutine are saved with the logs
+	showProcess       bool                    // if true the hostname, the pid and the goroutine of the logs are printed
+	density           DensityLevel            // the density of the logs printed in block mode
+	compat            bool                    // if true a database newer than the package is opened read-only
+	idleTimeout       time.Duration           // the time the SQLite connection is kept open without being used
+	dedup             time.Duration           // the window of the deduplication of the logs, if 0 every log is inserted
+	debugSQL          bool                    // if true the SQL of the queries is printed in the output, see DebugSQL
+	consoleOnly       bool                    // if true the logs are printed in the console instead of being stored
+	minLevel          LogLevel                // the logs with a lower level are dropped
+	location          *time.Location          // the location of the stored and printed times, if nil the local one is used
+	timeLayout        string                  // the custom layout of the printed and exported times, if empty the default ones are used
+	tagHooks          map[string]RenderHook   // the render hooks of the logs by tag
+	levelHooks        map[LogLevel]RenderHook // the render hooks of the logs by level
+	notifications     *notifications          // the notifier of the logs, shared by the copies of the logger
+	alerts            *alerts                 // the alert rules, shared by the copies of the logger
+	hooks             []Hook                  // the hooks called before and after the logs are stored
+	sinks             []route                 // the other destinations of the logs, with their filters
+	sinkErrors        func(error)             // the handler of the errors of the sinks, if nil the errors are only counted
+	storeRoute        route                   // the filter of the logs written in the store, every log by default
+	printing          bool                    // if true the logs created with the level or a higher one are printed too
+	printLevel        LogLevel                // the minimum level of the logs created printed in the console
+	async             *asyncWriter            // the writer of the logs stored in background, if nil the logs are written synchronously
+	retention         time.Duration           // the maximum age of the stored logs deleted by Prune, if 0 every log is kept
+	redaction         redaction               // the patterns and the field keys redacted before the logs are stored and printed
+	output            io.Writer               // the writer of the printed logs, if nil the standard output is used
+	groupBy           GroupBy                 // how the printed logs are sectioned
+	pager             bool                    // if true the printed logs higher than the terminal are shown in a pager
+	highlights        []string                // the terms of the search filters highlighted in the printed logs
+	format            ConsoleFormat           // the format of the logs printed in the console
+	theme             Theme                   // the colors of the printed logs, the nil ones are the default colors
+	maxMessage        int                     // the maximum size in bytes of the messages, if 0 the messages are not truncated
+	overflow          bool                    // if true the full messages of the truncated logs are kept in the SQLite store
+	encryption        encryption              // the encryption of the messages and of the fields stored in the SQLite database
+	rotation          rotation                // the rotation of the SQLite database files, disabled by default
+	sources           []string                // the paths of the other SQLite databases read by the queries
+	mu                sync.RWMutex            // protects the configuration, the logger can be used by multiple goroutines
 }
 
 // New creates a new logger with the given tags
 // the tags will be added to the logs created with this logger
 // if no tags are passed it will create a logger without tags
 // The new logger will have the following default configurations:
-//   - folderPath: the bynary folder path (if it fails to get the path it will use an empty string)
+//   - folderPath: the binary folder path if it is writable, otherwise the data folder of the application
+//     named as the binary (the working directory for the binaries run with "go run" or "go test")
+//   - fileName: logs_data.db
 //   - showTags: false
 //   - inline: false
 //   - showCaller: ShowCallerFile
@@ -64,66 +218,639 @@ type Logger struct {
 //   - fatalTitle: "Fatal"
 //   - fatalMessage: "An error occurred, please check the logs for more information"
 //   - tags: the tags passed or an empty slice
+//   - wal: true
+//   - busyTimeout: 5 seconds
+//   - busyRetries: 3
+//   - idleTimeout: 5 minutes
+//   - density: Comfortable
+//   - minLevel: Debug
+//   - processInfo: false
+//   - dedup: 0 (disabled)
+//   - showProcess: false
 //
 // Check the Logger struct for more information about the logger configurations
 // and the methods to interact with the logger and log messages
 func New(tags ...string) *Logger {
 	l := new(Logger)
-
-	folder, err := os.Executable()
-	if err != nil {
-		folder = ""
-	}
-
-	if strings.Contains(folder, os.TempDir()) {
-		folder, err = os.Getwd()
-		if err != nil {
-			folder = ""
-		}
-	}
-
-	l.folderPath = folder
+	l.folderPath = defaultFolder()
 	l.showCaller = ShowCallerFile
 	l.showTimestamp = ShowDateTime
 	l.showTags = false
 	l.fatalTitle = "Fatal"
 	l.fatalMessage = "An error occurred, please check the logs for more information"
 	l.tags = make([]string, 0)
+	l.wal = true
+	l.busyTimeout = defaultBusyTimeout
+	l.busyRetries = defaultBusyRetries
+	l.idleTimeout = defaultIdleTimeout
+	l.minLevel = Debug
+	l.storeRoute.level = Trace
 
 	if len(tags) > 0 {
-		l.tags = tags
+		l.tags = append(l.tags, tags...)
 	}
 
 	return l
 }
 
+// NewDevelopment creates a new logger with the given tags configured for the development:
+//   - the logs created are stored and printed in the console in colorful blocks
+//   - the caller is shown with the function, the tags are shown
+//   - every level is logged (Debug), no notification is sent
+//
+// the configurations can be changed with the usual methods
+// Example:
+//
+//	log := logger.NewDevelopment("api")
+//	log.Debug("request %v", req) // stored and printed
+func NewDevelopment(tags ...string) *Logger {
+	l := New(tags...)
+	l.showTags = true
+	l.showCaller = ShowCallerFunction
+	l.format = StyledFormat
+	l.printing = true
+	l.printLevel = Debug
+	return l
+}
+
+// NewProduction creates a new logger with the given tags configured for the production:
+//   - the logs are written in the store by a background goroutine (see Async) with a queue of 10000 logs
+//   - the logs lower than Warning are dropped
+//   - the logs are printed as plain text, without colors
+//
+// call Close (or Flush) before the program exits, so the queued logs are stored
+// Example:
+//
+//	log := logger.NewProduction("api")
+//	defer log.Close()
+func NewProduction(tags ...string) *Logger {
+	l := New(tags...)
+	l.minLevel = Warning
+	l.format = PlainFormat
+	l.Async(productionQueueSize)
+	return l
+}
+
+// NewConsoleOnly creates a new logger with the given tags that never touches the filesystem
+// it is useful for the tools that want only the terminal output of the package:
+//   - the Print methods work as usual
+//   - the methods that create a log (Debug, Info, Warn, Error, Fatal, ...) print it in the console
+//   - the methods that need the database (PrintLogs, GetLogs, Export, Import, DeleteLogs, ...)
+//     return ErrNoStore
+//
+// the logs can be dropped by level with the Level method
+// the store of the logger can't be changed with SetStore or Folder
+// Example:
+//
+//	log := logger.NewConsoleOnly("cli")
+//	log.Level(logger.Info)
+//	log.Debug("dropped")
+//	log.Info("printed in the console")
+func NewConsoleOnly(tags ...string) *Logger {
+	l := New(tags...)
+	l.folderPath = ""
+	l.consoleOnly = true
+	return l
+}
+
 // Copy creates a copy of the logger with the same configurations
 func (opts *Logger) Copy() *Logger {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+
 	l := new(Logger)
 	l.folderPath = opts.folderPath
+	l.fileName = opts.fileName
 	l.showTags = opts.showTags
 	l.inline = opts.inline
 	l.showCaller = opts.showCaller
+	l.callerPath = opts.callerPath
 	l.showTimestamp = opts.showTimestamp
 	l.tags = append(make([]string, 0), opts.tags...)
+	l.correlation = opts.correlation
 	l.fatalTitle = opts.fatalTitle
 	l.fatalMessage = opts.fatalMessage
+	l.store = opts.store
+	l.wal = opts.wal
+	l.busyTimeout = opts.busyTimeout
+	l.busyRetries = opts.busyRetries
+	l.showHeader = opts.showHeader
+	l.processInfo = opts.processInfo
+	l.goroutineDump = opts.goroutineDump
+	l.profiling = opts.profiling
+	l.maxAttachment = opts.maxAttachment
+	l.exportAttachments = opts.exportAttachments
+	l.exportOptions = opts.exportOptions
+	l.exportTemplate = opts.exportTemplate
+	l.maintenance = opts.maintenance
+	l.showProcess = opts.showProcess
+	l.density = opts.density
+	l.compat = opts.compat
+	l.idleTimeout = opts.idleTimeout
+	l.dedup = opts.dedup
+	l.debugSQL = opts.debugSQL
+	l.consoleOnly = opts.consoleOnly
+	l.minLevel = opts.minLevel
+	l.location = opts.location
+	l.timeLayout = opts.timeLayout
+	l.notifications = opts.notifications
+	l.alerts = opts.alerts
+	l.hooks = append(make([]Hook, 0, len(opts.hooks)), opts.hooks...)
+	l.sinks = append(make([]route, 0, len(opts.sinks)), opts.sinks...)
+	l.sinkErrors = opts.sinkErrors
+	l.storeRoute = opts.storeRoute
+	l.printing = opts.printing
+	l.printLevel = opts.printLevel
+	l.async = opts.async
+	l.retention = opts.retention
+	l.redaction = opts.redaction.copy()
+	l.output = opts.output
+	l.format = opts.format
+	l.groupBy = opts.groupBy
+	l.pager = opts.pager
+	l.highlights = append(make([]string, 0, len(opts.highlights)), opts.highlights...)
+	l.theme = opts.theme
+	l.maxMessage = opts.maxMessage
+	l.overflow = opts.overflow
+	l.encryption = opts.encryption
+	l.rotation = opts.rotation
+	l.sources = append([]string(nil), opts.sources...)
+	l.tagHooks = make(map[string]RenderHook, len(opts.tagHooks))
+	for tag, hook := range opts.tagHooks {
+		l.tagHooks[tag] = hook
+	}
+	l.levelHooks = make(map[LogLevel]RenderHook, len(opts.levelHooks))
+	for level, hook := range opts.levelHooks {
+		l.levelHooks[level] = hook
+	}
+	return l
+}
+
+// Child creates a derived logger that inherits the configuration of the logger
+// the tags of the child are the tags of the logger followed by the tags passed,
+// so hierarchical component loggers can be created without manual tag bookkeeping
+// the child is independent: changing its configuration doesn't affect the parent
+// Example:
+//
+//	api := logger.New("api")
+//	auth := api.Child("api/auth") // tags: ["api", "api/auth"]
+//	auth.Info("user logged in")   // the log can be found filtering by "api" or "api/auth"
+func (opts *Logger) Child(tags ...string) *Logger {
+	l := opts.Copy()
+	l.tags = append(l.tags, tags...)
+	return l
+}
+
+// WithCorrelation creates a derived logger that stamps the logs it creates with the correlation id passed
+// (e.g. the id of a request or of a job), the logs of every component that receives the derived logger
+// can then be read together with queries.Correlation, whatever their tags
+// the derived logger is independent like the ones created by Child, the loggers derived from it
+// keep the correlation id, an empty id removes it
+// Example:
+//
+//	reqLog := log.WithCorrelation(r.Header.Get("X-Request-ID"))
+//	reqLog.Info("order created")
+//	// ...
+//	logs, _ := log.GetLogs(queries.Correlation(r.Header.Get("X-Request-ID")))
+func (opts *Logger) WithCorrelation(id string) *Logger {
+	l := opts.Copy()
+	l.correlation = id
 	return l
 }
 
+// correlate stamps the log passed with the correlation id of the logger, if the log has none
+func (opts *Logger) correlate(l *log) {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	if l.correlation == "" {
+		l.correlation = opts.correlation
+	}
+}
+
 // Folder sets the folder path to store the logs data
 // Every log created with this logger will be stored in this folder
 func (opts *Logger) Folder(path string) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
 	opts.folderPath = path
 }
 
+// SetStore sets the store used to save and query the logs
+// by default the logger uses the SQLite store in the folder path,
+// passing nil restores the default store
+// Example:
+//
+//	log.SetStore(logger.NewSQLiteStore("/var/log/my-app"))
+func (opts *Logger) SetStore(store Store) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.store = store
+}
+
+// getStore returns the store used by the logger
+func (opts *Logger) getStore() Store {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+
+	if opts.consoleOnly {
+		return noStore{}
+	}
+
+	if opts.store != nil {
+		return opts.store
+	}
+
+	fileName, rotation := opts.fileName, opts.rotation
+	if rotation.policy != NoRotation {
+		rotation.base = fileName
+		if rotation.base == "" {
+			rotation.base = defaultFileName
+		}
+		fileName = rotation.current(opts.folderPath)
+	}
+
+	return &sqliteStore{
+		folderPath:  opts.folderPath,
+		fileName:    fileName,
+		wal:         opts.wal,
+		busyTimeout: opts.busyTimeout,
+		busyRetries: opts.busyRetries,
+		compat:      opts.compat,
+		idleTimeout: opts.idleTimeout,
+		dedup:       opts.dedup,
+		encryption:  opts.encryption,
+		rotation:    rotation,
+		sources:     opts.sources,
+		debug:       opts.debugWriter(),
+	}
+}
+
+// WAL sets the journal mode of the SQLite database
+// if the enabled parameter is true the database uses the WAL journal mode (default),
+// which lets readers and a writer work at the same time, otherwise it uses the rollback journal
+func (opts *Logger) WAL(enabled bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.wal = enabled
+}
+
+// BusyTimeout sets how long an operation waits for the SQLite database
+// locked by another connection (process or goroutine) before failing
+// the default timeout is 5 seconds
+func (opts *Logger) BusyTimeout(timeout time.Duration) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.busyTimeout = timeout
+}
+
+// BusyRetries sets how many times an operation failed because
+// the SQLite database is locked is retried (with an exponential backoff)
+// before returning the error, the default is 3 retries
+func (opts *Logger) BusyRetries(retries int) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.busyRetries = retries
+}
+
+// IdleTimeout sets how long the connection to the SQLite database is kept open
+// without operations, after the timeout the database file is closed (so it is not
+// locked by a quiet process) and it is reopened by the next operation
+// the default timeout is 5 minutes, if the timeout is 0 the database
+// is closed after every operation
+func (opts *Logger) IdleTimeout(timeout time.Duration) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.idleTimeout = timeout
+}
+
+// ReadOnlyCompat sets the read-only compatibility mode of the SQLite database
+// by default a database created by a newer version of the package can't be used
+// and every operation fails with ErrNewerSchema, if the enabled parameter is true
+// the logs of the newer database can be read (printed, queried and exported)
+// while the write operations fail with ErrReadOnly
+// the databases created by the same or an older version are not affected
+// Example:
+//
+//	log.ReadOnlyCompat(true)
+//	log.PrintLogs() // works with a database of a newer version
+//	log.Info("...") // returns ErrReadOnly with a database of a newer version
+func (opts *Logger) ReadOnlyCompat(enabled bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.compat = enabled
+}
+
+// Close releases the resources used by the store of the logger
+// the logs queued by the Async mode are written before closing the store
+func (opts *Logger) Close() error {
+	opts.Flush()
+	return opts.getStore().Close()
+}
+
+// getTags returns a copy of the tags of the logger
+func (opts *Logger) getTags() []string {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	return append(make([]string, 0, len(opts.tags)), opts.tags...)
+}
+
+// writeLog saves the log passed in the store of the logger
+// the log is passed to the hooks of the logger before and after being stored
+func (opts *Logger) writeLog(l *log) error {
+	_, err := opts.writeLogID(l)
+	return err
+}
+
+// writeLogID saves the log passed in the store of the logger and in its sinks and returns its id,
+// the id is 0 if the log is not stored (disabled level, store filter or console only logger)
+// the errors of the sinks are not returned, they are reported by writeSinks
+func (opts *Logger) writeLogID(l *log) (int64, error) {
+	return opts.write(l, false)
+}
+
+// write saves the log passed in the store of the logger and in its sinks and returns its id,
+// if print is true the log is printed in the console too, after it is processed (hooks, redaction
+// and truncation) and before it is stored, so the printed log is the same as the stored one
+// and it is printed even if it fails to be stored
+func (opts *Logger) write(l *log, print bool) (int64, error) {
+	if !opts.enabled(l.level) {
+		return 0, nil
+	}
+
+	l.timestamp = timestamp(time.Time(l.timestamp).In(opts.getLocation()))
+	opts.captureProcess(l)
+	opts.resolveCaller(l)
+	opts.correlate(l)
+
+	hooks := opts.getHooks(l.level)
+	hooked, err := beforeWrite(hooks, l)
+	if err != nil {
+		return 0, err
+	}
+	*l = *hooked
+	opts.redact(l)
+	opts.truncate(l, true)
+
+	if opts.printEnabled(l.level) || (print && !opts.isConsoleOnly()) {
+		printLogs(opts.Copy(), []*log{l})
+	}
+
+	var id int64
+	if opts.storeEnabled(l) {
+		if opts.isConsoleOnly() {
+			printLogs(opts.Copy(), []*log{l})
+		} else if !opts.writeAsync(l) {
+			start := time.Now()
+			id, err = opts.writeStore(l)
+			observeWrite(l.level, time.Since(start), err)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	opts.writeSinks(l, id)
+	afterWrite(hooks, l, id)
+	opts.notify(l)
+	opts.checkAlerts(l)
+	return id, nil
+}
+
+// writeStore saves the log passed in the store of the logger and returns its id
+// the SQLite store receives the log itself, so it keeps the full message of the truncated logs
+func (opts *Logger) writeStore(l *log) (int64, error) {
+	return writeTo(opts.getStore(), l)
+}
+
+// writeTo saves the log passed in the store passed and returns its id
+func writeTo(store Store, l *log) (int64, error) {
+	if s, ok := store.(*sqliteStore); ok && (l.overflow != "" || l.goroutines != "") {
+		return s.write(context.Background(), l)
+	}
+	return store.Write(context.Background(), l.entry())
+}
+
+// printLog prints the log passed in the console if its level is enabled
+func (opts *Logger) printLog(l *log) {
+	if !opts.enabled(l.level) {
+		return
+	}
+	opts.captureProcess(l)
+	opts.resolveCaller(l)
+	opts.correlate(l)
+	opts.redact(l)
+	opts.truncate(l, false)
+	printLogs(opts.Copy(), []*log{l})
+}
+
+// enabled reports if the logs with the level passed are created
+func (opts *Logger) enabled(level LogLevel) bool {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	return level >= opts.minLevel
+}
+
+// isConsoleOnly reports if the logger was created with NewConsoleOnly
+func (opts *Logger) isConsoleOnly() bool {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	return opts.consoleOnly
+}
+
+// queryLogs returns the logs in the store of the logger selected by the query options passed
+func (opts *Logger) queryLogs(queryOptions ...QueryOption) ([]*log, error) {
+	return opts.queryLogsContext(context.Background(), queryOptions...)
+}
+
+// queryLogsContext returns the logs in the store of the logger selected by the query options passed,
+// the query is interrupted when the context passed is done
+func (opts *Logger) queryLogsContext(ctx context.Context, queryOptions ...QueryOption) ([]*log, error) {
+	entries, err := opts.getStore().Query(ctx, queryOptions...)
+	if err != nil {
+		return nil, cancelled(ctx, err)
+	}
+	return fromEntries(entries), nil
+}
+
+// cancelled returns the error of the context passed, wrapped, if it is done,
+// so the callers can check it with errors.Is, otherwise it returns the error passed
+func cancelled(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("[logger-pkg] the operation was interrupted: %w", ctxErr)
+	}
+	return err
+}
+
 // Inline sets the logger to print the logs inline
 // if the inline parameter is true, otherwise it will print
 // the logs in a block (like cards)
 func (opts *Logger) Inline(inline bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
 	opts.inline = inline
 }
 
+// Level sets the minimum level of the logs created and printed by the logger
+// the logs with a lower level are dropped: they are not stored nor printed
+// the default level is Debug, so every log is kept
+// this option doesn't affect the logs already stored, printed with PrintLogs
+// Example:
+//
+//	log.Level(logger.Warning) // Debug and Info logs are dropped
+func (opts *Logger) Level(min LogLevel) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.minLevel = min
+}
+
+// StoreLevel sets the minimum level of the logs created that are stored,
+// the logs with a lower level are not stored (their id is 0) but they are still
+// printed (see PrintLevel) and passed to the sinks and to the hooks
+// it is a shortcut of StoreFilter that keeps its tags
+// Example:
+//
+//	log.StoreLevel(logger.Warning) // only the warnings and the errors are persisted
+//	log.PrintLevel(logger.Debug)   // every log is printed in the console
+func (opts *Logger) StoreLevel(level LogLevel) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.storeRoute.level = level
+}
+
+// PrintLevel sets the minimum level of the logs created (with Log, Debug, Info, ...)
+// that are printed in the console too, as the LogDebug, LogInfo, ... methods do,
+// the printing is disabled by default, an invalid level disables it again
+// the console only loggers already print every log, so this option doesn't affect them
+func (opts *Logger) PrintLevel(level LogLevel) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.printing = level.valid()
+	opts.printLevel = level
+}
+
+// printEnabled reports if the logs created with the level passed are printed in the console
+func (opts *Logger) printEnabled(level LogLevel) bool {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	return opts.printing && !opts.consoleOnly && level >= opts.printLevel
+}
+
+// TimestampFormat sets a custom Go layout (see the time package) of the printed
+// and exported times, instead of the ones of the ShowTimestampLevel values
+// an empty layout restores the default ones, the timestamps are still hidden
+// with the HideTimestamp level, and the exports keep the RFC3339 timestamp field
+// used to import them
+// Example:
+//
+//	log.TimestampFormat(time.RFC3339)
+//	log.TimestampFormat("Jan 2 03:04:05 PM")
+func (opts *Logger) TimestampFormat(layout string) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.timeLayout = layout
+}
+
+// UTC sets the logger to store and print the times in UTC if the enabled parameter is true,
+// otherwise the local time zone of the machine is used (default)
+// it is the same as calling Location(time.UTC) or Location(time.Local)
+func (opts *Logger) UTC(enabled bool) {
+	if enabled {
+		opts.Location(time.UTC)
+	} else {
+		opts.Location(time.Local)
+	}
+}
+
+// Location sets the location (time zone) of the times stored and printed by the logger
+// the logs are stored with the offset of the location, so they are compared as instants
+// by the time filters of the queries package whatever location created them,
+// the printed times of every log (also the stored ones) are converted to the location
+// if the location is nil the local time zone of the machine is used (default)
+// Example:
+//
+//	loc, _ := time.LoadLocation("Europe/Rome")
+//	log.Location(loc)
+func (opts *Logger) Location(loc *time.Location) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.location = loc
+}
+
+// getLocation returns the location of the times of the logger
+func (opts *Logger) getLocation() *time.Location {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	if opts.location == nil {
+		return time.Local
+	}
+	return opts.location
+}
+
+// Header sets the logger to print a legend of the level colors and
+// a header row with the column names (TIME | TAGS | LEVEL | CALLER | MESSAGE)
+// above the inline logs if the show parameter is true, otherwise they are hidden (default)
+// this option has effect only in inline mode
+func (opts *Logger) Header(show bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.showHeader = show
+}
+
+// Dedup sets the window of the deduplication of the logs: a log identical to one created
+// in the window before it (same level, caller and message) increments the count of that log
+// instead of being inserted, the printed logs show the count as "×N"
+// the tags, the fields and the time of the first occurrence are kept
+// a window of 0 disables the deduplication (default)
+// it is useful to avoid flooding the database with the logs created in a loop or by a retry
+// Example:
+//
+//	log.Dedup(time.Minute)
+//	for range 100 {
+//		log.Warn("connection refused") // one log with the count 100
+//	}
+//
+// Note: the deduplication is done by the SQLite store, the custom stores ignore it
+func (opts *Logger) Dedup(window time.Duration) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	if window < 0 {
+		window = 0
+	}
+	opts.dedup = window
+}
+
+// CaptureProcess sets the logger to save the hostname, the process id and the goroutine id
+// with the logs if the enabled parameter is true, otherwise they are not saved (default)
+// it is useful when several services or processes share the same database,
+// the logs can then be filtered with queries.Hostname, queries.PID and queries.Goroutine
+// Note: reading the goroutine id has a small cost on every log
+func (opts *Logger) CaptureProcess(enabled bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.processInfo = enabled
+}
+
+// ShowProcess sets the logger to print the hostname, the process id and the goroutine id
+// of the logs (captured with CaptureProcess) next to their caller if the show parameter
+// is true, otherwise they are hidden (default)
+func (opts *Logger) ShowProcess(show bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.showProcess = show
+}
+
+// Density sets how much space the logs take when printed in block mode
+// the level can be Comfortable (default), Compact or Dense
+// this option has no effect in inline mode
+// Example:
+//
+//	log.Density(logger.Compact)
+func (opts *Logger) Density(level DensityLevel) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.density = level
+}
+
 // Caller sets the level of caller information to show
 // in the logs based on the level parameter
 // the level can be one of the following:
@@ -132,6 +859,8 @@ func (opts *Logger) Inline(inline bool) {
 //   - ShowCallerFunction: shows the caller file, line and function
 //   - HideCaller: hides the caller information
 func (opts *Logger) Caller(level ShowCallerLevel) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
 	opts.showCaller = level
 }
 
@@ -141,146 +870,233 @@ func (opts *Logger) Caller(level ShowCallerLevel) {
 //   - ShowFullTimestamp: shows the full timestamp with date and time
 //   - ShowDateTime: shows the timestamp with date and time
 //   - ShowTime: shows the timestamp with time only
+//   - ShowRelativeTime: shows the time elapsed since the log (3m ago, 2h ago, yesterday),
+//     useful to scan the recent logs
 //   - HideTimestamp: hides the timestamp
 func (opts *Logger) Timestamp(level ShowTimestampLevel) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
 	opts.showTimestamp = level
 }
 
 // ShowTags sets the logger to show the tags in the logs
 // if the show parameter is true, otherwise it will hide the tags
 func (opts *Logger) ShowTags(show bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
 	opts.showTags = show
 }
 
 // Tags adds the tags to the logger
 // the tags will be added to the logs created with this logger
 func (opts *Logger) Tags(tags ...string) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
 	opts.tags = append(opts.tags, tags...)
 }
 
 // SetTags sets the tags to the logger
 // this method replaces the current tags with the new ones
 func (opts *Logger) SetTags(tags ...string) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
 	opts.tags = append(make([]string, 0), tags...)
 }
 
 // SetFatal sets the title and message to show in the fatal error
 // alert when the Fatal method is called
 func (opts *Logger) SetFatal(title, message string) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
 	opts.fatalTitle = title
 	opts.fatalMessage = message
 }
 
-// Debug creates a debug log message in the database
+// Log creates a log message with the level passed in the database
 // with the message and arguments passed
 // it formats the message with the arguments using fmt.Sprintf
+// it is useful for wrappers and adapters that map the levels of other libraries
+// Example:
+//
+//	log.Log(logger.Warning, "disk usage at %d%%", usage)
+//
 // The new log is created in the database, but it is not printed
-// if it fails to create the log it will return an error
-func (opts *Logger) Debug(message string, args ...any) error {
-	formattedMessage := fmt.Sprintf(message, args...)
-	log, err := newLog(Debug, opts.tags, formattedMessage)
+// a Fatal log created with this method doesn't exit the program, use Fatal for that
+// it returns the id of the new log, to amend it later with Append or AddTagsToLog
+// (0 if the log is not stored: disabled level or console only logger)
+// if the level is not valid or it fails to create the log it will return an error
+func (opts *Logger) Log(level LogLevel, message string, args ...any) (int64, error) {
+	if !level.valid() {
+		return 0, fmt.Errorf("[logger-pkg] invalid log level %d", level)
+	}
+
+	l, err := newLog(level, opts.getTags(), fmt.Sprintf(message, args...))
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return createNewLog(opts, log)
+	return opts.writeLogID(l)
+}
+
+// Debug creates a debug log message in the database
+// with the message and arguments passed
+// it formats the message with the arguments using fmt.Sprintf
+// The new log is created in the database, but it is not printed
+// it returns the id of the new log (0 if it is not stored, see Log)
+// if it fails to create the log it will return an error
+func (opts *Logger) Debug(message string, args ...any) (int64, error) {
+	return opts.Log(Debug, message, args...)
+}
+
+// Trace creates a trace log message in the database
+// with the message and arguments passed
+// it formats the message with the arguments using fmt.Sprintf
+// the trace logs are below Debug, so they are dropped until the level
+// of the logger is lowered (e.g. log.Level(logger.Trace))
+// it returns the id of the new log (0 if it is not stored, see Log)
+// if it fails to create the log it will return an error
+func (opts *Logger) Trace(message string, args ...any) (int64, error) {
+	return opts.Log(Trace, message, args...)
 }
 
 // Info creates an info log message in the database
 // with the message and arguments passed
 // it formats the message with the arguments using fmt.Sprintf
 // The new log is created in the database, but it is not printed
+// it returns the id of the new log (0 if it is not stored, see Log)
 // if it fails to create the log it will return an error
-func (opts *Logger) Info(message string, args ...any) error {
-	formattedMessage := fmt.Sprintf(message, args...)
-	log, err := newLog(Info, opts.tags, formattedMessage)
-	if err != nil {
-		return err
-	}
-	return createNewLog(opts, log)
+func (opts *Logger) Info(message string, args ...any) (int64, error) {
+	return opts.Log(Info, message, args...)
+}
+
+// Notice creates a notice log message in the database, for the normal
+// but significant events (between Info and Warning)
+// with the message and arguments passed
+// it formats the message with the arguments using fmt.Sprintf
+// The new log is created in the database, but it is not printed
+// it returns the id of the new log (0 if it is not stored, see Log)
+// if it fails to create the log it will return an error
+func (opts *Logger) Notice(message string, args ...any) (int64, error) {
+	return opts.Log(Notice, message, args...)
 }
 
 // Warn creates a warning log message in the database
 // with the message and arguments passed
 // it formats the message with the arguments using fmt.Sprintf
 // The new log is created in the database, but it is not printed
+// it returns the id of the new log (0 if it is not stored, see Log)
 // if it fails to create the log it will return an error
-func (opts *Logger) Warn(message string, args ...any) error {
-	formattedMessage := fmt.Sprintf(message, args...)
-	log, err := newLog(Warning, opts.tags, formattedMessage)
-	if err != nil {
-		return err
-	}
-	return createNewLog(opts, log)
+func (opts *Logger) Warn(message string, args ...any) (int64, error) {
+	return opts.Log(Warning, message, args...)
 }
 
 // Error creates an error log message in the database
 // with the message and arguments passed
 // it formats the message with the arguments using fmt.Sprintf
 // The new log is created in the database, but it is not printed
+// it returns the id of the new log (0 if it is not stored, see Log)
 // if it fails to create the log it will return an error
-func (opts *Logger) Error(message string, args ...any) error {
-	formattedMessage := fmt.Sprintf(message, args...)
-	log, err := newLog(Error, opts.tags, formattedMessage)
+func (opts *Logger) Error(message string, args ...any) (int64, error) {
+	return opts.Log(Error, message, args...)
+}
+
+// Err creates an error log in the database only if the error passed is not nil
+// it uses the error message as the message of the log and records the error
+// as structured fields of the log instead of formatting it into the message:
+//   - error_chain: the messages of the errors in the chain (following errors.Unwrap)
+//   - error_type: the type of the root cause of the error (e.g. *fs.PathError)
+//
+// Example:
+//
+//	if err := os.Remove(path); err != nil {
+//		log.Err(fmt.Errorf("cleanup failed: %w", err))
+//	}
+//
+// The new log is created in the database, but it is not printed
+// it returns the id of the new log (0 if the error is nil or the log is not stored, see Log)
+// if it fails to create the log it will return an error
+func (opts *Logger) Err(e error) (int64, error) {
+	if e == nil {
+		return 0, nil
+	}
+
+	l, err := newLog(Error, opts.getTags(), e.Error())
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return createNewLog(opts, log)
+
+	l.fields = errorFields(e)
+	return opts.writeLogID(l)
 }
 
 // Fatal creates a fatal log message in the database only if the error passed is not nil
 // it uses the error message as the message of the log
 // The new log is created in the database, but it is not printed
 // it will show an alert with the title and message set with SetFatal
-// this method will exit the program with code 1
-// if it fails to create the log it will return an error
+// the stack trace of the goroutine is saved in the "stack" field of the log (see CrashReport)
+// this method will exit the program with code 1, even if it fails to store the log
+// (the error is printed in the standard error before exiting)
 func (opts *Logger) Fatal(e error) error {
-	if e == nil {
+	if e == nil || !opts.enabled(Fatal) {
 		return nil
 	}
 
-	log, err := newLog(Fatal, opts.tags, e.Error())
+	log, err := newLog(Fatal, opts.getTags(), e.Error())
 	if err != nil {
 		return err
 	}
+	log.fields = map[string]any{stackField: string(debug.Stack())}
+	opts.captureGoroutines(log)
+	opts.captureProfiles(log)
 
-	err = createNewLog(opts, log)
-	if err != nil {
-		return err
+	if err = opts.writeLog(log); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 	}
 
-	beeep.Alert(opts.fatalTitle, opts.fatalMessage, "")
+	cfg := opts.Copy()
+	beeep.Alert(cfg.fatalTitle, cfg.fatalMessage, "")
 	os.Exit(1)
 	return nil
 }
 
-// PrintDebug prints a debug log message in the console
+// Print prints a log message with the level passed in the console
 // with the message and arguments passed
 // it formats the message with the arguments using fmt.Sprintf
+// Example:
+//
+//	log.Print(logger.Info, "listening on %s", addr)
+//
 // The new log is not created in the database
-// if it fails to print the log it will return an error
-func (opts *Logger) PrintDebug(message string, args ...any) error {
-	formattedMessage := fmt.Sprintf(message, args...)
-	l, err := newLog(Debug, opts.tags, formattedMessage)
+// a Fatal log printed with this method doesn't exit the program, use PrintFatal for that
+// if the level is not valid or it fails to print the log it will return an error
+func (opts *Logger) Print(level LogLevel, message string, args ...any) error {
+	if !level.valid() {
+		return fmt.Errorf("[logger-pkg] invalid log level %d", level)
+	}
+
+	l, err := newLog(level, opts.getTags(), fmt.Sprintf(message, args...))
 	if err != nil {
 		return err
 	}
-	printLogs(opts, []*log{l})
+	opts.printLog(l)
 	return nil
 }
 
+// PrintDebug prints a debug log message in the console
+// with the message and arguments passed
+// it formats the message with the arguments using fmt.Sprintf
+// The new log is not created in the database
+// if it fails to print the log it will return an error
+func (opts *Logger) PrintDebug(message string, args ...any) error {
+	return opts.Print(Debug, message, args...)
+}
+
 // PrintInfo prints an info log message in the console
 // with the message and arguments passed
 // it formats the message with the arguments using fmt.Sprintf
 // The new log is not created in the database
 // if it fails to print the log it will return an error
 func (opts *Logger) PrintInfo(message string, args ...any) error {
-	formattedMessage := fmt.Sprintf(message, args...)
-	l, err := newLog(Info, opts.tags, formattedMessage)
-	if err != nil {
-		return err
-	}
-	printLogs(opts, []*log{l})
-	return nil
+	return opts.Print(Info, message, args...)
 }
 
 // PrintWarn prints a warning log message in the console
@@ -289,13 +1105,7 @@ func (opts *Logger) PrintInfo(message string, args ...any) error {
 // The new log is not created in the database
 // if it fails to print the log it will return an error
 func (opts *Logger) PrintWarn(message string, args ...any) error {
-	formattedMessage := fmt.Sprintf(message, args...)
-	l, err := newLog(Warning, opts.tags, formattedMessage)
-	if err != nil {
-		return err
-	}
-	printLogs(opts, []*log{l})
-	return nil
+	return opts.Print(Warning, message, args...)
 }
 
 // PrintError prints an error log message in the console
@@ -304,12 +1114,25 @@ func (opts *Logger) PrintWarn(message string, args ...any) error {
 // The new log is not created in the database
 // if it fails to print the log it will return an error
 func (opts *Logger) PrintError(message string, args ...any) error {
-	formattedMessage := fmt.Sprintf(message, args...)
-	l, err := newLog(Error, opts.tags, formattedMessage)
+	return opts.Print(Error, message, args...)
+}
+
+// PrintErr prints an error log in the console only if the error passed is not nil
+// with the error chain and the type of the root cause as fields, like Err
+// The new log is not created in the database
+// if it fails to print the log it will return an error
+func (opts *Logger) PrintErr(e error) error {
+	if e == nil {
+		return nil
+	}
+
+	l, err := newLog(Error, opts.getTags(), e.Error())
 	if err != nil {
 		return err
 	}
-	printLogs(opts, []*log{l})
+
+	l.fields = errorFields(e)
+	opts.printLog(l)
 	return nil
 }
 
@@ -319,29 +1142,358 @@ func (opts *Logger) PrintError(message string, args ...any) error {
 // The new log is not created in the database
 // if it fails to print the log it will return an error
 func (opts *Logger) PrintFatal(e error) error {
-	if e == nil {
+	if e == nil || !opts.enabled(Fatal) {
 		return nil
 	}
 
-	l, err := newLog(Fatal, opts.tags, e.Error())
+	l, err := newLog(Fatal, opts.getTags(), e.Error())
 	if err != nil {
 		return err
 	}
 
-	printLogs(opts, []*log{l})
+	opts.printLog(l)
 	os.Exit(1)
 	return nil
 }
 
+// writeAndPrint creates the log in the store and prints the same log in the console
+// so the stored and the printed log share the same timestamp and caller info
+// the log is processed once and printed even if it fails to be stored
+func (opts *Logger) writeAndPrint(l *log) (int64, error) {
+	return opts.write(l, true)
+}
+
+// LogDebug creates a debug log message in the database and prints it in the console
+// with the message and arguments passed
+// it formats the message with the arguments using fmt.Sprintf
+// it is the same as calling Debug and PrintDebug, but the caller and timestamp
+// of the printed log are the same as the stored one
+// it returns the id of the new log (0 if it is not stored, see Log)
+// if it fails to create the log it will return an error
+func (opts *Logger) LogDebug(message string, args ...any) (int64, error) {
+	formattedMessage := fmt.Sprintf(message, args...)
+	l, err := newLog(Debug, opts.getTags(), formattedMessage)
+	if err != nil {
+		return 0, err
+	}
+	return opts.writeAndPrint(l)
+}
+
+// LogInfo creates an info log message in the database and prints it in the console
+// with the message and arguments passed
+// it formats the message with the arguments using fmt.Sprintf
+// it is the same as calling Info and PrintInfo, but the caller and timestamp
+// of the printed log are the same as the stored one
+// it returns the id of the new log (0 if it is not stored, see Log)
+// if it fails to create the log it will return an error
+func (opts *Logger) LogInfo(message string, args ...any) (int64, error) {
+	formattedMessage := fmt.Sprintf(message, args...)
+	l, err := newLog(Info, opts.getTags(), formattedMessage)
+	if err != nil {
+		return 0, err
+	}
+	return opts.writeAndPrint(l)
+}
+
+// LogWarn creates a warning log message in the database and prints it in the console
+// with the message and arguments passed
+// it formats the message with the arguments using fmt.Sprintf
+// it is the same as calling Warn and PrintWarn, but the caller and timestamp
+// of the printed log are the same as the stored one
+// it returns the id of the new log (0 if it is not stored, see Log)
+// if it fails to create the log it will return an error
+func (opts *Logger) LogWarn(message string, args ...any) (int64, error) {
+	formattedMessage := fmt.Sprintf(message, args...)
+	l, err := newLog(Warning, opts.getTags(), formattedMessage)
+	if err != nil {
+		return 0, err
+	}
+	return opts.writeAndPrint(l)
+}
+
+// LogError creates an error log message in the database and prints it in the console
+// with the message and arguments passed
+// it formats the message with the arguments using fmt.Sprintf
+// it is the same as calling Error and PrintError, but the caller and timestamp
+// of the printed log are the same as the stored one
+// it returns the id of the new log (0 if it is not stored, see Log)
+// if it fails to create the log it will return an error
+func (opts *Logger) LogError(message string, args ...any) (int64, error) {
+	formattedMessage := fmt.Sprintf(message, args...)
+	l, err := newLog(Error, opts.getTags(), formattedMessage)
+	if err != nil {
+		return 0, err
+	}
+	return opts.writeAndPrint(l)
+}
+
 // PrintLogs prints the logs in the database based on the query options passed
 // if it fails to query the logs it will return an error
 func (opts *Logger) PrintLogs(queryOptions ...QueryOption) error {
-	logs, err := queryLogs(opts, queryOptions...)
+	return opts.PrintLogsContext(context.Background(), queryOptions...)
+}
+
+// PrintLogsContext prints the logs in the database based on the query options passed, as PrintLogs,
+// the query is interrupted when the context passed is done (e.g. on a timeout or on the shutdown)
+// if it fails to query the logs it will return an error, that wraps the error of the context if it is done
+func (opts *Logger) PrintLogsContext(ctx context.Context, queryOptions ...QueryOption) error {
+	logs, err := opts.queryLogsContext(ctx, queryOptions...)
 	if err != nil {
 		return err
 	}
 
-	printLogs(opts, logs)
+	pageLogs(opts.searchCopy(queryOptions), logs)
+	return nil
+}
+
+// RenderLogs returns the logs in the database based on the query options passed
+// rendered as PrintLogs prints them, e.g. to show them in a TUI pane or to check them in a test
+// if the output of the logger is not a terminal the styled logs have the default width
+// if it fails to query the logs it will return an error
+func (opts *Logger) RenderLogs(queryOptions ...QueryOption) (string, error) {
+	logs, err := opts.queryLogs(queryOptions...)
+	if err != nil {
+		return "", err
+	}
+
+	return renderLogs(opts.searchCopy(queryOptions), logs), nil
+}
+
+// searchCopy returns a copy of the logger that highlights the terms
+// searched by the query options passed (MessageLike and Search filters)
+func (opts *Logger) searchCopy(queryOptions []QueryOption) *Logger {
+	l := opts.Copy()
+	l.highlights = searchTerms(queryOptions...)
+	return l
+}
+
+// SetOutput sets the writer of the logs printed by the logger (the Print methods,
+// PrintLogs, the console-only logs, ...), e.g. os.Stderr, a file or a buffer
+// passing nil restores the standard output (default)
+// Example:
+//
+//	log.SetOutput(os.Stderr)
+func (opts *Logger) SetOutput(w io.Writer) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.output = w
+}
+
+// getOutput returns the writer of the logs printed by the logger
+func (opts *Logger) getOutput() io.Writer {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	if opts.output == nil {
+		return os.Stdout
+	}
+	return opts.output
+}
+
+// PrintResult represents the outcome of PrintLogsResult
+// its values are meant to be used as exit codes of scripts and CLIs,
+// so they can branch on the logs without parsing the output
+type PrintResult int
+
+const (
+	MatchesFound         PrintResult = 0 // at least one log matched the query, none of them is an error
+	NoMatches            PrintResult = 1 // no logs matched the query
+	MatchesIncludeErrors PrintResult = 3 // at least one of the logs matched is an error or a fatal log
+)
+
+// PrintLogsResult prints the logs in the database based on the query options passed
+// like PrintLogs, and returns the outcome of the query as a PrintResult
+// Example:
+//
+//	res, err := log.PrintLogsResult(queries.HasTags("job"))
+//	if err != nil {
+//		os.Exit(2)
+//	}
+//	os.Exit(int(res))
+//
+// if it fails to query the logs it will return an error
+func (opts *Logger) PrintLogsResult(queryOptions ...QueryOption) (PrintResult, error) {
+	logs, err := opts.queryLogs(queryOptions...)
+	if err != nil {
+		return NoMatches, err
+	}
+
+	pageLogs(opts.searchCopy(queryOptions), logs)
+	if len(logs) == 0 {
+		return NoMatches, nil
+	}
+
+	for _, l := range logs {
+		if l.level >= Error {
+			return MatchesIncludeErrors, nil
+		}
+	}
+
+	return MatchesFound, nil
+}
+
+// GetLogs returns the logs in the database based on the query options passed
+// the returned entries are copies, they can be shared across goroutines
+// (e.g. fanned out to workers) without any synchronization
+// if it fails to query the logs it will return an error
+func (opts *Logger) GetLogs(queryOptions ...QueryOption) ([]Entry, error) {
+	return opts.GetLogsContext(context.Background(), queryOptions...)
+}
+
+// GetLogsContext returns the logs in the database based on the query options passed, as GetLogs,
+// the query is interrupted when the context passed is done, so a slow query over a huge database
+// can be cancelled
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	logs, err := log.GetLogsContext(ctx, queries.MessageLike("timeout"))
+//	if errors.Is(err, context.DeadlineExceeded) {
+//		// the query took too long
+//	}
+//
+// if it fails to query the logs it will return an error, that wraps the error of the context if it is done
+func (opts *Logger) GetLogsContext(ctx context.Context, queryOptions ...QueryOption) ([]Entry, error) {
+	logs, err := opts.queryLogsContext(ctx, queryOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEntries(logs), nil
+}
+
+// LogsPage represents a page of the logs returned by GetLogsPage
+type LogsPage struct {
+	Logs    []Entry `json:"logs"`     // the logs of the page
+	Page    int     `json:"page"`     // the number of the page, starting from 1
+	PerPage int     `json:"per_page"` // the maximum number of logs of every page
+	Total   int     `json:"total"`    // the number of logs selected by the query, in every page
+	Pages   int     `json:"pages"`    // the number of pages, 0 if there are no logs
+	HasPrev bool    `json:"has_prev"` // if true there is a previous page
+	HasNext bool    `json:"has_next"` // if true there is a next page
+}
+
+// GetLogsPage returns a page of the logs in the database selected by the query options passed,
+// with the number of logs and of pages, so the user interfaces don't compute the limits and the offsets
+// the pages start from 1, a page after the last one has no logs
+// the query options can filter and sort the logs, but they must not limit them (see queries.AddLimit)
+// Example:
+//
+//	page, err := log.GetLogsPage(2, 50, queries.LevelEqual(logger.Error), queries.SortID("DESC"))
+//	fmt.Printf("page %d of %d (%d errors)\n", page.Page, page.Pages, page.Total)
+//
+// this method returns an error if the page or the number of logs per page is not positive
+// or if it fails to query the logs
+func (opts *Logger) GetLogsPage(page, perPage int, queryOptions ...QueryOption) (LogsPage, error) {
+	if page < 1 || perPage < 1 {
+		return LogsPage{}, fmt.Errorf("[logger-pkg] invalid page %d with %d logs per page, both must be positive", page, perPage)
+	}
+
+	result := LogsPage{Page: page, PerPage: perPage}
+	offset := (page - 1) * perPage
+	store := opts.getStore()
+	if s, ok := sqliteStoreOf(store); ok && len(s.readPaths()) == 0 {
+		total, err := countLogs(context.Background(), s, queryOptions...)
+		if err != nil {
+			return LogsPage{}, err
+		}
+
+		result.Total = total
+		result.Logs = make([]Entry, 0)
+		if offset < total {
+			pageOptions := append(append(make([]QueryOption, 0, len(queryOptions)+1), queryOptions...), func(sb *strings.Builder) {
+				sb.WriteString(fmt.Sprintf(" LIMIT %d OFFSET %d", perPage, offset))
+			})
+			entries, err := store.Query(context.Background(), pageOptions...)
+			if err != nil {
+				return LogsPage{}, err
+			}
+			result.Logs = entries
+		}
+	} else {
+		// the other stores (and the federated databases) are paged in memory
+		entries, err := store.Query(context.Background(), queryOptions...)
+		if err != nil {
+			return LogsPage{}, err
+		}
+
+		result.Total = len(entries)
+		entries = entries[min(offset, len(entries)):]
+		result.Logs = entries[:min(perPage, len(entries))]
+	}
+
+	result.Pages = (result.Total + perPage - 1) / perPage
+	result.HasPrev = page > 1
+	result.HasNext = page < result.Pages
+	return result, nil
+}
+
+// Tail returns the last n logs in the database sorted from the oldest to the newest
+// the returned entries are copies, they can be shared across goroutines
+// (e.g. fanned out to workers) without any synchronization
+// if n is not positive or it fails to query the logs it will return an error
+func (opts *Logger) Tail(n int) ([]Entry, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("[logger-pkg] invalid number of logs %d, it must be positive", n)
+	}
+
+	logs, err := opts.queryLogs(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf(" ORDER BY logs.id DESC LIMIT %d", n))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+		logs[i], logs[j] = logs[j], logs[i]
+	}
+
+	return toEntries(logs), nil
+}
+
+// AssertNo checks that the database contains no logs with the level passed
+// or a higher one created since the time passed
+// it returns nil if there are no matching logs, otherwise it returns an error
+// with the number of the matching logs, so it can be used in CI pipelines
+// to fail a run when the application logged errors during the tests
+// Example:
+//
+//	start := time.Now()
+//	runIntegrationTests()
+//	if err := log.AssertNo(logger.Error, start); err != nil {
+//		fmt.Println(err)
+//		os.Exit(1)
+//	}
+func (opts *Logger) AssertNo(level LogLevel, since time.Time) error {
+	var count int
+	bound := since.UTC().Format("2006-01-02 15:04:05")
+	if s, ok := sqliteStoreOf(opts.getStore()); ok && len(s.readPaths()) == 0 {
+		db, err := getDBConnection(s)
+		if err != nil {
+			return err
+		}
+		defer releaseDBConnection(s, db)
+
+		// the logs are counted without the join with the tags, so the untagged ones are included
+		query := "SELECT COUNT(*) FROM logs WHERE logs.level >= ? AND " + instantColumn + " >= ?"
+		s.logSQL(query)
+		if err := db.QueryRow(query+";", int(level), bound).Scan(&count); err != nil {
+			return errors.New("[logger-pkg] failed to count the logs: " + err.Error())
+		}
+	} else {
+		// the other stores (and the federated databases) are counted in memory
+		logs, err := opts.queryLogs(func(sb *strings.Builder) {
+			sb.WriteString(fmt.Sprintf(" WHERE logs.level >= %d AND %s >= '%s'", level, instantColumn, bound))
+		})
+		if err != nil {
+			return err
+		}
+		count = len(logs)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("[logger-pkg] found %d logs with level %s or higher since %s", count, level.String(), since.Format("2006-01-02 15:04:05"))
+	}
+
 	return nil
 }
 
@@ -352,24 +1504,200 @@ func (opts *Logger) PrintLogs(queryOptions ...QueryOption) error {
 //   - LOG: exports the logs in a .log file
 //   - JSON: exports the logs in a .json file
 //   - CSV: exports the logs in a .csv file
+//   - NDJSON: exports the logs in a .ndjson file (one JSON object per line)
+//   - Template: exports the logs with the templates set with SetExportTemplate
 //
 // the target folder for the exported file will be the folder path set in the logger
+// and the file is named as 20240102150405_logs.json, SetExportOptions changes them
+// and splits the exports in more files by day, number of logs or size
+// the attachments of the logs are exported next to the file if ExportAttachments is enabled
 //
-// this method returns the path of the exported file and an error if it fails to export the logs
+// this method returns the path of the exported file (of the manifest of the split exports)
+// and an error if it fails to export the logs
 func (opts *Logger) Export(exportType ExportType, queryOptions ...QueryOption) (string, error) {
-	logs, err := queryLogs(opts, queryOptions...)
+	return opts.ExportContext(context.Background(), exportType, queryOptions...)
+}
+
+// ExportContext exports the logs in the database based on the query options passed
+// to the export type passed, as Export, the query is interrupted when the context passed is done
+// this method returns the path of the exported file and an error if it fails to export the logs,
+// that wraps the error of the context if it is done
+func (opts *Logger) ExportContext(ctx context.Context, exportType ExportType, queryOptions ...QueryOption) (string, error) {
+	logs, err := opts.queryLogsContext(ctx, queryOptions...)
 	if err != nil {
 		return "", err
 	}
 
-	switch exportType {
-	case JSON:
-		return exportJson(logs, opts.folderPath)
-	case CSV:
-		return exportCSV(logs, opts.folderPath)
-	default: // LOG
-		return exportLogFile(logs, opts.folderPath)
+	cfg := opts.Copy()
+	path, err := exportLogs(exportType, logs, cfg)
+	if err != nil || !cfg.exportAttachments {
+		return path, err
+	}
+	return path, opts.writeAttachments(path, logs)
+}
+
+// Import imports in the database the logs exported in JSON or NDJSON format in the file passed,
+// the gzip-compressed exports and archives (.gz) are decompressed
+// every log is identified by a checksum of its content, so the logs already stored
+// in the database are skipped: importing the same file twice, or files exported
+// from overlapping queries, doesn't create duplicated logs
+//
+// this method returns the number of logs imported and an error if it fails to import the logs
+func (opts *Logger) Import(path string) (int, error) {
+	data, err := readExport(path)
+	if err != nil {
+		return 0, errors.New("[logger-pkg] failed to read the file to import: " + err.Error())
+	}
+
+	logs, err := parseJSONLogs(data)
+	if err != nil {
+		return 0, errors.New("[logger-pkg] failed to parse the file to import: " + err.Error())
+	}
+
+	return opts.importLogs(logs)
+}
+
+// ImportEntries imports the entries passed in the database, keeping their level, time,
+// caller, tags and fields, so the logs received from other processes (e.g. by the server package)
+// are stored as they were created, the ID field of the entries is ignored
+// as Import, the entries already stored in the database are skipped
+// this method returns the number of entries imported and an error if it fails to import them
+func (opts *Logger) ImportEntries(entries ...Entry) (int, error) {
+	return opts.importLogs(fromEntries(entries))
+}
+
+// importLogs imports the logs passed in the database of the logger, skipping the ones already stored
+func (opts *Logger) importLogs(logs []*log) (int, error) {
+	s := opts.getStore()
+	store, ok := s.(*sqliteStore)
+	if !ok {
+		return 0, unsupported(s)
+	}
+
+	var imported int
+	err := store.retry(context.Background(), func() error {
+		var err error
+		imported, err = importLogs(context.Background(), store, logs)
+		return err
+	})
+	return imported, err
+}
+
+// DeleteLogs deletes the logs in the database based on the query options passed
+// if no query options are passed every log will be deleted
+// this method returns the number of deleted logs and an error if it fails to delete the logs
+func (opts *Logger) DeleteLogs(queryOptions ...QueryOption) (int64, error) {
+	return opts.DeleteLogsContext(context.Background(), queryOptions...)
+}
+
+// DeleteLogsContext deletes the logs in the database based on the query options passed, as DeleteLogs,
+// the deletion is interrupted and rolled back when the context passed is done
+// this method returns the number of deleted logs and an error if it fails to delete the logs,
+// that wraps the error of the context if it is done
+func (opts *Logger) DeleteLogsContext(ctx context.Context, queryOptions ...QueryOption) (int64, error) {
+	deleted, err := opts.getStore().Delete(ctx, queryOptions...)
+	if err != nil {
+		return deleted, cancelled(ctx, err)
 	}
+	return deleted, nil
+}
+
+// previewSampleSize is the maximum number of logs returned by PreviewDelete
+const previewSampleSize = 5
+
+// PreviewDelete returns the number of logs that DeleteLogs would delete
+// with the query options passed and a sample of them (at most 5, the first ones
+// returned by the query), without deleting anything
+// it can be used to ask for a confirmation before a destructive operation
+// Example:
+//
+//	count, sample, _ := log.PreviewDelete(queries.TimestampLessThan(monthAgo))
+//	fmt.Printf("%d logs will be deleted, e.g. %q\n", count, sample[0].Message)
+//	if confirmed {
+//		log.DeleteLogs(queries.TimestampLessThan(monthAgo))
+//	}
+//
+// if it fails to query the logs it will return an error
+func (opts *Logger) PreviewDelete(queryOptions ...QueryOption) (int64, []Entry, error) {
+	store := opts.getStore()
+	if s, ok := sqliteStoreOf(store); ok && len(s.readPaths()) == 0 {
+		count, err := countLogs(context.Background(), s, queryOptions...)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		sampleOptions := append(append(make([]QueryOption, 0, len(queryOptions)+1), queryOptions...), func(sb *strings.Builder) {
+			sb.WriteString(fmt.Sprintf(" LIMIT %d", previewSampleSize))
+		})
+		sample, err := store.Query(context.Background(), sampleOptions...)
+		if err != nil {
+			return 0, nil, err
+		}
+		return int64(count), sample, nil
+	}
+
+	// the other stores (and the federated databases) are counted in memory
+	entries, err := store.Query(context.Background(), queryOptions...)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	sample := entries
+	if len(sample) > previewSampleSize {
+		sample = sample[:previewSampleSize]
+	}
+	return int64(len(entries)), sample, nil
+}
+
+// SetClockOffset stores in the database a correction added to the times of its logs
+// when they are queried, printed and exported, so the logs of machines with a skewed
+// clock interleave correctly with the others when the databases are merged
+// (e.g. with a MultiReader); a positive offset moves the logs forward in time
+// the offset belongs to the database, so it is applied to every logger using it
+// and it can be set on a snapshot too, an offset of 0 removes the correction
+// Example:
+//
+//	snapshot, _ := logger.OpenSnapshot("host-b/logs_data.db")
+//	hostB := log.Copy()
+//	hostB.SetStore(snapshot)
+//	hostB.SetClockOffset(-90 * time.Second) // host-b clock is 90 seconds ahead
+//
+// Note: the time filters of the queries compare the stored times, without the correction
+// this method returns ErrNotSupported if the store is not a SQLite store or a snapshot
+func (opts *Logger) SetClockOffset(offset time.Duration) error {
+	s := opts.getStore()
+	store, ok := sqliteStoreOf(s)
+	if !ok {
+		return unsupported(s)
+	}
+
+	value := ""
+	if offset != 0 {
+		value = offset.String()
+	}
+
+	return store.retry(context.Background(), func() error {
+		return setMetadata(context.Background(), store, clockOffsetKey, value)
+	})
+}
+
+// ClockOffset returns the correction added to the times of the logs of the database
+// set with SetClockOffset, it returns 0 if no correction is set
+// this method returns ErrNotSupported if the store is not a SQLite store or a snapshot
+func (opts *Logger) ClockOffset() (time.Duration, error) {
+	s := opts.getStore()
+	store, ok := sqliteStoreOf(s)
+	if !ok {
+		return 0, unsupported(s)
+	}
+
+	db, err := getDBConnection(store)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseDBConnection(store, db)
+
+	return getClockOffset(context.Background(), db)
 }
 
 func createExportFile(filePath string) (*os.File, error) {
@@ -391,105 +1719,139 @@ func createExportFile(filePath string) (*os.File, error) {
 	return file, nil
 }
 
-func exportJson(logs []*log, folder string) (string, error) {
-	filePath := filepath.Join(folder, fmt.Sprintf("%s_logs.json", time.Now().Format("20060102150405")))
-	file, err := createExportFile(filePath)
+// exportFormat returns the extension of the files of the export type passed
+// and the function writing the logs in that format
+func (opts *Logger) exportFormat(exportType ExportType) (string, func(io.Writer, []*log, string) error) {
+	switch exportType {
+	case Template:
+		return opts.exportTemplate.format()
+	case JSON:
+		return ".json", writeJSON
+	case CSV:
+		return ".csv", writeCSV
+	case NDJSON:
+		return ".ndjson", writeNDJSON
+	default: // LOG
+		return ".log", writeLogFile
+	}
+}
+
+// exportLogs writes the logs in a new file in the format of the export type, named and placed
+// as the export options of the logger passed set, or in more files if the options split the exports
+// it returns the path of the file (of the manifest for the split exports)
+func exportLogs(exportType ExportType, logs []*log, cfg *Logger) (string, error) {
+	ext, write := cfg.exportFormat(exportType)
+	filePath := cfg.exportOptions.exportPath(cfg.folderPath, ext, cfg.tags, time.Now())
+	if cfg.exportOptions.split() {
+		return cfg.exportOptions.exportChunks(filePath, ext, logs, write, cfg.timeLayout)
+	}
+
+	write = cfg.exportOptions.compressed(write)
+	file, filePath, err := cfg.exportOptions.create(filePath)
 	if err != nil {
 		return "", err
 	}
 
 	defer file.Close()
 
+	err = write(file, logs, cfg.timeLayout)
+	if err != nil {
+		file.Close()
+		os.Remove(filePath)
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+func writeJSON(w io.Writer, logs []*log, layout string) error {
 	if len(logs) == 0 {
-		_, err = file.WriteString("[]")
-		if err != nil {
-			return "", err
-		}
-		return filePath, nil
+		_, err := io.WriteString(w, "[]")
+		return err
 	}
 
-	_, err = file.WriteString("[\n")
+	_, err := io.WriteString(w, "[\n")
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	for i, log := range logs {
 		if i > 0 {
-			_, err = file.WriteString(",\n")
+			_, err = io.WriteString(w, ",\n")
 			if err != nil {
-				return "", err
+				return err
 			}
 		}
 
-		_, err = file.WriteString(log.toJSON())
+		_, err = io.WriteString(w, log.toJSON(layout))
 		if err != nil {
-			return "", err
+			return err
 		}
 	}
 
-	_, err = file.WriteString("\n]")
-	if err != nil {
-		return "", err
-	}
-
-	return filePath, nil
+	_, err = io.WriteString(w, "\n]")
+	return err
 }
 
-func exportCSV(logs []*log, folder string) (string, error) {
-	filePath := filepath.Join(folder, fmt.Sprintf("%s_logs.csv", time.Now().Format("20060102150405")))
-	file, err := createExportFile(filePath)
-	if err != nil {
-		return "", err
+func writeNDJSON(w io.Writer, logs []*log, layout string) error {
+	for _, log := range logs {
+		_, err := io.WriteString(w, log.toJSONLine(layout)+"\n")
+		if err != nil {
+			return err
+		}
 	}
 
-	defer file.Close()
+	return nil
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+func writeCSV(w io.Writer, logs []*log, layout string) error {
+	writer := csv.NewWriter(w)
 
-	err = writer.Write([]string{"level", "tags", "timestamp", "caller_file", "caller_line", "caller_function", "message"})
+	err := writer.Write([]string{"level", "tags", "timestamp", "caller_file", "caller_line", "caller_function", "message", "rfc3339", "fields", "run_id", "hostname", "pid", "goroutine", "count", "correlation"})
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	for _, log := range logs {
 		err = writer.Write([]string{
 			log.level.String(),
 			strings.Join(log.tags, "|"),
-			log.timestamp.String(),
+			log.timestamp.format(layout),
 			log.callerFile,
 			fmt.Sprintf("%d", log.callerLine),
 			log.callerFunction,
 			log.message,
+			log.timestamp.rfc3339(),
+			marshalFields(log.fields),
+			log.runID,
+			log.hostname,
+			fmt.Sprintf("%d", log.pid),
+			fmt.Sprintf("%d", log.goroutine),
+			fmt.Sprintf("%d", log.occurrences()),
+			log.correlation,
 		})
 		if err != nil {
-			return "", err
+			return err
 		}
 	}
-	return filePath, nil
-}
-
-func exportLogFile(logs []*log, folder string) (string, error) {
-	filePath := filepath.Join(folder, fmt.Sprintf("%s_logs.log", time.Now().Format("20060102150405")))
-	file, err := createExportFile(filePath)
-	if err != nil {
-		return "", err
-	}
 
-	defer file.Close()
+	writer.Flush()
+	return writer.Error()
+}
 
+func writeLogFile(w io.Writer, logs []*log, layout string) error {
 	for i, log := range logs {
 		if i > 0 {
-			_, err = file.WriteString("\n")
+			_, err := io.WriteString(w, "\n")
 			if err != nil {
-				return "", err
+				return err
 			}
 		}
 
-		_, err := file.WriteString(log.String())
+		_, err := io.WriteString(w, log.format(layout))
 		if err != nil {
-			return "", err
+			return err
 		}
 	}
-	return filePath, nil
+	return nil
 }
@@ -0,0 +1,168 @@
+// Package loggertest makes the applications using the logger package easy to test:
+// it creates loggers that keep the logs in memory instead of a database,
+// print them with t.Log (so they are shown only for the failed tests or with -v)
+// and it provides assertions on the logs created
+// Example:
+//
+//	func TestCheckout(t *testing.T) {
+//		log := loggertest.New(t, "checkout")
+//		svc := NewService(log)
+//
+//		svc.Checkout(cart)
+//
+//		loggertest.AssertLogged(t, logger.Info, "order created")
+//		loggertest.AssertNoErrors(t)
+//	}
+//
+// The loggers never touch the filesystem (see logger.NewConsoleOnly): the methods that need
+// the database return logger.ErrNoStore, and Fatal still exits the program
+package loggertest
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Tagliapietra96/logger/v2"
+)
+
+// recorders are the recorders of the tests, by test
+var recorders sync.Map
+
+// Recorder is a logger.Sink that keeps the logs in memory
+type Recorder struct {
+	mu      sync.Mutex
+	entries []logger.Entry
+}
+
+// Write keeps the entry passed, it implements the logger.Sink interface
+func (r *Recorder) Write(entry logger.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// Entries returns a copy of the logs kept, in the order they were created
+func (r *Recorder) Entries() []logger.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.entries)
+}
+
+// Reset removes the logs kept
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// New returns a logger with the tags passed that keeps its logs in the recorder of the test
+// (see Logs and the assertions) and prints them with tb.Log, the loggers created
+// for the same test share its recorder
+func New(tb testing.TB, tags ...string) *logger.Logger {
+	tb.Helper()
+
+	l := logger.NewConsoleOnly(tags...)
+	l.Inline(true)
+	l.SetOutput(Writer(tb))
+	l.AddSink(RecorderOf(tb), logger.Debug)
+	return l
+}
+
+// RecorderOf returns the recorder of the test passed, it is removed when the test ends
+func RecorderOf(tb testing.TB) *Recorder {
+	r, loaded := recorders.LoadOrStore(tb, new(Recorder))
+	if !loaded {
+		tb.Cleanup(func() { recorders.Delete(tb) })
+	}
+	return r.(*Recorder)
+}
+
+// Logs returns the logs created by the loggers of the test passed
+func Logs(tb testing.TB) []logger.Entry {
+	return RecorderOf(tb).Entries()
+}
+
+// AssertLogged fails the test if its loggers didn't create a log with the level passed
+// and a message containing the text passed
+func AssertLogged(tb testing.TB, level logger.LogLevel, substring string) {
+	tb.Helper()
+
+	entries := Logs(tb)
+	for _, e := range entries {
+		if e.Level == level && strings.Contains(e.Message, substring) {
+			return
+		}
+	}
+	tb.Errorf("no %s log containing %q, the logs are:\n%s", level, substring, list(entries))
+}
+
+// AssertNotLogged fails the test if its loggers created a log with the level passed
+// and a message containing the text passed
+func AssertNotLogged(tb testing.TB, level logger.LogLevel, substring string) {
+	tb.Helper()
+
+	for _, e := range Logs(tb) {
+		if e.Level == level && strings.Contains(e.Message, substring) {
+			tb.Errorf("unexpected %s log: %s", level, e.Message)
+			return
+		}
+	}
+}
+
+// AssertNoErrors fails the test if its loggers created Error or Fatal logs
+func AssertNoErrors(tb testing.TB) {
+	tb.Helper()
+
+	var errs []logger.Entry
+	for _, e := range Logs(tb) {
+		if e.Level >= logger.Error {
+			errs = append(errs, e)
+		}
+	}
+
+	if len(errs) > 0 {
+		tb.Errorf("%d error logs:\n%s", len(errs), list(errs))
+	}
+}
+
+// list returns the logs passed as a list, one log per line
+func list(entries []logger.Entry) string {
+	if len(entries) == 0 {
+		return "  (none)"
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "  %s [%s] %s\n", e.Level, strings.Join(e.Tags, ", "), e.Message)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// tbWriter is the io.Writer of Writer
+type tbWriter struct {
+	tb testing.TB
+}
+
+// Writer returns an io.Writer that writes every line with tb.Log, so the logs printed
+// by a logger are shown with the output of the test:
+//
+//	log.SetOutput(loggertest.Writer(t))
+func Writer(tb testing.TB) io.Writer {
+	return tbWriter{tb: tb}
+}
+
+// Write logs the lines of the data passed with tb.Log
+func (w tbWriter) Write(p []byte) (int, error) {
+	w.tb.Helper()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line = strings.TrimRight(line, " "); strings.TrimSpace(line) != "" {
+			w.tb.Log(line)
+		}
+	}
+	return len(p), nil
+}
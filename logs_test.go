@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLogger returns a logger with the tags passed that writes in a temporary folder
+// and discards the printed logs
+func newTestLogger(t *testing.T, tags ...string) *Logger {
+	t.Helper()
+	l := New(tags...)
+	l.Folder(t.TempDir())
+	l.SetOutput(io.Discard)
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestGetLogsUntagged(t *testing.T) {
+	l := newTestLogger(t)
+	if _, err := l.Info("untagged"); err != nil {
+		t.Fatalf("Info() = %v", err)
+	}
+	if _, err := l.Child("one", "two").Info("tagged"); err != nil {
+		t.Fatalf("Info() = %v", err)
+	}
+
+	entries, err := l.GetLogs()
+	if err != nil {
+		t.Fatalf("GetLogs() = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("GetLogs() returned %d logs, want 2", len(entries))
+	}
+	if entries[0].Message != "untagged" || len(entries[0].Tags) != 0 {
+		t.Errorf("GetLogs()[0] = %q %v, want the untagged log", entries[0].Message, entries[0].Tags)
+	}
+}
+
+func TestTail(t *testing.T) {
+	l := newTestLogger(t)
+	for _, message := range []string{"first", "second", "third"} {
+		if _, err := l.Info(message); err != nil {
+			t.Fatalf("Info() = %v", err)
+		}
+	}
+
+	entries, err := l.Tail(2)
+	if err != nil {
+		t.Fatalf("Tail(2) = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Message != "second" || entries[1].Message != "third" {
+		t.Errorf("Tail(2) = %v, want the second and the third log", entries)
+	}
+
+	for _, n := range []int{0, -1} {
+		if _, err := l.Tail(n); err == nil {
+			t.Errorf("Tail(%d) = nil error, want an error", n)
+		}
+	}
+}
+
+func TestAssertNoUntagged(t *testing.T) {
+	l := newTestLogger(t)
+	start := time.Now().Add(-time.Second)
+	if _, err := l.Warn("warning"); err != nil {
+		t.Fatalf("Warn() = %v", err)
+	}
+	if err := l.AssertNo(Error, start); err != nil {
+		t.Errorf("AssertNo(Error) = %v, want nil", err)
+	}
+
+	if _, err := l.Error("untagged failure"); err != nil {
+		t.Fatalf("Error() = %v", err)
+	}
+	if err := l.AssertNo(Error, start); err == nil || !strings.Contains(err.Error(), "found 1 logs") {
+		t.Errorf("AssertNo(Error) = %v, want 1 matching log", err)
+	}
+	if err := l.AssertNo(Error, time.Now().Add(time.Hour)); err != nil {
+		t.Errorf("AssertNo(Error) in the future = %v, want nil", err)
+	}
+}
+
+func TestLogInfoTruncatedOnce(t *testing.T) {
+	l := newTestLogger(t)
+	var out strings.Builder
+	l.SetOutput(&out)
+	l.MaxMessageSize(10, true)
+
+	message := strings.Repeat("x", 100)
+	if _, err := l.LogInfo(message); err != nil {
+		t.Fatalf("LogInfo() = %v", err)
+	}
+
+	entries, err := l.Tail(1)
+	if err != nil {
+		t.Fatalf("Tail(1) = %v", err)
+	}
+	want := truncateMessage(message, 10)
+	if len(entries) != 1 || entries[0].Message != want {
+		t.Fatalf("stored message = %v, want %q", entries, want)
+	}
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("printed log = %q, want the stored message %q", out.String(), want)
+	}
+}
+
+func TestPreviewDelete(t *testing.T) {
+	l := newTestLogger(t)
+	for i := 0; i < 6; i++ {
+		if _, err := l.Info("untagged %d", i); err != nil {
+			t.Fatalf("Info() = %v", err)
+		}
+	}
+	if _, err := l.Child("one", "two").Info("tagged"); err != nil {
+		t.Fatalf("Info() = %v", err)
+	}
+
+	count, sample, err := l.PreviewDelete()
+	if err != nil {
+		t.Fatalf("PreviewDelete() = %v", err)
+	}
+	if count != 7 {
+		t.Errorf("PreviewDelete() count = %d, want 7", count)
+	}
+	if len(sample) != previewSampleSize || sample[0].Message != "untagged 0" {
+		t.Errorf("PreviewDelete() sample = %v, want the first %d logs", sample, previewSampleSize)
+	}
+
+	entries, err := l.GetLogs()
+	if err != nil {
+		t.Fatalf("GetLogs() = %v", err)
+	}
+	if len(entries) != 7 {
+		t.Errorf("GetLogs() after PreviewDelete returned %d logs, want 7", len(entries))
+	}
+}
+
+func TestImportEntriesSkipsDuplicates(t *testing.T) {
+	l := newTestLogger(t, "api")
+	for _, message := range []string{"first", "second"} {
+		if _, err := l.Info(message); err != nil {
+			t.Fatalf("Info() = %v", err)
+		}
+	}
+
+	entries, err := l.GetLogs()
+	if err != nil {
+		t.Fatalf("GetLogs() = %v", err)
+	}
+
+	// the same logs in another location have the same checksums
+	moved := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		e.Time = e.Time.In(time.FixedZone("UTC+5", 5*60*60))
+		moved = append(moved, e)
+	}
+	if imported, err := l.ImportEntries(moved...); err != nil || imported != 0 {
+		t.Errorf("ImportEntries() of the stored logs = %d, %v, want 0", imported, err)
+	}
+
+	other := newTestLogger(t, "api")
+	if imported, err := other.ImportEntries(moved...); err != nil || imported != 2 {
+		t.Fatalf("ImportEntries() = %d, %v, want 2", imported, err)
+	}
+	if imported, err := other.ImportEntries(entries...); err != nil || imported != 0 {
+		t.Errorf("ImportEntries() twice = %d, %v, want 0", imported, err)
+	}
+}
+
+func TestErr(t *testing.T) {
+	l := newTestLogger(t)
+	if id, err := l.Err(nil); id != 0 || err != nil {
+		t.Errorf("Err(nil) = %d, %v, want 0, nil", id, err)
+	}
+
+	id, err := l.Err(fmt.Errorf("cleanup failed: %w", os.ErrNotExist))
+	if err != nil || id == 0 {
+		t.Fatalf("Err() = %d, %v, want the id of the new log", id, err)
+	}
+
+	entries, err := l.GetLogs()
+	if err != nil {
+		t.Fatalf("GetLogs() = %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != id || entries[0].Level != Error || entries[0].Fields["error_chain"] == nil {
+		t.Errorf("GetLogs() = %v, want the error log %d with the error chain", entries, id)
+	}
+}
@@ -0,0 +1,272 @@
+// Package loki pushes the logs created by a logger to Grafana Loki,
+// so the package integrates with the existing Grafana observability stacks
+// Example:
+//
+//	sink := loki.New("http://loki:3100")
+//	sink.Labels(map[string]string{"app": "my-app", "env": "prod"})
+//	log.AddHook(sink)
+//	defer sink.Close()
+//
+// The logs are sent to the /loki/api/v1/push endpoint in batches from a background goroutine,
+// every 5 seconds or every 500 logs, the failed batches are sent again with a doubling wait
+// The streams of the logs have the following labels:
+//   - level: the level of the log (debug, info, warning, error, fatal)
+//   - tags: the tags of the log sorted and separated by commas, if any
+//   - the static labels set with Labels
+//
+// The lines are JSON objects with the message, the caller and the fields of the logs,
+// so they can be parsed with the json stage of LogQL: {app="my-app"} | json | caller_file="main.go"
+package loki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/internal/batch"
+)
+
+// pushPath is the path of the push API of Loki
+const pushPath = "/loki/api/v1/push"
+
+// Sink is a logger.Hook that pushes the logs stored by the logger to Loki
+type Sink struct {
+	url     string
+	mu      sync.RWMutex
+	client  *http.Client
+	labels  map[string]string // the static labels of every stream
+	headers http.Header       // the headers of the requests (e.g. X-Scope-OrgID, Authorization)
+	level   logger.LogLevel   // the minimum level of the logs pushed
+	batcher *batch.Batcher
+}
+
+// New returns a Sink pushing the logs to the Loki instance at the URL passed (e.g. http://loki:3100)
+// and starts its background goroutine, Close sends the buffered logs and stops it
+func New(url string) *Sink {
+	return NewBatched(url, batch.DefaultSize, batch.DefaultInterval)
+}
+
+// NewBatched returns a Sink like New, that sends the logs every interval
+// or when size logs are buffered
+func NewBatched(url string, size int, interval time.Duration) *Sink {
+	s := &Sink{
+		url:     strings.TrimSuffix(url, "/") + pushPath,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		labels:  make(map[string]string),
+		headers: make(http.Header),
+	}
+	s.batcher = batch.New(s.push, size, interval)
+	return s
+}
+
+// Labels adds the static labels passed to every stream (e.g. app, env, host)
+func (s *Sink) Labels(labels map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range labels {
+		s.labels[k] = v
+	}
+}
+
+// Header sets a header of the push requests, e.g. X-Scope-OrgID for a multi-tenant Loki
+// or Authorization for a Loki behind an authenticating proxy
+func (s *Sink) Header(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headers.Set(key, value)
+}
+
+// Client sets the HTTP client used to push the logs
+func (s *Sink) Client(client *http.Client) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client = client
+}
+
+// MinLevel sets the minimum level of the logs pushed (Debug by default, every log)
+func (s *Sink) MinLevel(level logger.LogLevel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.level = level
+}
+
+// Levels returns the levels of the logs pushed, it implements the logger.Hook interface
+func (s *Sink) Levels() []logger.LogLevel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	levels := make([]logger.LogLevel, 0)
+	for _, level := range logger.Levels() {
+		if level >= s.level {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// BeforeWrite doesn't modify the log, it implements the logger.Hook interface
+func (s *Sink) BeforeWrite(entry *logger.Entry) error {
+	return nil
+}
+
+// AfterWrite buffers the log stored to push it, it implements the logger.Hook interface
+func (s *Sink) AfterWrite(entry logger.Entry) {
+	s.batcher.Add(entry)
+}
+
+// Spool sets the folder where the batches that Loki failed to receive are saved,
+// they are pushed again when Loki is reachable, also by the next runs of the process,
+// so no log is lost during a network outage
+// this method returns an error if it fails to create the folder
+func (s *Sink) Spool(folder string) error {
+	return s.batcher.Spool(folder)
+}
+
+// Flush pushes the buffered logs now and returns an error if Loki rejects them
+func (s *Sink) Flush(ctx context.Context) error {
+	return s.batcher.Flush(ctx)
+}
+
+// Err returns the error of the last push, nil if it succeeded
+func (s *Sink) Err() error {
+	return s.batcher.Err()
+}
+
+// Close pushes the buffered logs and stops the background goroutine
+// this method returns an error if the buffered logs could not be pushed
+func (s *Sink) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return s.batcher.Close(ctx)
+}
+
+// stream is a stream of the push request, the logs with the same labels
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// line is the content of a log line pushed to Loki
+type line struct {
+	Message        string         `json:"message"`
+	CallerFile     string         `json:"caller_file,omitempty"`
+	CallerLine     int            `json:"caller_line,omitempty"`
+	CallerFunction string         `json:"caller_function,omitempty"`
+	Fields         map[string]any `json:"fields,omitempty"`
+	RunID          string         `json:"run_id,omitempty"`
+	Hostname       string         `json:"hostname,omitempty"`
+	PID            int            `json:"pid,omitempty"`
+	Count          int            `json:"count,omitempty"`
+}
+
+// push sends the entries passed to Loki, grouped in streams by their labels
+func (s *Sink) push(ctx context.Context, entries []logger.Entry) error {
+	s.mu.RLock()
+	client, url, headers := s.client, s.url, s.headers.Clone()
+	static := make(map[string]string, len(s.labels))
+	for k, v := range s.labels {
+		static[k] = v
+	}
+	s.mu.RUnlock()
+
+	streams := make([]*stream, 0)
+	byKey := make(map[string]*stream)
+	for _, e := range entries {
+		labels := streamLabels(static, e)
+		key := labelsKey(labels)
+		st, ok := byKey[key]
+		if !ok {
+			st = &stream{Stream: labels}
+			byKey[key] = st
+			streams = append(streams, st)
+		}
+
+		data, err := json.Marshal(line{
+			Message:        e.Message,
+			CallerFile:     e.CallerFile,
+			CallerLine:     e.CallerLine,
+			CallerFunction: e.CallerFunction,
+			Fields:         e.Fields,
+			RunID:          e.RunID,
+			Hostname:       e.Hostname,
+			PID:            e.PID,
+			Count:          e.Count,
+		})
+		if err != nil {
+			return errors.New("[logger-pkg] failed to encode the log for Loki: " + err.Error())
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(e.Time.UnixNano(), 10), string(data)})
+	}
+
+	body, err := json.Marshal(map[string]any{"streams": streams})
+	if err != nil {
+		return errors.New("[logger-pkg] failed to encode the logs for Loki: " + err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("[logger-pkg] failed to push the logs to Loki: " + err.Error())
+	}
+	req.Header = headers
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to push the logs to Loki: " + err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("[logger-pkg] failed to push the logs to Loki: %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// streamLabels returns the labels of the stream of the entry passed
+func streamLabels(static map[string]string, e logger.Entry) map[string]string {
+	labels := make(map[string]string, len(static)+2)
+	for k, v := range static {
+		labels[k] = v
+	}
+
+	labels["level"] = strings.ToLower(e.Level.String())
+	if len(e.Tags) > 0 {
+		tags := slices.Clone(e.Tags)
+		slices.Sort(tags)
+		labels["tags"] = strings.Join(slices.Compact(tags), ",")
+	}
+	return labels
+}
+
+// labelsKey returns a key identifying the labels passed
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+		b.WriteByte('\x00')
+	}
+	return b.String()
+}
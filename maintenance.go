@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultMaintenanceInterval is the interval of the maintenance if the one passed is not positive
+const defaultMaintenanceInterval = 24 * time.Hour
+
+// maintenance is the configuration of the tasks of StartMaintenance
+type maintenance struct {
+	archiveAfter  time.Duration // the age of the logs archived, if 0 the logs are not archived
+	archiveFormat ExportType    // the format of the archives
+	export        bool          // if true the logs stored since the previous maintenance are exported
+	exportFormat  ExportType    // the format of the exports
+}
+
+// MaintenanceArchive sets the maintenance (see StartMaintenance) to move the logs older than
+// the duration passed to a gzip-compressed archive in the format passed (see Archive),
+// a duration of 0 disables the archives (default)
+func (opts *Logger) MaintenanceArchive(olderThan time.Duration, format ExportType) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.maintenance.archiveAfter = max(olderThan, 0)
+	opts.maintenance.archiveFormat = format
+}
+
+// MaintenanceExport sets the maintenance (see StartMaintenance) to export the logs stored since
+// the previous maintenance in a file of the format passed (see Export) if the enabled parameter is true,
+// otherwise no export is written (default)
+// the first export of StartMaintenance contains the logs stored after it started
+func (opts *Logger) MaintenanceExport(enabled bool, format ExportType) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.maintenance.export = enabled
+	opts.maintenance.exportFormat = format
+}
+
+// Optimize updates the statistics of the SQLite database used by the queries (ANALYZE)
+// and rebuilds the database file to give back the space of the deleted logs (VACUUM)
+// only the current file is optimized if the rotation of the files is enabled (see Rotate)
+// Note: VACUUM rewrites the whole database, so it can take a while with large databases
+// this method returns ErrNotSupported if the store is not a SQLite store
+func (opts *Logger) Optimize() error {
+	s := opts.getStore()
+	store, ok := s.(*sqliteStore)
+	if !ok {
+		return unsupported(s)
+	}
+
+	return store.retry(context.Background(), func() error {
+		db, err := getWritableDBConnection(store)
+		if err != nil {
+			return err
+		}
+		defer releaseDBConnection(store, db)
+
+		for _, statement := range []string{"ANALYZE;", "VACUUM;"} {
+			if _, err := db.ExecContext(context.Background(), statement); err != nil {
+				return errors.New("[logger-pkg] failed to optimize the database: " + err.Error())
+			}
+		}
+		return nil
+	})
+}
+
+// StartMaintenance runs the maintenance of the database every interval (every 24 hours if the
+// interval is not positive) in a background goroutine until the context is done,
+// so the long-running services keep their database healthy without a cron job
+// the maintenance runs the following tasks, in order:
+//   - deletes the logs older than the retention (see Retention)
+//   - archives the old logs (see MaintenanceArchive)
+//   - exports the logs stored since the previous maintenance (see MaintenanceExport)
+//   - optimizes the database (see Optimize)
+//   - evaluates the scheduled alert rules (see AddAlert)
+//
+// the first maintenance runs after the first interval, the errors of the tasks are logged
+// as Error logs tagged "maintenance" and the other tasks still run
+// Example:
+//
+//	log.Retention(30 * 24 * time.Hour)
+//	log.MaintenanceArchive(7*24*time.Hour, logger.NDJSON)
+//	log.StartMaintenance(ctx, 24*time.Hour)
+func (opts *Logger) StartMaintenance(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultMaintenanceInterval
+	}
+
+	var exported int64
+	if last, err := opts.Tail(1); err == nil && len(last) > 0 {
+		exported = last[0].ID
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := opts.maintain(&exported); err != nil {
+				if l, logErr := newLog(Error, append(opts.getTags(), "maintenance"), err.Error()); logErr == nil {
+					opts.writeLog(l)
+				}
+			}
+		}
+	}()
+}
+
+// maintain runs the tasks of the maintenance (see StartMaintenance), the exported parameter
+// is the id of the last exported log and it is updated by the export
+// it returns the errors of the tasks joined
+func (opts *Logger) maintain(exported *int64) error {
+	opts.mu.RLock()
+	m := opts.maintenance
+	opts.mu.RUnlock()
+
+	var errs []error
+	if _, err := opts.Prune(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if m.archiveAfter > 0 {
+		if _, err := opts.Archive(m.archiveAfter, m.archiveFormat); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if m.export {
+		if err := opts.exportSince(exported, m.exportFormat); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := opts.Optimize(); err != nil && !errors.Is(err, ErrNotSupported) {
+		errs = append(errs, err)
+	}
+
+	if err := opts.evaluateAlerts(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// exportSince exports the logs with an id greater than the exported one in the format passed
+// and updates the exported id, no file is written if there are no new logs
+func (opts *Logger) exportSince(exported *int64, format ExportType) error {
+	logs, err := opts.queryLogs(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf(" WHERE logs.id > %d ORDER BY logs.id", *exported))
+	})
+	if err != nil || len(logs) == 0 {
+		return err
+	}
+
+	cfg := opts.Copy()
+	path, err := exportLogs(format, logs, cfg)
+	if err == nil && cfg.exportAttachments {
+		err = opts.writeAttachments(path, logs)
+	}
+	if err != nil {
+		return err
+	}
+
+	*exported = logs[len(logs)-1].id
+	return nil
+}
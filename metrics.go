@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// WriteLatencyBuckets are the upper bounds (in seconds) of the buckets of the write latency histogram
+var WriteLatencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// Metrics is a snapshot of the counters of the logs written by the process (by every logger)
+// it is returned by ReadMetrics, the metrics package exposes them as a prometheus.Collector
+type Metrics struct {
+	Logs         map[LogLevel]uint64 // the logs written in the stores, by level
+	WriteErrors  uint64              // the logs that failed to be written in the stores
+	SinkErrors   uint64              // the logs that failed to be written in a sink (see OnSinkError)
+	Dropped      uint64              // the logs discarded before being written (e.g. by a full async buffer)
+	WriteLatency Histogram           // the time taken by the stores to write the logs
+}
+
+// Histogram is a snapshot of the distribution of the durations of an operation
+type Histogram struct {
+	Buckets []float64 // the upper bounds of the buckets, in seconds
+	Counts  []uint64  // the cumulative number of durations lower or equal to each bound
+	Count   uint64    // the number of durations observed
+	Sum     float64   // the sum of the durations observed, in seconds
+}
+
+// metrics are the counters of the logs written by the process
+var metrics = struct {
+	sync.Mutex
+	logs        map[LogLevel]uint64
+	writeErrors uint64
+	sinkErrors  uint64
+	dropped     uint64
+	latency     []uint64 // the not cumulative counts of the buckets, the last one is +Inf
+	count       uint64
+	sum         float64
+}{logs: make(map[LogLevel]uint64), latency: make([]uint64, len(WriteLatencyBuckets)+1)}
+
+// ReadMetrics returns a snapshot of the counters of the logs written by the process:
+// the logs written by level, the write errors, the dropped logs and the write latency
+// Example:
+//
+//	m := logger.ReadMetrics()
+//	fmt.Println(m.Logs[logger.Error], m.WriteErrors)
+func ReadMetrics() Metrics {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	m := Metrics{
+		Logs:        make(map[LogLevel]uint64, len(metrics.logs)),
+		WriteErrors: metrics.writeErrors,
+		SinkErrors:  metrics.sinkErrors,
+		Dropped:     metrics.dropped,
+		WriteLatency: Histogram{
+			Buckets: append([]float64(nil), WriteLatencyBuckets...),
+			Counts:  make([]uint64, len(WriteLatencyBuckets)),
+			Count:   metrics.count,
+			Sum:     metrics.sum,
+		},
+	}
+
+	for level, n := range metrics.logs {
+		m.Logs[level] = n
+	}
+
+	var cumulative uint64
+	for i := range WriteLatencyBuckets {
+		cumulative += metrics.latency[i]
+		m.WriteLatency.Counts[i] = cumulative
+	}
+	return m
+}
+
+// observeWrite records the result of the write of a log with the level passed
+func observeWrite(level LogLevel, latency time.Duration, err error) {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	if err != nil {
+		metrics.writeErrors++
+		return
+	}
+
+	metrics.logs[level]++
+	seconds := latency.Seconds()
+	metrics.count++
+	metrics.sum += seconds
+
+	i := 0
+	for i < len(WriteLatencyBuckets) && seconds > WriteLatencyBuckets[i] {
+		i++
+	}
+	metrics.latency[i]++
+}
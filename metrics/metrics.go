@@ -0,0 +1,70 @@
+// Package metrics exposes the counters of the logs written by the process
+// (see logger.ReadMetrics) as a prometheus.Collector, so the services can alert
+// on the rate of the error logs or on the failed writes
+// Example:
+//
+//	prometheus.MustRegister(metrics.NewCollector())
+//	http.Handle("/metrics", promhttp.Handler())
+//
+// The collector exports the following metrics:
+//   - logger_logs_total{level}: the logs written in the stores, by level
+//   - logger_write_errors_total: the logs that failed to be written in the stores
+//   - logger_sink_errors_total: the logs that failed to be written in a sink
+//   - logger_dropped_total: the logs discarded before being written
+//   - logger_write_duration_seconds: the histogram of the time taken to write the logs
+package metrics
+
+import (
+	"strings"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector is the prometheus.Collector of the logger metrics
+type collector struct {
+	logs         *prometheus.Desc
+	writeErrors  *prometheus.Desc
+	sinkErrors   *prometheus.Desc
+	dropped      *prometheus.Desc
+	writeLatency *prometheus.Desc
+}
+
+// NewCollector returns a prometheus.Collector of the logs written by the process,
+// the metrics are read when the collector is scraped
+func NewCollector() prometheus.Collector {
+	return &collector{
+		logs:         prometheus.NewDesc("logger_logs_total", "The logs written in the stores, by level.", []string{"level"}, nil),
+		writeErrors:  prometheus.NewDesc("logger_write_errors_total", "The logs that failed to be written in the stores.", nil, nil),
+		sinkErrors:   prometheus.NewDesc("logger_sink_errors_total", "The logs that failed to be written in a sink.", nil, nil),
+		dropped:      prometheus.NewDesc("logger_dropped_total", "The logs discarded before being written.", nil, nil),
+		writeLatency: prometheus.NewDesc("logger_write_duration_seconds", "The time taken to write the logs in the stores.", nil, nil),
+	}
+}
+
+// Describe sends the descriptors of the metrics to the channel passed
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.logs
+	ch <- c.writeErrors
+	ch <- c.sinkErrors
+	ch <- c.dropped
+	ch <- c.writeLatency
+}
+
+// Collect sends the current values of the metrics to the channel passed
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	m := logger.ReadMetrics()
+
+	for _, level := range logger.Levels() {
+		ch <- prometheus.MustNewConstMetric(c.logs, prometheus.CounterValue, float64(m.Logs[level]), strings.ToLower(level.String()))
+	}
+	ch <- prometheus.MustNewConstMetric(c.writeErrors, prometheus.CounterValue, float64(m.WriteErrors))
+	ch <- prometheus.MustNewConstMetric(c.sinkErrors, prometheus.CounterValue, float64(m.SinkErrors))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(m.Dropped))
+
+	buckets := make(map[float64]uint64, len(m.WriteLatency.Buckets))
+	for i, bound := range m.WriteLatency.Buckets {
+		buckets[bound] = m.WriteLatency.Counts[i]
+	}
+	ch <- prometheus.MustNewConstHistogram(c.writeLatency, m.WriteLatency.Count, m.WriteLatency.Sum, buckets)
+}
@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"database/sql"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// baselineSchema is the schema of the databases written by the first version of the package
+const baselineSchema = `
+CREATE TABLE logs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	level INTEGER NOT NULL DEFAULT 0,
+	caller_file TEXT DEFAULT '',
+	caller_line INTEGER DEFAULT 0,
+	caller_function TEXT DEFAULT '',
+	message TEXT DEFAULT '',
+	time TEXT NOT NULL DEFAULT (datetime('now', 'localtime'))
+);
+
+CREATE TABLE tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE log_tags (
+    log_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (log_id, tag_id),
+    FOREIGN KEY (log_id) REFERENCES logs(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+INSERT INTO tags (name) VALUES ('legacy');
+INSERT INTO logs (level, caller_file, caller_line, caller_function, message, time) VALUES
+	(0, 'main.go', 10, 'main.main', 'debug', '2024-01-02 15:04:01'),
+	(1, 'main.go', 11, 'main.main', 'info', '2024-01-02 15:04:02'),
+	(2, 'main.go', 12, 'main.main', 'warning', '2024-01-02 15:04:03'),
+	(3, 'main.go', 13, 'main.main', 'error', '2024-01-02 15:04:04'),
+	(4, 'main.go', 14, 'main.main', 'fatal', '2024-01-02 15:04:05');
+INSERT INTO log_tags (log_id, tag_id) SELECT id, 1 FROM logs;
+`
+
+func TestMigrateBaselineSchema(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "logs_data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(baselineSchema); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	l := New("legacy")
+	l.Folder(dir)
+	l.SetOutput(io.Discard)
+	defer l.Close()
+
+	if _, err := l.Info("after the migration"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := l.GetLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]LogLevel{
+		"debug":               Debug,
+		"info":                Info,
+		"warning":             Warning,
+		"error":               Error,
+		"fatal":               Fatal,
+		"after the migration": Info,
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d logs, want %d", len(entries), len(want))
+	}
+	for _, e := range entries {
+		if e.Level != want[e.Message] {
+			t.Errorf("log %q has level %v, want %v", e.Message, e.Level, want[e.Message])
+		}
+	}
+
+	errors, err := l.GetLogs(func(sb *strings.Builder) {
+		sb.WriteString(" WHERE logs.level >= 30")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errors) != 2 {
+		t.Errorf("got %d logs with level Error or higher, want 2", len(errors))
+	}
+
+	db, err = sql.Open("sqlite3", filepath.Join(dir, "logs_data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	version, err := getSchemaVersion(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != schemaVersion {
+		t.Errorf("schema version %d, want %d", version, schemaVersion)
+	}
+}
+
+func TestMigrateOnlyOnce(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "logs_data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(baselineSchema); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	for i := 0; i < 2; i++ {
+		l := New("legacy")
+		l.Folder(dir)
+		entries, err := l.GetLogs()
+		l.Close()
+		pool.Lock()
+		delete(pool.migrated, filepath.Join(dir, "logs_data.db")) // as if a new process opened the database
+		pool.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if e.Message == "error" && e.Level != Error {
+				t.Fatalf("open %d: the error log has level %v, want %v", i+1, e.Level, Error)
+			}
+		}
+	}
+}
+
+func TestMigrateHashes(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "logs_data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(baselineSchema); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	l := New("legacy")
+	l.Folder(dir)
+	l.SetOutput(io.Discard)
+	defer l.Close()
+
+	entries, err := l.GetLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = sql.Open("sqlite3", filepath.Join(dir, "logs_data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, l := range fromEntries(entries) {
+		var hash string
+		if err := db.QueryRow("SELECT hash FROM logs WHERE id = ?;", l.id).Scan(&hash); err != nil {
+			t.Fatal(err)
+		}
+		if hash != l.checksum() {
+			t.Errorf("log %q has hash %q, want %q", l.message, hash, l.checksum())
+		}
+	}
+
+	// the migrated logs are recognized when they are imported again
+	imported, err := l.ImportEntries(entries...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 0 {
+		t.Errorf("imported %d migrated logs again, want 0", imported)
+	}
+}
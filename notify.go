@@ -0,0 +1,288 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gen2brain/beeep"
+)
+
+// the default aggregation of the notifications
+const (
+	defaultNotifyWindow    = time.Minute
+	defaultNotifyThreshold = 1
+)
+
+// Notifier sends the notifications of the logs, e.g. desktop alerts or webhook calls
+// the notifications are sent asynchronously, so a slow notifier doesn't slow down the logging
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// NotifierFunc is a function that implements the Notifier interface
+type NotifierFunc func(n Notification) error
+
+// Notify calls the function with the notification passed
+func (f NotifierFunc) Notify(n Notification) error {
+	return f(n)
+}
+
+// Notification represents a log or a group of logs with the same level to notify
+type Notification struct {
+	Level  LogLevel      // the level of the logs
+	Count  int           // the number of logs, 1 for a single log
+	Window time.Duration // the time in which the logs were created, 0 for a single log
+	Entry  Entry         // the log, or the last one of the group
+	Rule   string        // the name of the alert rule that sent the notification, empty for the notifier (see AddAlert)
+}
+
+// Title returns the title of the notification, the name of the alert rule or the level of the logs
+func (n Notification) Title() string {
+	if n.Rule != "" {
+		return n.Rule
+	}
+	return n.Level.String()
+}
+
+// Message returns the message of the notification, the message of the log
+// or the number of logs of the group (e.g. "500 error logs in the last minute")
+func (n Notification) Message() string {
+	if n.Count <= 1 {
+		return n.Entry.Message
+	}
+	return fmt.Sprintf("%d %s logs in the last %s", n.Count, strings.ToLower(n.Level.String()), windowString(n.Window))
+}
+
+// windowString returns the window passed in words (minute, hour, 5m0s)
+func windowString(window time.Duration) string {
+	switch window {
+	case time.Minute:
+		return "minute"
+	case time.Hour:
+		return "hour"
+	default:
+		return window.String()
+	}
+}
+
+// notifications sends the logs of a logger (and of its copies) to the notifier,
+// the logs exceeding the threshold in a window are aggregated in one notification
+type notifications struct {
+	notifier  Notifier
+	level     LogLevel // the minimum level of the notified logs
+	window    time.Duration
+	threshold int // the logs notified one by one in a window, the next ones are aggregated
+	groups    map[LogLevel]*notificationGroup
+	mu        sync.Mutex
+}
+
+// notificationGroup counts the logs with a level in the current window
+type notificationGroup struct {
+	start      time.Time
+	sent       int   // the logs notified one by one in the window
+	aggregated int   // the logs waiting for the aggregated notification
+	last       Entry // the last aggregated log
+	timer      *time.Timer
+}
+
+// SetNotifier sets the notifier of the logs with the level passed or a higher one
+// (created with Log, Info, Error, ...), passing nil removes the notifier
+// in every window (by default 1 minute, see NotifyWindow) the first log of a level
+// is notified immediately and the next ones are aggregated in one notification sent
+// at the end of the window, so a burst of 500 errors produces one
+// "500 error logs in the last minute" notification instead of 500
+// the notifications are sent asynchronously and the errors of the notifier are ignored
+// the copies and the children of the logger share the notifier and its windows
+// Example:
+//
+//	log.SetNotifier(logger.WebhookNotifier("https://hooks.example.com/logs"), logger.Error)
+func (opts *Logger) SetNotifier(notifier Notifier, level LogLevel) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+
+	if notifier == nil {
+		opts.notifications = nil
+		return
+	}
+
+	opts.notifications = &notifications{
+		notifier:  notifier,
+		level:     level,
+		window:    defaultNotifyWindow,
+		threshold: defaultNotifyThreshold,
+		groups:    make(map[LogLevel]*notificationGroup),
+	}
+}
+
+// NotifyWindow sets the aggregation of the notifications of the notifier set with SetNotifier:
+// in every window the first threshold logs of a level are notified one by one and
+// the next ones are aggregated in one notification sent at the end of the window
+// a window of 0 disables the aggregation (every log is notified), the threshold is at least 1
+// it does nothing if the logger has no notifier
+// Example:
+//
+//	log.NotifyWindow(5*time.Minute, 3) // 3 notifications, then one every 5 minutes
+func (opts *Logger) NotifyWindow(window time.Duration, threshold int) {
+	opts.mu.RLock()
+	n := opts.notifications
+	opts.mu.RUnlock()
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if window < 0 {
+		window = 0
+	}
+	if threshold < 1 {
+		threshold = 1
+	}
+	n.window = window
+	n.threshold = threshold
+}
+
+// FlushNotifications sends now the aggregated notifications waiting for the end of their window
+// and waits for them to be sent, it is useful before the program exits
+func (opts *Logger) FlushNotifications() {
+	opts.mu.RLock()
+	n := opts.notifications
+	opts.mu.RUnlock()
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	pending := make([]Notification, 0, len(n.groups))
+	for level, g := range n.groups {
+		g.timer.Stop()
+		if g.aggregated > 0 {
+			pending = append(pending, g.notification(level, time.Since(g.start)))
+		}
+		delete(n.groups, level)
+	}
+	n.mu.Unlock()
+
+	for _, notification := range pending {
+		n.notifier.Notify(notification)
+	}
+}
+
+// notify sends the log passed to the notifier of the logger, if it has one and the level is enabled
+func (opts *Logger) notify(l *log) {
+	opts.mu.RLock()
+	n := opts.notifications
+	opts.mu.RUnlock()
+	if n == nil || l.level < n.level {
+		return
+	}
+
+	n.add(l.entry())
+}
+
+// add notifies the log passed or adds it to the aggregated notification of its level
+func (n *notifications) add(e Entry) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.window <= 0 {
+		go n.notifier.Notify(Notification{Level: e.Level, Count: 1, Entry: e})
+		return
+	}
+
+	g, ok := n.groups[e.Level]
+	if !ok {
+		g = &notificationGroup{start: time.Now()}
+		g.timer = time.AfterFunc(n.window, func() { n.close(e.Level, g) })
+		n.groups[e.Level] = g
+	}
+
+	if g.sent < n.threshold {
+		g.sent++
+		go n.notifier.Notify(Notification{Level: e.Level, Count: 1, Entry: e})
+		return
+	}
+
+	g.aggregated++
+	g.last = e
+}
+
+// close ends the window of the group passed sending its aggregated notification
+func (n *notifications) close(level LogLevel, g *notificationGroup) {
+	n.mu.Lock()
+	if n.groups[level] != g {
+		n.mu.Unlock()
+		return
+	}
+	delete(n.groups, level)
+	window := n.window
+	n.mu.Unlock()
+
+	if g.aggregated > 0 {
+		n.notifier.Notify(g.notification(level, window))
+	}
+}
+
+// notification returns the aggregated notification of the group
+func (g *notificationGroup) notification(level LogLevel, window time.Duration) Notification {
+	return Notification{Level: level, Count: g.aggregated, Window: window, Entry: g.last}
+}
+
+// DesktopNotifier returns a Notifier that shows the notifications as desktop notifications
+func DesktopNotifier() Notifier {
+	return NotifierFunc(func(n Notification) error {
+		return beeep.Notify(n.Title(), n.Message(), "")
+	})
+}
+
+// webhookPayload is the JSON body of the requests of WebhookNotifier
+type webhookPayload struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	Level   string `json:"level"`
+	Count   int    `json:"count"`
+	Window  string `json:"window,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Entry   Entry  `json:"entry"`
+}
+
+// WebhookNotifier returns a Notifier that sends the notifications to the URL passed
+// as POST requests with a JSON body (title, message, level, count, window and entry)
+// the requests time out after 10 seconds
+func WebhookNotifier(url string) Notifier {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return NotifierFunc(func(n Notification) error {
+		payload := webhookPayload{
+			Title:   n.Title(),
+			Message: n.Message(),
+			Level:   n.Level.String(),
+			Count:   n.Count,
+			Rule:    n.Rule,
+			Entry:   n.Entry,
+		}
+		if n.Window > 0 {
+			payload.Window = n.Window.String()
+		}
+
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		res, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+
+		if res.StatusCode >= 300 {
+			return fmt.Errorf("[logger-pkg] the webhook returned the status %s", res.Status)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+)
+
+// onceKeys are the keys of the logs already created with Once by the process
+var onceKeys sync.Map
+
+// Once creates a log with the level and the message passed only the first time it is called
+// with the key passed in the process, the next calls with the same key don't do anything,
+// so a warning repeated in a loop or in every request is stored only once
+// the key is shared by every logger of the process
+// Example:
+//
+//	for _, row := range rows {
+//		if row.Legacy {
+//			log.Once("legacy-rows", logger.Warning, "table %s has legacy rows", table)
+//		}
+//	}
+//
+// it returns the id of the new log, 0 if the key was already logged (see Log)
+// if the level is not valid or it fails to create the log it will return an error
+func (opts *Logger) Once(key string, level LogLevel, message string, args ...any) (int64, error) {
+	if !level.valid() {
+		return 0, fmt.Errorf("[logger-pkg] invalid log level %d", level)
+	}
+
+	if _, logged := onceKeys.LoadOrStore(key, struct{}{}); logged {
+		return 0, nil
+	}
+
+	id, err := opts.Log(level, message, args...)
+	if err != nil {
+		// the key is released, so the next call creates the log that failed
+		onceKeys.Delete(key)
+	}
+	return id, err
+}
+
+// If returns the logger if the condition passed is true, otherwise a logger
+// that drops every log (the log methods don't store, print or exit),
+// so the conditional logs don't need an if block
+// Example:
+//
+//	log.If(retries > 3).Warn("retrying %s for the %d time", url, retries)
+func (opts *Logger) If(condition bool) *Logger {
+	if condition {
+		return opts
+	}
+	return &Logger{minLevel: Fatal + 1, consoleOnly: true, tags: make([]string, 0)}
+}
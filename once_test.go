@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOnceRetriesAfterError(t *testing.T) {
+	l := newTestLogger(t)
+	dir := t.TempDir()
+
+	// the folder of the database is a file, so the first log fails
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l.Folder(filepath.Join(file, "logs"))
+	if _, err := l.Once("retry", Warning, "once"); err == nil {
+		t.Fatal("Once() with an invalid folder = nil error, want an error")
+	}
+
+	l.Folder(dir)
+	if id, err := l.Once("retry", Warning, "once"); err != nil || id == 0 {
+		t.Fatalf("Once() after the error = %d, %v, want a new log", id, err)
+	}
+	if id, err := l.Once("retry", Warning, "once"); err != nil || id != 0 {
+		t.Errorf("Once() twice = %d, %v, want 0", id, err)
+	}
+}
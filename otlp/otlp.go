@@ -0,0 +1,406 @@
+// Package otlp exports the logs of a logger as OpenTelemetry log records over OTLP/HTTP,
+// so the package can feed any OpenTelemetry collector
+// Example:
+//
+//	exporter := otlp.New("http://otel-collector:4318")
+//	exporter.ServiceName("my-app")
+//	log.AddHook(exporter) // the new logs
+//	defer exporter.Close()
+//
+//	exporter.ExportStored(ctx, log, queries.LastRun()) // the logs already in the database
+//
+// The records are sent to the /v1/logs endpoint with the JSON encoding of OTLP,
+// in batches from a background goroutine (every 5 seconds or every 500 logs),
+// the failed batches are sent again with a doubling wait
+// The logs are mapped to the records as follows:
+//   - the level is the severity (TRACE 1, DEBUG 5, INFO 9, NOTICE 10, WARN 13, ERROR 17, FATAL 21),
+//     the custom levels have the severity of the level below them
+//   - the message is the body
+//   - the tags are the logger.tags attribute (an array of strings)
+//   - the caller is the code.filepath, code.lineno and code.function attributes
+//   - the fields are attributes with their names
+//   - the run id, the host name and the process id are the logger.run_id, host.name and process.pid attributes
+//
+// OTLP/gRPC is not supported: the collectors accept OTLP/HTTP on the port 4318 by default
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/internal/batch"
+	"github.com/Tagliapietra96/logger/v2/queries"
+)
+
+// logsPath is the path of the OTLP/HTTP logs endpoint
+const logsPath = "/v1/logs"
+
+// scopeName is the name of the instrumentation scope of the records
+const scopeName = "github.com/Tagliapietra96/logger/v2"
+
+// severities are the severity numbers and texts of the levels
+var severities = map[logger.LogLevel]struct {
+	number int
+	text   string
+}{
+	logger.Trace:   {1, "TRACE"},
+	logger.Debug:   {5, "DEBUG"},
+	logger.Info:    {9, "INFO"},
+	logger.Notice:  {10, "INFO2"},
+	logger.Warning: {13, "WARN"},
+	logger.Error:   {17, "ERROR"},
+	logger.Fatal:   {21, "FATAL"},
+}
+
+// severityOf returns the severity of the level passed, the custom levels
+// (see logger.SetLevelLabel) have the severity of the level below them
+func severityOf(level logger.LogLevel) (int, string) {
+	base := logger.Trace
+	for l := range severities {
+		if l <= level && l > base {
+			base = l
+		}
+	}
+	return severities[base].number, severities[base].text
+}
+
+// Exporter is a logger.Hook that exports the logs stored by the logger to an OTLP collector
+type Exporter struct {
+	url      string
+	mu       sync.RWMutex
+	client   *http.Client
+	resource map[string]string // the attributes of the resource of the records (e.g. service.name)
+	headers  http.Header       // the headers of the requests (e.g. the API key of a vendor)
+	level    logger.LogLevel   // the minimum level of the logs exported
+	batcher  *batch.Batcher
+}
+
+// New returns an Exporter sending the logs to the collector at the URL passed (e.g. http://otel-collector:4318)
+// and starts its background goroutine, Close sends the buffered logs and stops it
+func New(url string) *Exporter {
+	return NewBatched(url, batch.DefaultSize, batch.DefaultInterval)
+}
+
+// NewBatched returns an Exporter like New, that sends the logs every interval
+// or when size logs are buffered
+func NewBatched(url string, size int, interval time.Duration) *Exporter {
+	e := &Exporter{
+		url:      strings.TrimSuffix(url, "/") + logsPath,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		resource: make(map[string]string),
+		headers:  make(http.Header),
+	}
+	e.batcher = batch.New(e.export, size, interval)
+	return e
+}
+
+// ServiceName sets the service.name attribute of the resource of the records
+func (e *Exporter) ServiceName(name string) {
+	e.Resource(map[string]string{"service.name": name})
+}
+
+// Resource adds the attributes passed to the resource of the records (e.g. deployment.environment)
+func (e *Exporter) Resource(attributes map[string]string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for k, v := range attributes {
+		e.resource[k] = v
+	}
+}
+
+// Header sets a header of the export requests, e.g. the API key of a vendor
+func (e *Exporter) Header(key, value string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.headers.Set(key, value)
+}
+
+// Client sets the HTTP client used to export the logs
+func (e *Exporter) Client(client *http.Client) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.client = client
+}
+
+// MinLevel sets the minimum level of the logs exported (Debug by default, every log)
+func (e *Exporter) MinLevel(level logger.LogLevel) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.level = level
+}
+
+// Levels returns the levels of the logs exported, it implements the logger.Hook interface
+func (e *Exporter) Levels() []logger.LogLevel {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	levels := make([]logger.LogLevel, 0)
+	for _, level := range logger.Levels() {
+		if level >= e.level {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// BeforeWrite doesn't modify the log, it implements the logger.Hook interface
+func (e *Exporter) BeforeWrite(entry *logger.Entry) error {
+	return nil
+}
+
+// AfterWrite buffers the log stored to export it, it implements the logger.Hook interface
+func (e *Exporter) AfterWrite(entry logger.Entry) {
+	e.batcher.Add(entry)
+}
+
+// ExportStored exports the logs already stored in the database of the logger passed,
+// selected by the filters passed (every log without filters), in batches of 1000 logs
+// the logs below the minimum level are skipped
+// this method returns the number of logs exported and an error if a batch fails
+func (e *Exporter) ExportStored(ctx context.Context, l *logger.Logger, filters ...logger.QueryOption) (int, error) {
+	e.mu.RLock()
+	level := e.level
+	e.mu.RUnlock()
+
+	var last int64
+	exported := 0
+	for {
+		options := append(append(make([]logger.QueryOption, 0, len(filters)+4), filters...),
+			queries.Not(queries.LevelLessThan(level)), queries.IDGreaterThan(last), queries.SortID("ASC"), queries.AddLimit(1000))
+		entries, err := l.GetLogs(options...)
+		if err != nil {
+			return exported, err
+		}
+
+		if len(entries) == 0 {
+			return exported, nil
+		}
+
+		if err := e.export(ctx, entries); err != nil {
+			return exported, err
+		}
+
+		exported += len(entries)
+		last = entries[len(entries)-1].ID
+	}
+}
+
+// Spool sets the folder where the batches that the collector failed to receive are saved,
+// they are exported again when the collector is reachable, also by the next runs of the process,
+// so no log is lost during a network outage
+// this method returns an error if it fails to create the folder
+func (e *Exporter) Spool(folder string) error {
+	return e.batcher.Spool(folder)
+}
+
+// Flush exports the buffered logs now and returns an error if the collector rejects them
+func (e *Exporter) Flush(ctx context.Context) error {
+	return e.batcher.Flush(ctx)
+}
+
+// Err returns the error of the last export, nil if it succeeded
+func (e *Exporter) Err() error {
+	return e.batcher.Err()
+}
+
+// Close exports the buffered logs and stops the background goroutine
+// this method returns an error if the buffered logs could not be exported
+func (e *Exporter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return e.batcher.Close(ctx)
+}
+
+// keyValue is an attribute of OTLP
+type keyValue struct {
+	Key   string `json:"key"`
+	Value value  `json:"value"`
+}
+
+// value is an AnyValue of OTLP, only one of the fields is set
+type value struct {
+	StringValue *string      `json:"stringValue,omitempty"`
+	BoolValue   *bool        `json:"boolValue,omitempty"`
+	IntValue    *string      `json:"intValue,omitempty"`
+	DoubleValue *float64     `json:"doubleValue,omitempty"`
+	ArrayValue  *arrayValue  `json:"arrayValue,omitempty"`
+	KvlistValue *kvlistValue `json:"kvlistValue,omitempty"`
+}
+
+type arrayValue struct {
+	Values []value `json:"values"`
+}
+
+type kvlistValue struct {
+	Values []keyValue `json:"values"`
+}
+
+// logRecord is a LogRecord of OTLP
+type logRecord struct {
+	TimeUnixNano         string     `json:"timeUnixNano"`
+	ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber"`
+	SeverityText         string     `json:"severityText"`
+	Body                 value      `json:"body"`
+	Attributes           []keyValue `json:"attributes"`
+}
+
+// export sends the entries passed to the collector
+func (e *Exporter) export(ctx context.Context, entries []logger.Entry) error {
+	e.mu.RLock()
+	client, url, headers := e.client, e.url, e.headers.Clone()
+	resource := make([]keyValue, 0, len(e.resource))
+	for k, v := range e.resource {
+		resource = append(resource, keyValue{Key: k, Value: toValue(v)})
+	}
+	e.mu.RUnlock()
+	sort.Slice(resource, func(i, j int) bool { return resource[i].Key < resource[j].Key })
+
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+	records := make([]logRecord, 0, len(entries))
+	for _, entry := range entries {
+		records = append(records, toRecord(entry, now))
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"resourceLogs": []any{map[string]any{
+			"resource": map[string]any{"attributes": resource},
+			"scopeLogs": []any{map[string]any{
+				"scope":      map[string]any{"name": scopeName},
+				"logRecords": records,
+			}},
+		}},
+	})
+	if err != nil {
+		return errors.New("[logger-pkg] failed to encode the logs for OTLP: " + err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("[logger-pkg] failed to export the logs to OTLP: " + err.Error())
+	}
+	req.Header = headers
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to export the logs to OTLP: " + err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("[logger-pkg] failed to export the logs to OTLP: %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// toRecord returns the log record of the entry passed, observed at the time passed
+func toRecord(e logger.Entry, observed string) logRecord {
+	number, text := severityOf(e.Level)
+	attributes := []keyValue{
+		{Key: "code.filepath", Value: toValue(e.CallerFile)},
+		{Key: "code.lineno", Value: toValue(e.CallerLine)},
+		{Key: "code.function", Value: toValue(e.CallerFunction)},
+	}
+
+	if len(e.Tags) > 0 {
+		tags := make([]any, 0, len(e.Tags))
+		for _, tag := range e.Tags {
+			tags = append(tags, tag)
+		}
+		attributes = append(attributes, keyValue{Key: "logger.tags", Value: toValue(tags)})
+	}
+
+	if e.RunID != "" {
+		attributes = append(attributes, keyValue{Key: "logger.run_id", Value: toValue(e.RunID)})
+	}
+	if e.Hostname != "" {
+		attributes = append(attributes, keyValue{Key: "host.name", Value: toValue(e.Hostname)})
+	}
+	if e.PID != 0 {
+		attributes = append(attributes, keyValue{Key: "process.pid", Value: toValue(e.PID)})
+	}
+	if e.Count > 1 {
+		attributes = append(attributes, keyValue{Key: "logger.count", Value: toValue(e.Count)})
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attributes = append(attributes, keyValue{Key: k, Value: toValue(e.Fields[k])})
+	}
+
+	return logRecord{
+		TimeUnixNano:         strconv.FormatInt(e.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: observed,
+		SeverityNumber:       number,
+		SeverityText:         text,
+		Body:                 toValue(e.Message),
+		Attributes:           attributes,
+	}
+}
+
+// toValue returns the AnyValue of the value passed, the values of unknown types are formatted as strings
+func toValue(v any) value {
+	switch v := v.(type) {
+	case string:
+		return value{StringValue: &v}
+	case bool:
+		return value{BoolValue: &v}
+	case int:
+		s := strconv.Itoa(v)
+		return value{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return value{IntValue: &s}
+	case float64:
+		return value{DoubleValue: &v}
+	case json.Number:
+		if _, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			s := v.String()
+			return value{IntValue: &s}
+		}
+		if f, err := v.Float64(); err == nil {
+			return value{DoubleValue: &f}
+		}
+		return toValue(v.String())
+	case []any:
+		values := make([]value, 0, len(v))
+		for _, item := range v {
+			values = append(values, toValue(item))
+		}
+		return value{ArrayValue: &arrayValue{Values: values}}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		values := make([]keyValue, 0, len(v))
+		for _, k := range keys {
+			values = append(values, keyValue{Key: k, Value: toValue(v[k])})
+		}
+		return value{KvlistValue: &kvlistValue{Values: values}}
+	default:
+		return toValue(fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// Pager sets the logger to show the printed logs in a pager when they don't fit in the terminal
+// if the enabled parameter is true, otherwise they are written to the output (default)
+// the pager is the command in the PAGER environment variable, or less (with the colors enabled)
+// or more if it is not set; the pager is used only if the output of the logger is a terminal,
+// so the redirected and piped outputs are not affected
+// Example:
+//
+//	log.Pager(true)
+//	log.PrintLogs(queries.LastRun()) // scroll and search the logs with the keys of less
+func (opts *Logger) Pager(enabled bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.pager = enabled
+}
+
+// writePaged writes the rendered logs passed to the output of the logger, through the pager
+// if it is enabled, the output is a terminal and the logs are higher than the terminal
+// the logs are written to the output if the pager can't be started
+func writePaged(lopts *Logger, rendered string) {
+	out := lopts.getOutput()
+	f, ok := out.(*os.File)
+	if !lopts.pager || !ok || !term.IsTerminal(f.Fd()) {
+		io.WriteString(out, rendered)
+		return
+	}
+
+	_, height, err := term.GetSize(f.Fd())
+	if err != nil || strings.Count(rendered, "\n") < height {
+		io.WriteString(out, rendered)
+		return
+	}
+
+	cmd := pagerCommand()
+	if cmd == nil {
+		io.WriteString(out, rendered)
+		return
+	}
+
+	cmd.Stdin = strings.NewReader(rendered)
+	cmd.Stdout = f
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil && cmd.ProcessState == nil {
+		// the pager didn't start, the logs are not lost
+		io.WriteString(out, rendered)
+	}
+}
+
+// pagerCommand returns the command of the pager, it returns nil if there is no pager available
+func pagerCommand() *exec.Cmd {
+	if args := strings.Fields(os.Getenv("PAGER")); len(args) > 0 {
+		return exec.Command(args[0], args[1:]...)
+	}
+
+	if path, err := exec.LookPath("less"); err == nil {
+		// -R keeps the colors, -X and -F leave the logs on the screen and exit if they fit
+		return exec.Command(path, "-R", "-X", "-F")
+	}
+
+	if path, err := exec.LookPath("more"); err == nil {
+		return exec.Command(path)
+	}
+	return nil
+}
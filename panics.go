@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"github.com/gen2brain/beeep"
+)
+
+// HandlePanics recovers a panic of the goroutine and saves it in the database as a Fatal log,
+// with the panic value as the message and the stack trace in the "stack" field,
+// then it shows the alert set with SetFatal and exits the program with code 2 (like an unhandled panic)
+// it must be deferred at the start of the main function, or of a goroutine (see Go)
+// Example:
+//
+//	func main() {
+//		log := logger.New()
+//		defer log.HandlePanics()
+//		// ...
+//	}
+//
+// if there is no panic it does nothing
+func (opts *Logger) HandlePanics() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	opts.logPanic(r, debug.Stack())
+}
+
+// Go runs the function passed in a new goroutine recovering its panics like HandlePanics,
+// so a panic of the goroutine is saved in the database before the program exits
+// Example:
+//
+//	log.Go(func() {
+//		worker(jobs)
+//	})
+func (opts *Logger) Go(fn func()) {
+	go func() {
+		defer opts.HandlePanics()
+		fn()
+	}()
+}
+
+// logPanic saves the panic value and the stack trace passed as a Fatal log and exits the program
+func (opts *Logger) logPanic(r any, stack []byte) {
+	l, err := newLog(Fatal, opts.getTags(), fmt.Sprintf("panic: %v", r))
+	if err == nil {
+		l.fields = map[string]any{stackField: string(stack)}
+		opts.captureGoroutines(l)
+		opts.captureProfiles(l)
+		err = opts.writeLog(l)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "panic: %v\n\n%s", r, stack)
+	}
+
+	cfg := opts.Copy()
+	beeep.Alert(cfg.fatalTitle, cfg.fatalMessage, "")
+	os.Exit(2)
+}
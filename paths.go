@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultFileName is the name of the database file when no other name is set with DatabasePath
+const defaultFileName = "logs_data.db"
+
+// DatabasePath sets the path of the SQLite database file, e.g. "/var/lib/my-app/events.db",
+// the folder of the file becomes the folder of the logger (see Folder), where the exports are saved too
+// the folder must exist, the file is created if it doesn't exist
+// Example:
+//
+//	log.DatabasePath("/var/lib/my-app/events.db")
+func (opts *Logger) DatabasePath(path string) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.folderPath = filepath.Dir(path)
+	opts.fileName = filepath.Base(path)
+}
+
+// AppName sets the folder of the logger to the data folder of the application with the name passed:
+// $XDG_DATA_HOME/<name> if the variable is set, otherwise <user cache folder>/<name>
+// (e.g. ~/.cache/<name> on Linux, ~/Library/Caches/<name> on macOS, %LocalAppData%\<name> on Windows)
+// the folder is created if it doesn't exist
+// it is useful when the binary is installed in a read-only location (e.g. /usr/local/bin)
+// Example:
+//
+//	log := logger.New()
+//	log.AppName("my-app")
+func (opts *Logger) AppName(name string) {
+	folder := appDataFolder(name)
+	if folder == "" {
+		return
+	}
+
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.folderPath = folder
+}
+
+// defaultFolder returns the default folder of the logs: the folder of the executable
+// if it is writable, otherwise the data folder of the application named as the executable
+// the binaries run with "go run" or "go test" (in the temporary folder) use the working directory
+func defaultFolder() string {
+	executable, err := os.Executable()
+	if err != nil || strings.Contains(executable, os.TempDir()) {
+		folder, err := os.Getwd()
+		if err != nil {
+			return ""
+		}
+		return folder
+	}
+
+	folder := filepath.Dir(executable)
+	if writable(folder) {
+		return folder
+	}
+
+	name := strings.TrimSuffix(filepath.Base(executable), filepath.Ext(executable))
+	if data := appDataFolder(name); data != "" {
+		return data
+	}
+	return folder
+}
+
+// appDataFolder returns the data folder of the application with the name passed creating it,
+// it returns an empty string if the folder can't be found or created
+func appDataFolder(name string) string {
+	base := os.Getenv("XDG_DATA_HOME")
+	if base == "" {
+		var err error
+		base, err = os.UserCacheDir()
+		if err != nil {
+			return ""
+		}
+	}
+
+	folder := filepath.Join(base, name)
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		return ""
+	}
+	return folder
+}
+
+// writable reports if a file can be created in the folder passed
+func writable(folder string) bool {
+	file, err := os.CreateTemp(folder, ".logger-*")
+	if err != nil {
+		return false
+	}
+	file.Close()
+	os.Remove(file.Name())
+	return true
+}
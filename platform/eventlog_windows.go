@@ -0,0 +1,59 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// the event ids of the levels
+const (
+	infoEvent    = 1
+	warningEvent = 2
+	errorEvent   = 3
+)
+
+// eventLog writes the logs in the Windows Event Log
+type eventLog struct {
+	log *eventlog.Log
+}
+
+// open opens the Event Log with the name passed as source,
+// the source is registered if it is not yet (it requires the administrator rights the first time)
+func open(name string) (writer, error) {
+	// the error is ignored: the source is already registered, or the events are written without its messages
+	eventlog.InstallAsEventCreate(name, eventlog.Error|eventlog.Warning|eventlog.Info)
+
+	l, err := eventlog.Open(name)
+	if err != nil {
+		return nil, errors.New("[logger-pkg] failed to open the Windows Event Log: " + err.Error())
+	}
+	return &eventLog{log: l}, nil
+}
+
+// write writes the entry passed in the Event Log, the logs below Warning are information
+// events, the Warning logs are warning events and the Error and Fatal logs are error events
+// (the custom levels are treated like the level below them)
+func (l *eventLog) write(e logger.Entry) error {
+	var err error
+	switch {
+	case e.Level >= logger.Error:
+		err = l.log.Error(errorEvent, text(e))
+	case e.Level >= logger.Warning:
+		err = l.log.Warning(warningEvent, text(e))
+	default:
+		err = l.log.Info(infoEvent, text(e))
+	}
+
+	if err != nil {
+		return errors.New("[logger-pkg] failed to write the log in the Windows Event Log: " + err.Error())
+	}
+	return nil
+}
+
+func (l *eventLog) close() error {
+	return l.log.Close()
+}
@@ -0,0 +1,123 @@
+//go:build linux
+
+package platform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tagliapietra96/logger/v2"
+)
+
+// journalSocket is the socket of the native protocol of the systemd journal
+const journalSocket = "/run/systemd/journal/socket"
+
+// priorities are the syslog priorities of the levels
+var priorities = map[logger.LogLevel]int{
+	logger.Trace:   7, // debug
+	logger.Debug:   7, // debug
+	logger.Info:    6, // info
+	logger.Notice:  5, // notice
+	logger.Warning: 4, // warning
+	logger.Error:   3, // err
+	logger.Fatal:   2, // crit
+}
+
+// priorityOf returns the syslog priority of the level passed, the custom levels
+// (see logger.SetLevelLabel) have the priority of the level below them
+func priorityOf(level logger.LogLevel) int {
+	base := logger.Trace
+	for l := range priorities {
+		if l <= level && l > base {
+			base = l
+		}
+	}
+	return priorities[base]
+}
+
+// journal writes the logs in the systemd journal with its native protocol
+type journal struct {
+	conn       *net.UnixConn
+	identifier string
+}
+
+// open connects to the systemd journal
+func open(name string) (writer, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, errors.New("[logger-pkg] failed to connect to the systemd journal: " + err.Error())
+	}
+	return &journal{conn: conn, identifier: name}, nil
+}
+
+// write sends the entry passed to the journal, the caller, the tags and the fields
+// are journal fields (CODE_FILE, CODE_LINE, CODE_FUNC, LOGGER_TAGS, LOGGER_<FIELD>)
+func (j *journal) write(e logger.Entry) error {
+	var b bytes.Buffer
+	journalField(&b, "MESSAGE", e.Message)
+	journalField(&b, "PRIORITY", strconv.Itoa(priorityOf(e.Level)))
+	journalField(&b, "SYSLOG_IDENTIFIER", j.identifier)
+	journalField(&b, "CODE_FILE", e.CallerFile)
+	journalField(&b, "CODE_LINE", strconv.Itoa(e.CallerLine))
+	journalField(&b, "CODE_FUNC", e.CallerFunction)
+	if len(e.Tags) > 0 {
+		journalField(&b, "LOGGER_TAGS", strings.Join(e.Tags, ","))
+	}
+	if e.RunID != "" {
+		journalField(&b, "LOGGER_RUN_ID", e.RunID)
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		journalField(&b, "LOGGER_"+journalName(k), fmt.Sprint(e.Fields[k]))
+	}
+
+	_, err := j.conn.Write(b.Bytes())
+	if err != nil {
+		return errors.New("[logger-pkg] failed to write the log in the systemd journal: " + err.Error())
+	}
+	return nil
+}
+
+func (j *journal) close() error {
+	return j.conn.Close()
+}
+
+// journalField appends a field to a message of the native protocol,
+// the values with new lines are written with their length
+func journalField(b *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(b, "%s=%s\n", name, value)
+		return
+	}
+
+	b.WriteString(name)
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalName returns the name passed as a journal field name: upper case letters, digits and underscores
+func journalName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,119 @@
+// Package platform writes the logs created by a logger to the log system of the platform too,
+// so they are collected with the logs of the other services of the machine:
+//   - Linux: the systemd journal, with the level mapped to the syslog priority (journalctl -t <name>)
+//   - Windows: the Windows Event Log (the Application log, with the name as source)
+//
+// The sink of the platform is selected by the build tags of the files,
+// on the other platforms New returns logger.ErrNotSupported
+// Example:
+//
+//	sink, err := platform.New("my-app")
+//	if err != nil {
+//		return err
+//	}
+//	log.AddHook(sink)
+//	defer sink.Close()
+package platform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Tagliapietra96/logger/v2"
+)
+
+// writer writes the logs in the log system of a platform
+type writer interface {
+	write(e logger.Entry) error
+	close() error
+}
+
+// Sink is a logger.Hook that writes the logs stored by the logger to the log system of the platform
+type Sink struct {
+	out     writer
+	mu      sync.RWMutex
+	level   logger.LogLevel // the minimum level of the logs written
+	lastErr error
+}
+
+// New returns the Sink of the platform, the name identifies the program
+// in the log system (the syslog identifier of the journal or the source of the Event Log)
+// this function returns an error if the log system is not available
+func New(name string) (*Sink, error) {
+	out, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Sink{out: out}, nil
+}
+
+// MinLevel sets the minimum level of the logs written (Debug by default, every log)
+func (s *Sink) MinLevel(level logger.LogLevel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.level = level
+}
+
+// Levels returns the levels of the logs written, it implements the logger.Hook interface
+func (s *Sink) Levels() []logger.LogLevel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	levels := make([]logger.LogLevel, 0)
+	for _, level := range logger.Levels() {
+		if level >= s.level {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// BeforeWrite doesn't modify the log, it implements the logger.Hook interface
+func (s *Sink) BeforeWrite(entry *logger.Entry) error {
+	return nil
+}
+
+// AfterWrite writes the log stored in the log system, it implements the logger.Hook interface
+func (s *Sink) AfterWrite(entry logger.Entry) {
+	err := s.out.write(entry)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+}
+
+// Err returns the error of the last log written, nil if it succeeded
+func (s *Sink) Err() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastErr
+}
+
+// Close releases the connection to the log system
+func (s *Sink) Close() error {
+	return s.out.close()
+}
+
+// text returns the log as a line of text, for the log systems without structured fields
+// e.g. "[api, db] connection lost <db.go:42> attempt=3"
+func text(e logger.Entry) string {
+	var b strings.Builder
+	if len(e.Tags) > 0 {
+		fmt.Fprintf(&b, "[%s] ", strings.Join(e.Tags, ", "))
+	}
+	b.WriteString(e.Message)
+	if e.CallerFile != "" {
+		fmt.Fprintf(&b, " <%s:%d>", e.CallerFile, e.CallerLine)
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, e.Fields[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package platform
+
+import "github.com/Tagliapietra96/logger/v2"
+
+// open returns logger.ErrNotSupported, the platform has no log system supported
+func open(name string) (writer, error) {
+	return nil, logger.ErrNotSupported
+}
@@ -0,0 +1,211 @@
+package logger
+
+import (
+	"database/sql"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultIdleTimeout is the default time a database connection is kept open without being used
+const defaultIdleTimeout = 5 * time.Minute
+
+// pooledDB is a database connection shared by the stores with the same database
+type pooledDB struct {
+	db      *sql.DB
+	stmts   statements  // the prepared statements of the connection, nil until the first write
+	users   int         // the number of operations using the connection
+	timer   *time.Timer // the timer closing the connection when it is idle
+	closing bool        // if true the connection is closed as soon as it is no longer used
+}
+
+// pool keeps the database connections open between the operations,
+// the connections are closed after the idle timeout of their store
+// so the quiet processes don't hold the database file open
+var pool = struct {
+	sync.Mutex
+	dbs      map[string]*pooledDB
+	migrated map[string]bool // the paths of the databases whose schema was ensured by this process
+	runs     map[string]bool // the paths of the databases where the run of this process is saved
+}{dbs: make(map[string]*pooledDB), migrated: make(map[string]bool), runs: make(map[string]bool)}
+
+// poolKey returns the key of the connection of the store passed in the pool
+func (s *sqliteStore) poolKey() string {
+	key := s.dbPath() + s.dsnParams()
+	if s.compat {
+		key += "&compat"
+	}
+	return key
+}
+
+// getDBConnection returns the connection to the database of the store passed,
+// opening it if it is not in the pool (or if the database file was removed)
+// the connection must be released with releaseDBConnection
+func getDBConnection(s *sqliteStore) (*sql.DB, error) {
+	key := s.poolKey()
+
+	pool.Lock()
+	defer pool.Unlock()
+
+	p, ok := pool.dbs[key]
+	if _, err := os.Stat(s.dbPath()); os.IsNotExist(err) {
+		// the file was removed, its schema must be created again
+		// and the pooled connection points to the deleted database
+		delete(pool.migrated, s.dbPath())
+		delete(pool.runs, s.dbPath())
+		if ok {
+			discardDBConnection(key, p)
+			ok = false
+		}
+	}
+
+	if !ok {
+		db, err := openDBConnection(s)
+		if err != nil {
+			return nil, err
+		}
+
+		p = &pooledDB{db: db}
+		pool.dbs[key] = p
+	}
+
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.users++
+	return p.db, nil
+}
+
+// releaseDBConnection releases the connection returned by getDBConnection,
+// the connection is closed when it is no longer used for the idle timeout of the store
+func releaseDBConnection(s *sqliteStore, db *sql.DB) {
+	pool.Lock()
+	defer pool.Unlock()
+
+	key := s.poolKey()
+	p, ok := pool.dbs[key]
+	if !ok || p.db != db {
+		// the connection was discarded while it was used
+		closeDB(&pooledDB{db: db})
+		return
+	}
+
+	p.users--
+	if p.users > 0 {
+		return
+	}
+
+	if p.closing || s.idleTimeout <= 0 {
+		delete(pool.dbs, key)
+		closeDB(p)
+		return
+	}
+
+	p.timer = time.AfterFunc(s.idleTimeout, func() {
+		pool.Lock()
+		defer pool.Unlock()
+		if pool.dbs[key] == p && p.users == 0 {
+			delete(pool.dbs, key)
+			closeDB(p)
+		}
+	})
+}
+
+// closeDBConnection closes the connection to the database of the store passed,
+// if it is used the connection is closed when it is released
+func closeDBConnection(s *sqliteStore) error {
+	pool.Lock()
+	defer pool.Unlock()
+
+	key := s.poolKey()
+	p, ok := pool.dbs[key]
+	if !ok {
+		return nil
+	}
+
+	if p.users > 0 {
+		p.closing = true
+		return nil
+	}
+
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	delete(pool.dbs, key)
+	return closeDB(p)
+}
+
+// discardDBConnection removes the connection passed from the pool,
+// it is closed now if it is not used, otherwise when it is released
+// the pool must be locked
+func discardDBConnection(key string, p *pooledDB) {
+	delete(pool.dbs, key)
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	if p.users == 0 {
+		closeDB(p)
+	}
+}
+
+// closeDB closes the prepared statements and the connection passed
+func closeDB(p *pooledDB) error {
+	p.stmts.close()
+	return p.db.Close()
+}
+
+// statements are the prepared statements inserting the logs by query,
+// they are prepared once per connection instead of on every write
+type statements map[string]*sql.Stmt
+
+// getStatements returns the prepared statements of the pooled connection passed,
+// preparing them on the first call; it returns nil if the statements can't be
+// prepared or the connection is no longer pooled, the queries without a prepared
+// statement are prepared by the transactions (see statements.exec)
+func getStatements(db *sql.DB) statements {
+	pool.Lock()
+	defer pool.Unlock()
+
+	for _, p := range pool.dbs {
+		if p.db != db {
+			continue
+		}
+
+		if p.stmts == nil {
+			p.stmts = prepareStatements(db)
+		}
+		return p.stmts
+	}
+	return nil
+}
+
+// prepareStatements prepares the statements inserting the logs, it returns nil if it fails
+func prepareStatements(db *sql.DB) statements {
+	stmts := make(statements)
+	for _, query := range []string{insertLogQuery, importLogQuery, insertTagQuery, insertLogTagQuery} {
+		stmt, err := db.Prepare(query)
+		if err != nil {
+			stmts.close()
+			return nil
+		}
+		stmts[query] = stmt
+	}
+	return stmts
+}
+
+// close closes the prepared statements
+func (st statements) close() {
+	for _, stmt := range st {
+		stmt.Close()
+	}
+}
+
+// exec executes the query passed in the transaction passed,
+// using its prepared statement if there is one
+func (st statements) exec(tx *sql.Tx, query string, args ...any) (sql.Result, error) {
+	if stmt, ok := st[query]; ok {
+		return tx.Stmt(stmt).Exec(args...)
+	}
+	return tx.Exec(query, args...)
+}
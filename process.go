@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/Tagliapietra96/tui"
+)
+
+// goroutineID returns the id of the calling goroutine, read from the header
+// of its stack trace ("goroutine 7 [running]:"), it returns 0 if it fails
+func goroutineID() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf, ok := bytes.CutPrefix(buf, []byte("goroutine "))
+	if !ok {
+		return 0
+	}
+
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+
+	id, err := strconv.ParseInt(string(buf), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// captureProcess adds to the log passed the hostname, the pid and the id of
+// the calling goroutine if the logger captures them (see CaptureProcess)
+func (opts *Logger) captureProcess(l *log) {
+	opts.mu.RLock()
+	capture := opts.processInfo
+	opts.mu.RUnlock()
+	if !capture {
+		return
+	}
+
+	l.hostname = currentRun.hostname
+	l.pid = currentRun.pid
+	l.goroutine = goroutineID()
+}
+
+// getCallerProcess returns the caller of the log (see getCaller) followed by
+// its process information if showProcess is true (see getProcess)
+func (l *log) getCallerProcess(inline bool, level ShowCallerLevel, showProcess bool, th Theme) string {
+	caller := l.getCaller(inline, level, th)
+
+	if !showProcess {
+		return caller
+	}
+
+	process := l.getProcess(th)
+	switch {
+	case process == "":
+		return caller
+	case caller == "":
+		return process
+	default:
+		return caller + " " + process
+	}
+}
+
+// getProcess returns the hostname, the pid and the goroutine of the log (host:pid g7),
+// it returns an empty string if they were not captured
+func (l *log) getProcess(th Theme) string {
+	parts := make([]string, 0, 2)
+	switch {
+	case l.hostname != "" && l.pid != 0:
+		parts = append(parts, fmt.Sprintf("%s:%d", l.hostname, l.pid))
+	case l.hostname != "":
+		parts = append(parts, l.hostname)
+	case l.pid != 0:
+		parts = append(parts, fmt.Sprintf("pid %d", l.pid))
+	}
+
+	if l.goroutine != 0 {
+		parts = append(parts, fmt.Sprintf("g%d", l.goroutine))
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return tui.Render(strings.Join(parts, " "), th.muted())
+}
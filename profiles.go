@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"time"
+)
+
+// the fields of the Fatal logs with the paths of the profiles written by FatalProfiles
+const (
+	heapProfileField = "heap_profile"
+	cpuProfileField  = "cpu_profile"
+)
+
+// profiling is the configuration of the profiles written with the Fatal logs
+type profiling struct {
+	heap bool          // if true a heap profile is written
+	cpu  time.Duration // the duration of the CPU profile, if 0 no CPU profile is written
+}
+
+// FatalProfiles sets the Fatal logs (created by Fatal, HandlePanics and Go) to write pprof profiles
+// in the folder of the logger before the process exits: a heap profile if the heap parameter is true
+// and a CPU profile of the duration passed if it is positive (the exit is delayed by the duration,
+// so it should be short, e.g. 2 seconds)
+// the paths of the files (e.g. 20240102150405_heap.pprof) are saved in the heap_profile and
+// cpu_profile fields of the log and CrashReport adds the profiles to the report
+// the profiles can be inspected with "go tool pprof"
+// Example:
+//
+//	log.FatalProfiles(true, 2*time.Second)
+//	defer log.HandlePanics()
+//
+// Note: the CPU profile is skipped if another one is already running
+func (opts *Logger) FatalProfiles(heap bool, cpu time.Duration) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.profiling = profiling{heap: heap, cpu: max(cpu, 0)}
+}
+
+// captureProfiles writes the profiles of the logger (see FatalProfiles) and adds their paths
+// to the fields of the log passed, the profiles that fail to be written are skipped
+func (opts *Logger) captureProfiles(l *log) {
+	opts.mu.RLock()
+	p, folder := opts.profiling, opts.folderPath
+	opts.mu.RUnlock()
+	if !p.heap && p.cpu == 0 {
+		return
+	}
+
+	if l.fields == nil {
+		l.fields = make(map[string]any)
+	}
+
+	prefix := filepath.Join(folder, time.Time(l.timestamp).Format("20060102150405"))
+	if p.cpu > 0 {
+		if path, err := writeCPUProfile(prefix+"_cpu.pprof", p.cpu); err == nil {
+			l.fields[cpuProfileField] = path
+		}
+	}
+
+	if p.heap {
+		if path, err := writeHeapProfile(prefix + "_heap.pprof"); err == nil {
+			l.fields[heapProfileField] = path
+		}
+	}
+}
+
+// writeHeapProfile writes a heap profile in the file passed and returns its absolute path
+func writeHeapProfile(path string) (string, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+
+	err = pprof.WriteHeapProfile(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// writeCPUProfile writes a CPU profile of the duration passed in the file passed and returns its absolute path
+func writeCPUProfile(path string, duration time.Duration) (string, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+
+	err = pprof.StartCPUProfile(file)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return "", fmt.Errorf("failed to start the CPU profile: %w", err)
+	}
+
+	time.Sleep(duration)
+	pprof.StopCPUProfile()
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return filepath.Abs(path)
+}
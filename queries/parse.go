@@ -0,0 +1,434 @@
+package queries
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tagliapietra96/logger/v2"
+)
+
+// Parse returns the QueryOption described by the filter expression passed,
+// so the filters can be written in the CLIs and in the configuration files
+// Example:
+//
+//	queryOpt, err := queries.Parse("level>=warning AND tag:api AND message~timeout since:24h")
+//
+// The expression is a list of filters combined with AND (the default when no operator
+// is written), OR and NOT (case insensitive), the parentheses group the filters:
+//
+//	expression = or
+//	or         = and { "OR" and }
+//	and        = unary { [ "AND" ] unary }
+//	unary      = "NOT" unary | "(" or ")" | filter
+//	filter     = key operator value
+//
+// The filters are written without spaces around the operator, the values with spaces
+// must be quoted (e.g. message~"connection refused"), the keys and their operators are:
+//
+//	level     =, !=, >, >=, <, <=   the level of the log (debug, info, warning, error, fatal)
+//	tag       :, =, !=              a tag of the log contains the value, is the value or no tag is the value
+//	message   ~, !~                 the message contains (or not) the value
+//	file      ~, !~                 the caller file contains (or not) the value
+//	function  ~, !~                 the caller function contains (or not) the value
+//	line      =, !=, >, <           the caller line of the log
+//	host      =                     the hostname of the log (see Hostname)
+//	pid       =                     the process id of the log (see PID)
+//	revision  =                     the VCS revision of the build that created the log (see Revision)
+//	corr      =                     the correlation id of the log (see Correlation)
+//	search    :                     the message matches the words of the value (see Search)
+//	since     :                     the log was created after the value
+//	before    :                     the log was created before the value
+//
+// The values of since and before are durations before now (30m, 24h, 7d)
+// or timestamps (2006-01-02, "2006-01-02 15:04:05" or RFC3339)
+// An empty expression returns an option that doesn't filter the logs
+// it returns an error wrapping logger.ErrInvalidQuery with the position of the error
+// if the expression is not valid
+func Parse(expression string) (logger.QueryOption, error) {
+	p := &parser{input: expression, tokens: tokenize(expression)}
+	if len(p.tokens) == 0 {
+		return func(sb *strings.Builder) {}, nil
+	}
+
+	if p.tokens[len(p.tokens)-1].text == `"` {
+		return nil, p.errorf(p.tokens[len(p.tokens)-1], "unterminated quoted value")
+	}
+
+	config, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := p.peek(); ok {
+		return nil, p.errorf(t, "unexpected %q, expected AND, OR or the end of the expression", t.text)
+	}
+	return config, nil
+}
+
+// token is a word or a parenthesis of a filter expression
+type token struct {
+	text string // the text of the token
+	pos  int    // the position of the token in the expression
+}
+
+// tokenize splits the expression passed in words and parentheses,
+// the quoted parts of the words can contain spaces and parentheses
+// an unterminated quote is returned as the last token
+func tokenize(expression string) []token {
+	tokens := make([]token, 0)
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{string(c), i})
+			i++
+		default:
+			start := i
+			for i < len(expression) && !strings.ContainsRune(" \t\n()", rune(expression[i])) {
+				if expression[i] == '"' {
+					end := quotedEnd(expression, i)
+					if end < 0 {
+						return append(tokens, token{`"`, i})
+					}
+					i = end
+				}
+				i++
+			}
+			tokens = append(tokens, token{expression[start:i], start})
+		}
+	}
+	return tokens
+}
+
+// quotedEnd returns the index of the quote closing the one at the index passed,
+// the escaped quotes (\") are skipped, it returns -1 if the quote is not closed
+func quotedEnd(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// parser parses the tokens of a filter expression
+type parser struct {
+	input  string  // the expression
+	tokens []token // the tokens of the expression
+	next   int     // the index of the next token
+}
+
+// peek returns the next token without consuming it
+func (p *parser) peek() (token, bool) {
+	if p.next >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.next], true
+}
+
+// keyword reports if the next token is the keyword passed and consumes it
+func (p *parser) keyword(word string) bool {
+	if t, ok := p.peek(); ok && strings.EqualFold(t.text, word) {
+		p.next++
+		return true
+	}
+	return false
+}
+
+// errorf returns the error at the position of the token passed
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return fmt.Errorf("%w at position %d of %q: %s", logger.ErrInvalidQuery, t.pos, p.input, fmt.Sprintf(format, args...))
+}
+
+// eof returns the error of an expression ended before the token expected
+func (p *parser) eof(expected string) error {
+	return fmt.Errorf("%w at the end of %q: expected %s", logger.ErrInvalidQuery, p.input, expected)
+}
+
+func (p *parser) parseOr() (logger.QueryOption, error) {
+	configs := make([]logger.QueryOption, 0)
+	for {
+		config, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+
+		if !p.keyword("OR") {
+			break
+		}
+	}
+
+	if len(configs) == 1 {
+		return configs[0], nil
+	}
+	return Or(configs...), nil
+}
+
+func (p *parser) parseAnd() (logger.QueryOption, error) {
+	configs := make([]logger.QueryOption, 0)
+	for {
+		config, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+
+		if p.keyword("AND") {
+			continue
+		}
+
+		// the filters written one after the other are combined with AND
+		t, ok := p.peek()
+		if !ok || t.text == ")" || strings.EqualFold(t.text, "OR") {
+			break
+		}
+	}
+
+	if len(configs) == 1 {
+		return configs[0], nil
+	}
+
+	return func(sb *strings.Builder) {
+		for _, config := range configs {
+			config(sb)
+		}
+	}, nil
+}
+
+func (p *parser) parseUnary() (logger.QueryOption, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, p.eof("a filter, NOT or (")
+	}
+
+	switch {
+	case strings.EqualFold(t.text, "NOT"):
+		p.next++
+		config, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(config), nil
+	case t.text == "(":
+		p.next++
+		config, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		closing, ok := p.peek()
+		if !ok {
+			return nil, p.eof(") closing the parenthesis at position " + strconv.Itoa(t.pos))
+		}
+		if closing.text != ")" {
+			return nil, p.errorf(closing, "unexpected %q, expected )", closing.text)
+		}
+		p.next++
+		return config, nil
+	case t.text == ")" || strings.EqualFold(t.text, "AND") || strings.EqualFold(t.text, "OR"):
+		return nil, p.errorf(t, "unexpected %q, expected a filter, NOT or (", t.text)
+	}
+
+	p.next++
+	return p.parseFilter(t)
+}
+
+// the operators of the filters, the longer ones first
+var operators = []string{">=", "<=", "!=", "!~", "=", ">", "<", "~", ":"}
+
+// the operators allowed by the keys of the filters
+var keyOperators = map[string][]string{
+	"level":    {"=", "!=", ">", ">=", "<", "<="},
+	"tag":      {":", "=", "!="},
+	"message":  {"~", "!~"},
+	"file":     {"~", "!~"},
+	"function": {"~", "!~"},
+	"line":     {"=", "!=", ">", "<"},
+	"host":     {"="},
+	"pid":      {"="},
+	"revision": {"="},
+	"corr":     {"="},
+	"search":   {":"},
+	"since":    {":"},
+	"before":   {":"},
+}
+
+// filterKeys returns the keys of the filters, sorted
+func filterKeys() []string {
+	keys := make([]string, 0, len(keyOperators))
+	for key := range keyOperators {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (p *parser) parseFilter(t token) (logger.QueryOption, error) {
+	key, op, value := splitFilter(t.text)
+	key = strings.ToLower(key)
+
+	allowed, ok := keyOperators[key]
+	if !ok {
+		return nil, p.errorf(t, "unknown filter %q, expected a key (%s) followed by an operator and a value", t.text, strings.Join(filterKeys(), ", "))
+	}
+
+	if op == "" || !slices.Contains(allowed, op) {
+		return nil, p.errorf(t, "invalid operator in %q, the operators of %s are %s", t.text, key, strings.Join(allowed, " "))
+	}
+
+	value, err := unquote(value)
+	if err != nil {
+		return nil, p.errorf(t, "invalid quoted value in %q", t.text)
+	}
+
+	if value == "" {
+		return nil, p.errorf(t, "missing value in %q", t.text)
+	}
+
+	switch key {
+	case "level":
+		return p.levelFilter(t, op, value)
+	case "tag":
+		switch op {
+		case "=":
+			return HasTagExact(value), nil
+		case "!=":
+			return NotTags(value), nil
+		default:
+			return HasTags(value), nil
+		}
+	case "message":
+		return likeFilter(op, value, MessageLike, MessageNotLike), nil
+	case "file":
+		return likeFilter(op, value, CallerFileLike, CallerFileNotLike), nil
+	case "function":
+		return likeFilter(op, value, CallerFunctionLike, CallerFunctionNotLike), nil
+	case "line":
+		line, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, p.errorf(t, "invalid line %q, expected a number", value)
+		}
+
+		switch op {
+		case "=":
+			return CallerLineEqual(line), nil
+		case "!=":
+			return CallerLineNotEqual(line), nil
+		case ">":
+			return CallerLineGreaterThan(line), nil
+		default:
+			return CallerLineLessThan(line), nil
+		}
+	case "host":
+		return Hostname(value), nil
+	case "corr":
+		return Correlation(value), nil
+	case "pid":
+		pid, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, p.errorf(t, "invalid pid %q, expected a number", value)
+		}
+		return PID(pid), nil
+	case "revision":
+		return Revision(value), nil
+	case "search":
+		return Search(value), nil
+	default:
+		return p.timeFilter(t, key, value)
+	}
+}
+
+// levelFilter returns the filter comparing the level of the logs with the level passed
+func (p *parser) levelFilter(t token, op, value string) (logger.QueryOption, error) {
+	level, err := logger.ParseLevel(value)
+	if err != nil {
+		return nil, p.errorf(t, "invalid level %q, expected trace, debug, info, notice, warning, error or fatal", value)
+	}
+
+	switch op {
+	case "=":
+		return LevelEqual(level), nil
+	case "!=":
+		return LevelNotEqual(level), nil
+	case ">":
+		return LevelGreaterThan(level), nil
+	case ">=":
+		return Not(LevelLessThan(level)), nil
+	case "<":
+		return LevelLessThan(level), nil
+	default:
+		return Not(LevelGreaterThan(level)), nil
+	}
+}
+
+// timeFilter returns the filter of the logs created after (since) or before (before) the value
+func (p *parser) timeFilter(t token, key, value string) (logger.QueryOption, error) {
+	if d, ok := parseDuration(value); ok {
+		if key == "since" {
+			return Since(d), nil
+		}
+		return recent("", ago(d)), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if tm, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			if key == "since" {
+				return InstantAfter(tm), nil
+			}
+			return InstantBefore(tm), nil
+		}
+	}
+
+	return nil, p.errorf(t, "invalid time %q, expected a duration (30m, 24h, 7d) or a timestamp (2006-01-02 15:04:05)", value)
+}
+
+// splitFilter returns the key, the operator and the value of the filter passed
+func splitFilter(filter string) (string, string, string) {
+	i := 0
+	for i < len(filter) && (filter[i] == '_' || (filter[i] >= 'a' && filter[i] <= 'z') || (filter[i] >= 'A' && filter[i] <= 'Z')) {
+		i++
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(filter[i:], op) {
+			return filter[:i], op, filter[i+len(op):]
+		}
+	}
+	return filter[:i], "", filter[i:]
+}
+
+// unquote returns the value without the double quotes, if it is quoted
+func unquote(value string) (string, error) {
+	if !strings.HasPrefix(value, `"`) {
+		return value, nil
+	}
+	return strconv.Unquote(value)
+}
+
+// likeFilter returns the like or the not like filter of the value passed based on the operator
+func likeFilter(op, value string, like, notLike func(string) logger.QueryOption) logger.QueryOption {
+	if op == "!~" {
+		return notLike(value)
+	}
+	return like(value)
+}
+
+// parseDuration returns the duration passed, it accepts the days (7d) too
+func parseDuration(s string) (time.Duration, bool) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		return time.Duration(n) * 24 * time.Hour, err == nil
+	}
+
+	d, err := time.ParseDuration(s)
+	return d, err == nil
+}
@@ -0,0 +1,121 @@
+package queries_test
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/queries"
+)
+
+// newParseLogger returns a logger with the logs selected by the expressions of the parser tests
+func newParseLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	l := logger.New()
+	l.Folder(t.TempDir())
+	l.SetOutput(io.Discard)
+	t.Cleanup(func() { l.Close() })
+
+	logs := []struct {
+		level   logger.LogLevel
+		tags    []string
+		message string
+	}{
+		{logger.Error, []string{"api"}, "connection refused"},
+		{logger.Info, []string{"api", "http"}, "request served"},
+		{logger.Debug, []string{"cache"}, "cache miss"},
+		{logger.Warning, nil, "disk almost full"},
+	}
+	for _, log := range logs {
+		if _, err := l.Child(log.tags...).Log(log.level, log.message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return l
+}
+
+func TestParse(t *testing.T) {
+	l := newParseLogger(t)
+
+	tests := []struct {
+		expression string
+		want       []string
+	}{
+		{"", []string{"connection refused", "request served", "cache miss", "disk almost full"}},
+		{"level>=warning", []string{"connection refused", "disk almost full"}},
+		{"level=info", []string{"request served"}},
+		{"level!=debug", []string{"connection refused", "request served", "disk almost full"}},
+		{"level<info", []string{"cache miss"}},
+		{"tag:ap", []string{"connection refused", "request served"}},
+		{"tag=http", []string{"request served"}},
+		{"tag!=api", []string{"cache miss", "disk almost full"}},
+		{`message~"refused"`, []string{"connection refused"}},
+		{"message!~e", []string{"disk almost full"}},
+		{"file~parse_test", []string{"connection refused", "request served", "cache miss", "disk almost full"}},
+		{"level>=info tag:api", []string{"connection refused", "request served"}},
+		{"level=error OR tag=cache", []string{"connection refused", "cache miss"}},
+		{"NOT tag:api", []string{"cache miss", "disk almost full"}},
+		{"not (level=error or level=debug)", []string{"request served", "disk almost full"}},
+		{"(tag=api OR tag=cache) AND NOT message~served", []string{"connection refused", "cache miss"}},
+		{"since:1h", []string{"connection refused", "request served", "cache miss", "disk almost full"}},
+		{"before:1h", nil},
+		{"since:2000-01-01 before:2999-01-01", []string{"connection refused", "request served", "cache miss", "disk almost full"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			option, err := queries.Parse(tt.expression)
+			if err != nil {
+				t.Fatalf("Parse(%q) = %v", tt.expression, err)
+			}
+
+			entries, err := l.GetLogs(option, queries.SortID("ASC"))
+			if err != nil {
+				t.Fatalf("GetLogs(Parse(%q)) = %v", tt.expression, err)
+			}
+
+			got := make([]string, 0, len(entries))
+			for _, e := range entries {
+				got = append(got, e.Message)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Parse(%q) selected %q, want %q", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		message    string
+	}{
+		{"level>=loud", "invalid level"},
+		{"color=red", "unknown filter"},
+		{"message=refused", "invalid operator"},
+		{"level=", "missing value"},
+		{`message~"refused`, "unterminated quoted value"},
+		{"line=ten", "invalid line"},
+		{"pid=abc", "invalid pid"},
+		{"since:yesterday", "invalid time"},
+		{"(level=error", "expected )"},
+		{"level=error)", "unexpected"},
+		{"level=error AND", "expected a filter"},
+		{"NOT", "expected a filter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := queries.Parse(tt.expression)
+			if !errors.Is(err, logger.ErrInvalidQuery) {
+				t.Fatalf("Parse(%q) = %v, want an error wrapping ErrInvalidQuery", tt.expression, err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Parse(%q) = %q, want an error containing %q", tt.expression, err, tt.message)
+			}
+		})
+	}
+}
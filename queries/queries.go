@@ -2,19 +2,49 @@ package queries
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/Tagliapietra96/logger"
+	"github.com/Tagliapietra96/logger/v2"
 )
 
 const defaultQuery = `
-SELECT DISTINCT logs.id, logs.level, logs.caller_file, logs.caller_line, logs.caller_function, logs.message, logs.time
+SELECT DISTINCT logs.id, logs.level, logs.caller_file, logs.caller_line, logs.caller_function, logs.message, logs.time, logs.timestamp, logs.fields, logs.run_id, logs.hostname, logs.pid, logs.goroutine, logs.count, logs.correlation
 FROM logs
-INNER JOIN log_tags ON logs.id = log_tags.log_id
-INNER JOIN tags ON log_tags.tag_id = tags.id
+LEFT JOIN log_tags ON logs.id = log_tags.log_id
+LEFT JOIN tags ON log_tags.tag_id = tags.id
 `
 
+// instant is the SQL expression of the UTC time of a log, used to compare the instants
+// the logs with the offset timestamp are converted to UTC, the older ones only have
+// the local time of the machine that created them, so it is converted from the local time
+// the expression is computed for every log, so the filters on it can't use the index of the
+// timestamp column and scan the logs selected by the other filters
+const instant = `(CASE WHEN logs.timestamp != '' THEN datetime(logs.timestamp) ELSE datetime(logs.time, 'utc') END)`
+
+// utc returns the time passed in UTC formatted to be compared with the instant of the logs
+func utc(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// dayStart returns the midnight of the day of the date passed in its location,
+// moved by the number of days passed
+func dayStart(date time.Time, days int) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day()+days, 0, 0, 0, 0, date.Location())
+}
+
+// contains returns the LIKE pattern matching the strings containing the one passed,
+// as a quoted SQL string (the quotes of the string are escaped)
+func contains(s string) string {
+	return quote("%" + s + "%")
+}
+
+// quote returns the string passed as a quoted SQL string, escaping its quotes
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 func getOrder(order string) string {
 	order = strings.ToUpper(order)
 	if order != "ASC" && order != "DESC" {
@@ -38,6 +68,12 @@ func getOrder(order string) string {
 //	SELECT id, level, caller_file, caller_line, caller_function, message, time FROM logs WHERE level = 1 OR level = 3 ORDER BY time DESC
 //
 // The main approach for this package is to use the other QueryOptions, as they are more specific and easier to use.
+//
+// The custom query is validated before it is executed: only the WHERE, ORDER BY, LIMIT
+// and OFFSET clauses are allowed (in this order), the quotes and the parentheses must be
+// balanced and semicolons, comments and the statements changing the database are rejected,
+// otherwise the query methods return an error wrapping logger.ErrInvalidQuery.
+// The values of the custom query must be quoted by the caller, doubling their single quotes
 func CustomQuery(query string) logger.QueryOption {
 	return func(sb *strings.Builder) {
 		sb.WriteString(" ")
@@ -45,113 +81,132 @@ func CustomQuery(query string) logger.QueryOption {
 	}
 }
 
-func prepareFilter(config logger.QueryOption) logger.QueryOption {
-	return func(sb *strings.Builder) {
-		var filter, order, limit string
-		s := sb.String()
-		if s == "" {
-			sb.WriteString(defaultQuery)
-		}
+// Saved returns a QueryOption that filters the logs selected by the query saved in the database
+// with the name passed (see Logger.SaveQuery), it can be combined with the other QueryOptions
+// Example:
+//
+//	queryOpt := queries.Saved("prod api errors today")
+//
+// In this example, the query will return the logs of the saved query "prod api errors today",
+// its order and its limit select the logs but they don't sort the result, use the sorts of this package
+// or Logger.PrintSaved to print the logs in the order of the saved query
+// The saved query is read from the database when the query is executed, the query methods
+// return an error if it doesn't exist and the stores other than the SQLite store don't support it
+func Saved(name string) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf("saved_query(%s)", quote(name)))
+	})
+}
 
-		if strings.Contains(s, " WHERE ") {
-			pieces := strings.Split(s, " WHERE ")
-			filter = pieces[1]
-			if strings.Contains(filter, " ORDER BY ") {
-				pieces = strings.Split(filter, " ORDER BY ")
-				filter = pieces[0]
-				order = pieces[1]
-				if strings.Contains(order, " LIMIT ") {
-					pieces = strings.Split(order, " LIMIT ")
-					order = pieces[0]
-					limit = pieces[1]
-				}
-			} else if strings.Contains(filter, " LIMIT ") {
-				pieces = strings.Split(filter, " LIMIT ")
-				filter = pieces[0]
-				limit = pieces[1]
-			}
-			sb.Reset()
-			sb.WriteString(defaultQuery)
-			sb.WriteString(" WHERE ")
-			sb.WriteString(filter)
-		} else if strings.Contains(s, " ORDER BY ") {
-			pieces := strings.Split(s, " ORDER BY ")
-			order = pieces[1]
-			if strings.Contains(order, " LIMIT ") {
-				pieces = strings.Split(order, " LIMIT ")
-				order = pieces[0]
-				limit = pieces[1]
+// cutClause splits the query passed around the first occurrence of the clause
+// outside of the parentheses and of the quoted strings (the clause is case insensitive),
+// so the subqueries and the values of the filters are never split
+// it returns the query, an empty string and false if the clause is not found
+func cutClause(query, clause string) (string, string, bool) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
 			}
-			sb.Reset()
-			sb.WriteString(defaultQuery)
-		} else if strings.Contains(s, " LIMIT ") {
-			pieces := strings.Split(s, " LIMIT ")
-			limit = pieces[1]
-			sb.Reset()
-			sb.WriteString(defaultQuery)
+			continue
 		}
 
-		if !strings.Contains(s, " WHERE ") {
-			sb.WriteString(" WHERE ")
-		} else {
-			sb.WriteString(" AND ")
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 && len(query)-i >= len(clause) && strings.EqualFold(query[i:i+len(clause)], clause) {
+				return query[:i], query[i+len(clause):], true
+			}
 		}
+	}
+	return query, "", false
+}
 
-		config(sb)
+// splitQuery returns the parts of the query passed: the base query (SELECT ... FROM ...),
+// the filter, the order and the limit, without their keywords
+func splitQuery(query string) (base, filter, order, limit string) {
+	if query == "" {
+		query = defaultQuery
+	}
 
-		if order != "" {
-			sb.WriteString(" ORDER BY ")
-			sb.WriteString(order)
-		}
+	base, limit, _ = cutClause(query, " LIMIT ")
+	base, order, _ = cutClause(base, " ORDER BY ")
+	base, filter, _ = cutClause(base, " WHERE ")
+	return base, filter, order, limit
+}
 
-		if limit != "" {
-			sb.WriteString(" LIMIT ")
-			sb.WriteString(limit)
-		}
+// joinQuery returns the query with the parts passed, the empty parts are omitted
+func joinQuery(sb *strings.Builder, base, filter, order, limit string) {
+	sb.Reset()
+	sb.WriteString(base)
+	if filter != "" {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(filter)
+	}
+
+	if order != "" {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(order)
+	}
+
+	if limit != "" {
+		sb.WriteString(" LIMIT ")
+		sb.WriteString(limit)
 	}
 }
 
-func prepareSort(config logger.QueryOption) logger.QueryOption {
+// condition returns the condition written by the filter passed,
+// it returns false if the option passed doesn't filter the logs (e.g. a sort)
+func condition(config logger.QueryOption) (string, bool) {
+	sb := new(strings.Builder)
+	sb.WriteString(defaultQuery)
+	config(sb)
+
+	_, filter, _, _ := splitQuery(sb.String())
+	return filter, filter != ""
+}
+
+func prepareFilter(config logger.QueryOption) logger.QueryOption {
 	return func(sb *strings.Builder) {
-		var base, order, limit string
-		s := sb.String()
-		if s == "" {
-			sb.WriteString(defaultQuery)
-		}
+		base, filter, order, limit := splitQuery(sb.String())
 
-		if strings.Contains(s, " ORDER BY ") {
-			pieces := strings.Split(s, " ORDER BY ")
-			base = pieces[0]
-			order = pieces[1]
-			if strings.Contains(order, " LIMIT ") {
-				pieces = strings.Split(order, " LIMIT ")
-				order = pieces[0]
-				limit = pieces[1]
+		cond := new(strings.Builder)
+		config(cond)
+		if filter == "" {
+			filter = cond.String()
+		} else {
+			if _, _, ok := cutClause(filter, " OR "); ok {
+				// the AND of the new condition must not bind to the last term of the OR
+				filter = "(" + filter + ")"
 			}
-			sb.Reset()
-			sb.WriteString(base)
-			sb.WriteString(" ORDER BY ")
-			sb.WriteString(order)
-		} else if strings.Contains(s, " LIMIT ") {
-			pieces := strings.Split(s, " LIMIT ")
-			base = pieces[0]
-			limit = pieces[1]
-			sb.Reset()
-			sb.WriteString(base)
+			filter += " AND " + cond.String()
 		}
 
-		if !strings.Contains(s, " ORDER BY ") {
-			sb.WriteString(" ORDER BY ")
-		} else {
-			sb.WriteString(", ")
-		}
+		joinQuery(sb, base, filter, order, limit)
+	}
+}
 
-		config(sb)
+func prepareSort(config logger.QueryOption) logger.QueryOption {
+	return func(sb *strings.Builder) {
+		base, filter, order, limit := splitQuery(sb.String())
 
-		if limit != "" {
-			sb.WriteString(" LIMIT ")
-			sb.WriteString(limit)
+		sort := new(strings.Builder)
+		config(sort)
+		if order == "" {
+			order = sort.String()
+		} else {
+			order += ", " + sort.String()
 		}
+
+		joinQuery(sb, base, filter, order, limit)
 	}
 }
 
@@ -168,7 +223,7 @@ func prepareSort(config logger.QueryOption) logger.QueryOption {
 func AddFilters(configs ...logger.QueryOption) logger.QueryOption {
 	return func(sb *strings.Builder) {
 		for _, config := range configs {
-			prepareFilter(config)
+			prepareFilter(config)(sb)
 		}
 	}
 }
@@ -186,8 +241,62 @@ func AddFilters(configs ...logger.QueryOption) logger.QueryOption {
 func AddSorts(configs ...logger.QueryOption) logger.QueryOption {
 	return func(sb *strings.Builder) {
 		for _, config := range configs {
-			prepareSort(config)
+			prepareSort(config)(sb)
+		}
+	}
+}
+
+// Or returns a QueryOption that filters the logs matching at least one of the given filters
+// the filters are grouped in parentheses, so they can be combined with the other filters
+// and with Not; the options that don't filter the logs (e.g. the sorts) are ignored
+// Example:
+//
+//	queryOpt := queries.Or(queries.LevelEqual(logger.Error), queries.HasTags("api"))
+//
+// In this example, the query will return all the logs with the Error level or the api tag
+func Or(configs ...logger.QueryOption) logger.QueryOption {
+	return func(sb *strings.Builder) {
+		conditions := make([]string, 0, len(configs))
+		for _, config := range configs {
+			if cond, ok := condition(config); ok {
+				conditions = append(conditions, "("+cond+")")
+			}
+		}
+
+		if len(conditions) == 0 {
+			return
+		}
+
+		prepareFilter(func(sb *strings.Builder) {
+			sb.WriteString("(" + strings.Join(conditions, " OR ") + ")")
+		})(sb)
+	}
+}
+
+// Not returns a QueryOption that filters the logs not matching the given filter
+// the options that don't filter the logs (e.g. the sorts) are ignored
+// Example:
+//
+//	queryOpt := []logger.QueryOption{
+//		queries.Or(queries.LevelEqual(logger.Error), queries.HasTags("api")),
+//		queries.Not(queries.MessageLike("health")),
+//	}
+//
+// In this example, the query will return all the logs with the Error level or the api tag
+// and without the string "health" in their message
+// Note: the tag filters match a single tag of the log, so Not(HasTags("api")) returns
+// the logs with at least one tag different from api, even if they also have the api tag,
+// use NotTags to exclude the logs with a tag
+func Not(config logger.QueryOption) logger.QueryOption {
+	return func(sb *strings.Builder) {
+		cond, ok := condition(config)
+		if !ok {
+			return
 		}
+
+		prepareFilter(func(sb *strings.Builder) {
+			sb.WriteString("NOT (" + cond + ")")
+		})(sb)
 	}
 }
 
@@ -211,31 +320,20 @@ func AddLimit(limitAndOffset ...int) logger.QueryOption {
 			return
 		}
 
-		var base string
-		s := sb.String()
-		if s == "" {
-			sb.WriteString(defaultQuery)
-		}
-
-		if strings.Contains(s, " LIMIT ") {
-			pieces := strings.Split(s, " LIMIT ")
-			base = pieces[0]
-			sb.Reset()
-			sb.WriteString(base)
-		}
-
-		sb.WriteString(" LIMIT ")
-		sb.WriteString(fmt.Sprintf("%d", limitAndOffset[0]))
+		base, filter, order, _ := splitQuery(sb.String())
 
+		limit := fmt.Sprintf("%d", limitAndOffset[0])
 		if len(limitAndOffset) > 1 {
-			sb.WriteString(" OFFSET ")
-			sb.WriteString(fmt.Sprintf("%d", limitAndOffset[1]))
+			limit += fmt.Sprintf(" OFFSET %d", limitAndOffset[1])
 		}
+
+		joinQuery(sb, base, filter, order, limit)
 	}
 }
 
 // HasTags returns a QueryOption that filters the logs by the given tags
-// the logs must have at least one of the given tags
+// the logs must have at least one of the given tags, every tag of the log is checked,
+// so Not(HasTags(...)) excludes the logs with one of the tags and keeps the untagged logs
 // Example:
 //
 //	queryOpt := queries.HasTags("tag1", "tag2")
@@ -245,15 +343,85 @@ func AddLimit(limitAndOffset ...int) logger.QueryOption {
 // The query will return the logs with at least one of the given tags
 func HasTags(tag string, tags ...string) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		for i, tag := range append(tags, tag) {
-			sb.WriteString(fmt.Sprintf("tags.name LIKE '%%%s%%'", tag))
-			if i < len(tags)-1 {
-				sb.WriteString(" OR ")
-			}
+		filters := make([]string, 0, len(tags)+1)
+		for _, tag := range append([]string{tag}, tags...) {
+			filters = append(filters, fmt.Sprintf("tags.name LIKE %s", contains(tag)))
 		}
+		sb.WriteString(tagFilter("(" + strings.Join(filters, " OR ") + ")"))
+	})
+}
+
+// HasTagExact returns a QueryOption that filters the logs by the given tags (exact match)
+// the logs must have at least one of the given tags, unlike HasTags the tags are not
+// matched as substrings, so "api" doesn't match "api-gateway"
+// Example:
+//
+//	queryOpt := queries.HasTagExact("api", "worker")
+//
+// In this example, the query will return all the logs with the tag api or the tag worker
+func HasTagExact(tag string, tags ...string) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(tagFilter("tags.name IN " + quoteList(append([]string{tag}, tags...))))
+	})
+}
+
+// NotTags returns a QueryOption that filters the logs without any of the given tags (exact match)
+// unlike Not(HasTags(...)) the tags are not matched as substrings, the logs with one of the
+// given tags are excluded even if they have other tags
+// Example:
+//
+//	queryOpt := queries.NotTags("health", "metrics")
+//
+// In this example, the query will return all the logs without the tags health and metrics
+func NotTags(tag string, tags ...string) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString("NOT " + tagFilter("tags.name IN "+quoteList(append([]string{tag}, tags...))))
+	})
+}
+
+// TagKeyEquals returns a QueryOption that filters the logs by the value of a key:value tag
+// the logs must have the tag key:value (exact match, see logger.KeyValueTag), e.g. "env:prod"
+// Example:
+//
+//	queryOpt := queries.TagKeyEquals("env", "prod")
+//
+// In this example, the query will return all the logs with the tag env:prod,
+// the logs with the tags env:production or env:staging are excluded
+func TagKeyEquals(key, value string) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(tagFilter(fmt.Sprintf("tags.name = %s", quote(logger.KeyValueTag(key, value)))))
+	})
+}
+
+// HasTagKey returns a QueryOption that filters the logs with a key:value tag with the given key,
+// whatever its value
+// Example:
+//
+//	queryOpt := queries.HasTagKey("service")
+//
+// In this example, the query will return all the logs with a tag like service:api or service:worker
+func HasTagKey(key string) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		prefix := logger.KeyValueTag(key, "")
+		sb.WriteString(tagFilter(fmt.Sprintf("substr(tags.name, 1, %d) = %s", len(prefix), quote(prefix))))
 	})
 }
 
+// quoteList returns the strings passed as a list of quoted SQL strings, e.g. ('a', 'b')
+func quoteList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, quote(v))
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// tagFilter returns the filter of the logs with at least one tag matching the condition passed,
+// the condition is checked on every tag of the log instead of the joined one
+func tagFilter(cond string) string {
+	return "logs.id IN (SELECT log_tags.log_id FROM log_tags INNER JOIN tags ON log_tags.tag_id = tags.id WHERE " + cond + ")"
+}
+
 // LevelEqual returns a QueryOption that filters the logs by the given level
 // Example:
 //
@@ -323,7 +491,7 @@ func LevelBetween(start, end logger.LogLevel) logger.QueryOption {
 // or any other file with the string "main.go" in its name
 func CallerFileLike(file string) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.caller_file LIKE '%%%s%%'", file))
+		sb.WriteString(fmt.Sprintf("logs.caller_file LIKE %s", contains(file)))
 	})
 }
 
@@ -336,7 +504,7 @@ func CallerFileLike(file string) logger.QueryOption {
 // or any other file without the string "main.go" in its name
 func CallerFileNotLike(file string) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.caller_file NOT LIKE '%%%s%%'", file))
+		sb.WriteString(fmt.Sprintf("logs.caller_file NOT LIKE %s", contains(file)))
 	})
 }
 
@@ -409,7 +577,7 @@ func CallerLineBetween(start, end int) logger.QueryOption {
 // or any other function with the string "main.main" in its name
 func CallerFunctionLike(function string) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.caller_function LIKE '%%%s%%'", function))
+		sb.WriteString(fmt.Sprintf("logs.caller_function LIKE %s", contains(function)))
 	})
 }
 
@@ -422,7 +590,7 @@ func CallerFunctionLike(function string) logger.QueryOption {
 // or any other function without the string "main.main" in its name
 func CallerFunctionNotLike(function string) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.caller_function NOT LIKE '%%%s%%'", function))
+		sb.WriteString(fmt.Sprintf("logs.caller_function NOT LIKE %s", contains(function)))
 	})
 }
 
@@ -435,7 +603,7 @@ func CallerFunctionNotLike(function string) logger.QueryOption {
 // or any other message with the string "error" in its content
 func MessageLike(message string) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.message LIKE '%%%s%%'", message))
+		sb.WriteString(fmt.Sprintf("logs.message LIKE %s", contains(message)))
 	})
 }
 
@@ -448,7 +616,7 @@ func MessageLike(message string) logger.QueryOption {
 // or any other message without the string "error" in its content
 func MessageNotLike(message string) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.message NOT LIKE '%%%s%%'", message))
+		sb.WriteString(fmt.Sprintf("logs.message NOT LIKE %s", contains(message)))
 	})
 }
 
@@ -458,10 +626,10 @@ func MessageNotLike(message string) logger.QueryOption {
 //	queryOpt := queries.TimestampEqual(time.Now())
 //
 // In this example, the query will return all the logs with the timestamp set to the current time
-// it consider both date and time
+// it consider both date and time, comparing the instants (the location of the timestamp doesn't matter)
 func TimestampEqual(timestamp time.Time) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.time = '%s'", timestamp.Format("2006-01-02 15:04:05")))
+		sb.WriteString(fmt.Sprintf("%s = '%s'", instant, utc(timestamp)))
 	})
 }
 
@@ -471,10 +639,10 @@ func TimestampEqual(timestamp time.Time) logger.QueryOption {
 //	queryOpt := queries.TimestampNotEqual(time.Now())
 //
 // In this example, the query will return all the logs with the timestamp different from the current time
-// it consider both date and time
+// it consider both date and time, comparing the instants (the location of the timestamp doesn't matter)
 func TimestampNotEqual(timestamp time.Time) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.time != '%s'", timestamp.Format("2006-01-02 15:04:05")))
+		sb.WriteString(fmt.Sprintf("%s != '%s'", instant, utc(timestamp)))
 	})
 }
 
@@ -484,10 +652,10 @@ func TimestampNotEqual(timestamp time.Time) logger.QueryOption {
 //	queryOpt := queries.TimestampGreaterThan(time.Now())
 //
 // In this example, the query will return all the logs with the timestamp greater than the current time
-// it consider both date and time
+// it consider both date and time, comparing the instants (the location of the timestamp doesn't matter)
 func TimestampGreaterThan(timestamp time.Time) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.time > '%s'", timestamp.Format("2006-01-02 15:04:05")))
+		sb.WriteString(fmt.Sprintf("%s > '%s'", instant, utc(timestamp)))
 	})
 }
 
@@ -497,10 +665,10 @@ func TimestampGreaterThan(timestamp time.Time) logger.QueryOption {
 //	queryOpt := queries.TimestampLessThan(time.Now())
 //
 // In this example, the query will return all the logs with the timestamp less than the current time
-// it consider both date and time
+// it consider both date and time, comparing the instants (the location of the timestamp doesn't matter)
 func TimestampLessThan(timestamp time.Time) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.time < '%s'", timestamp.Format("2006-01-02 15:04:05")))
+		sb.WriteString(fmt.Sprintf("%s < '%s'", instant, utc(timestamp)))
 	})
 }
 
@@ -510,10 +678,10 @@ func TimestampLessThan(timestamp time.Time) logger.QueryOption {
 //	queryOpt := queries.TimestampBetween(time.Now().Add(-time.Hour), time.Now())
 //
 // In this example, the query will return all the logs with the timestamp between one hour ago and the current time
-// it consider both date and time
+// it consider both date and time, comparing the instants (the location of the timestamp doesn't matter)
 func TimestampBetween(start, end time.Time) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.time BETWEEN '%s' AND '%s'", start.Format("2006-01-02 15:04:05"), end.Format("2006-01-02 15:04:05")))
+		sb.WriteString(fmt.Sprintf("%s BETWEEN '%s' AND '%s'", instant, utc(start), utc(end)))
 	})
 }
 
@@ -523,10 +691,10 @@ func TimestampBetween(start, end time.Time) logger.QueryOption {
 //	queryOpt := queries.DateEqual(time.Now())
 //
 // In this example, the query will return all the logs with the date set to the current date
-// it consider only the date, not the time
+// it consider only the date, not the time, the days are the ones of the location of the date
 func DateEqual(date time.Time) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("DATE(logs.time) = '%s'", date.Format("2006-01-02")))
+		sb.WriteString(fmt.Sprintf("%s >= '%s' AND %s < '%s'", instant, utc(dayStart(date, 0)), instant, utc(dayStart(date, 1))))
 	})
 }
 
@@ -536,10 +704,10 @@ func DateEqual(date time.Time) logger.QueryOption {
 //	queryOpt := queries.DateNotEqual(time.Now())
 //
 // In this example, the query will return all the logs with the date different from the current date
-// it consider only the date, not the time
+// it consider only the date, not the time, the days are the ones of the location of the date
 func DateNotEqual(date time.Time) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("DATE(logs.time) != '%s'", date.Format("2006-01-02")))
+		sb.WriteString(fmt.Sprintf("(%s < '%s' OR %s >= '%s')", instant, utc(dayStart(date, 0)), instant, utc(dayStart(date, 1))))
 	})
 }
 
@@ -549,10 +717,10 @@ func DateNotEqual(date time.Time) logger.QueryOption {
 //	queryOpt := queries.DateGreaterThan(time.Now())
 //
 // In this example, the query will return all the logs with the date greater than the current date
-// it consider only the date, not the time
+// it consider only the date, not the time, the days are the ones of the location of the date
 func DateGreaterThan(date time.Time) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("DATE(logs.time) > '%s'", date.Format("2006-01-02")))
+		sb.WriteString(fmt.Sprintf("%s >= '%s'", instant, utc(dayStart(date, 1))))
 	})
 }
 
@@ -562,10 +730,10 @@ func DateGreaterThan(date time.Time) logger.QueryOption {
 //	queryOpt := queries.DateLessThan(time.Now())
 //
 // In this example, the query will return all the logs with the date less than the current date
-// it consider only the date, not the time
+// it consider only the date, not the time, the days are the ones of the location of the date
 func DateLessThan(date time.Time) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("DATE(logs.time) < '%s'", date.Format("2006-01-02")))
+		sb.WriteString(fmt.Sprintf("%s < '%s'", instant, utc(dayStart(date, 0))))
 	})
 }
 
@@ -575,10 +743,363 @@ func DateLessThan(date time.Time) logger.QueryOption {
 //	queryOpt := queries.DateBetween(time.Now().Add(-24*time.Hour), time.Now())
 //
 // In this example, the query will return all the logs with the date between 24 hours ago and the current date
-// it consider only the date, not the time
+// it consider only the date, not the time, the days are the ones of the location of the date
 func DateBetween(start, end time.Time) logger.QueryOption {
 	return prepareFilter(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("DATE(logs.time) BETWEEN '%s' AND '%s'", start.Format("2006-01-02"), end.Format("2006-01-02")))
+		sb.WriteString(fmt.Sprintf("%s >= '%s' AND %s < '%s'", instant, utc(dayStart(start, 0)), instant, utc(dayStart(end, 1))))
+	})
+}
+
+// InstantAfter returns a QueryOption that filters the logs created after the given instant
+// it is the same as TimestampGreaterThan: the instant is compared with the offset of the logs,
+// so the result doesn't depend on the time zone of the time passed and of the logs
+// Note: the instants of the logs are not indexed, on a large database combine the filter
+// with an indexed one (e.g. RunID or Correlation) to avoid scanning every log
+// Example:
+//
+//	loc, _ := time.LoadLocation("America/New_York")
+//	queryOpt := queries.InstantAfter(time.Date(2024, 3, 10, 1, 30, 0, 0, loc))
+//
+// In this example, the query will return all the logs created after 01:30 in New York
+func InstantAfter(t time.Time) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf("%s > '%s'", instant, utc(t)))
+	})
+}
+
+// InstantBefore returns a QueryOption that filters the logs created before the given instant
+// it is the same as TimestampLessThan, the time zone of the time passed and of the logs doesn't matter
+// Note: like InstantAfter, the filter scans the logs because their instants are not indexed
+// Example:
+//
+//	queryOpt := queries.InstantBefore(time.Now().Add(-time.Hour))
+//
+// In this example, the query will return all the logs created more than one hour ago
+func InstantBefore(t time.Time) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf("%s < '%s'", instant, utc(t)))
+	})
+}
+
+// InstantBetween returns a QueryOption that filters the logs created between the given instants
+// the start instant is included and the end one is excluded, so consecutive ranges don't overlap
+// (TimestampBetween includes both), the time zone of the times passed and of the logs doesn't matter
+// Note: like InstantAfter, the filter scans the logs because their instants are not indexed
+// Example:
+//
+//	queryOpt := queries.InstantBetween(time.Now().Add(-time.Hour), time.Now())
+//
+// In this example, the query will return all the logs created in the last hour
+func InstantBetween(start, end time.Time) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf("%s >= '%s' AND %s < '%s'", instant, utc(start), instant, utc(end)))
+	})
+}
+
+// DayIn returns a QueryOption that filters the logs created in the calendar day
+// of the given date in the given location, from its midnight to the next one
+// the day is computed in the location, so the days with a DST transition
+// last 23 or 25 hours as expected
+// Example:
+//
+//	loc, _ := time.LoadLocation("Europe/Rome")
+//	queryOpt := queries.DayIn(time.Date(2024, 3, 31, 0, 0, 0, 0, loc), loc)
+//
+// In this example, the query will return all the logs created on March 31 2024 in Rome
+func DayIn(date time.Time, loc *time.Location) logger.QueryOption {
+	date = date.In(loc)
+	return InstantBetween(dayStart(date, 0), dayStart(date, 1))
+}
+
+// recent returns a QueryOption that filters the logs created between the instants of the SQL
+// expressions passed (the start is not checked if empty), the expressions are relative to the
+// current time of the database (e.g. datetime('now', '-60 seconds')), so the option always refers
+// to the time the query runs, also when it is saved in the database (see Logger.SaveQuery)
+func recent(start, end string) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		if start != "" {
+			sb.WriteString(fmt.Sprintf("%s >= %s AND ", instant, start))
+		}
+		sb.WriteString(fmt.Sprintf("%s < %s", instant, end))
+	})
+}
+
+// ago returns the SQL expression of the instant the duration passed before the current time, in UTC
+func ago(d time.Duration) string {
+	return fmt.Sprintf("datetime('now', '%s seconds')", strconv.FormatFloat(-d.Seconds(), 'f', -1, 64))
+}
+
+// localDay returns the SQL expression of the midnight of the current day in the local time zone, in UTC,
+// moved by the SQLite date modifiers passed (e.g. '+1 day')
+func localDay(modifiers ...string) string {
+	return "datetime(" + strings.Join(append(append([]string{"'now'", "'localtime'", "'start of day'"}, modifiers...), "'utc'"), ", ") + ")"
+}
+
+// Since returns a QueryOption that filters the logs created in the given duration before now
+// Example:
+//
+//	queryOpt := queries.Since(15 * time.Minute)
+//
+// In this example, the query will return all the logs created in the last 15 minutes
+// the current time is read when the query is executed, so the option can be reused and saved
+func Since(d time.Duration) logger.QueryOption {
+	return recent(ago(d), ago(-time.Second))
+}
+
+// LastHours returns a QueryOption that filters the logs created in the given number of hours before now
+// Example:
+//
+//	queryOpt := queries.LastHours(6)
+//
+// In this example, the query will return all the logs created in the last 6 hours
+// the current time is read when the query is executed, so the option can be reused and saved
+func LastHours(n int) logger.QueryOption {
+	return Since(time.Duration(n) * time.Hour)
+}
+
+// Today returns a QueryOption that filters the logs created today in the local time zone
+// Example:
+//
+//	queryOpt := queries.Today()
+//
+// In this example, the query will return all the logs created since the last midnight
+// the current day is read when the query is executed, so the option can be reused and saved
+func Today() logger.QueryOption {
+	return recent(localDay(), localDay("'+1 day'"))
+}
+
+// Yesterday returns a QueryOption that filters the logs created yesterday in the local time zone
+// Example:
+//
+//	queryOpt := queries.Yesterday()
+//
+// In this example, the query will return all the logs created in the day before today
+// the current day is read when the query is executed, so the option can be reused and saved
+func Yesterday() logger.QueryOption {
+	return recent(localDay("'-1 day'"), localDay())
+}
+
+// ThisWeek returns a QueryOption that filters the logs created this week in the local time zone
+// the weeks start on Monday
+// Example:
+//
+//	queryOpt := queries.ThisWeek()
+//
+// In this example, the query will return all the logs created since the last Monday midnight
+// the current week is read when the query is executed, so the option can be reused and saved
+func ThisWeek() logger.QueryOption {
+	// the Monday of the week is the first one from six days ago
+	return recent(localDay("'-6 days'", "'weekday 1'"), localDay("'-6 days'", "'weekday 1'", "'+7 days'"))
+}
+
+// localWeekday and localHour are the SQL expressions of the day of the week (0 for Sunday) and of the hour
+// of the day (0-23) of a log in the local time zone, computed from the instant of the log as the other time
+// filters (InstantAfter, Since, Today, ...), so the time zone of the logger that created the log doesn't matter
+const (
+	localWeekday = "strftime('%w', " + instant + ", 'localtime')"
+	localHour    = "CAST(strftime('%H', " + instant + ", 'localtime') AS INTEGER)"
+)
+
+// Weekday returns a QueryOption that filters the logs created on the given days of the week
+// in the local time zone, like Today
+// Example:
+//
+//	queryOpt := queries.Weekday(time.Saturday, time.Sunday)
+//
+// In this example, the query will return all the logs created on the weekend,
+// no logs are returned if no days are passed, the days after Saturday are ignored
+func Weekday(days ...time.Weekday) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		values := make([]string, 0, len(days))
+		for _, day := range days {
+			if day >= time.Sunday && day <= time.Saturday {
+				values = append(values, fmt.Sprintf("'%d'", day))
+			}
+		}
+		sb.WriteString(fmt.Sprintf("%s IN (%s)", localWeekday, strings.Join(values, ", ")))
+	})
+}
+
+// HourBetween returns a QueryOption that filters the logs created between the given hours of the day
+// in the local time zone, like Today; the start hour is included and the end one is excluded, if the start
+// is after the end the range crosses midnight (e.g. 22 to 6 for the night)
+// the hours are clamped between 0 and 24, so e.g. HourBetween(25, -1) selects no logs
+// Example:
+//
+//	queryOpt := queries.HourBetween(0, 6)
+//
+// In this example, the query will return all the logs created from midnight to 5:59
+func HourBetween(start, end int) logger.QueryOption {
+	start, end = min(max(start, 0), 24), min(max(end, 0), 24)
+	return prepareFilter(func(sb *strings.Builder) {
+		if start <= end {
+			sb.WriteString(fmt.Sprintf("%s >= %d AND %s < %d", localHour, start, localHour, end))
+			return
+		}
+		sb.WriteString(fmt.Sprintf("(%s >= %d OR %s < %d)", localHour, start, localHour, end))
+	})
+}
+
+// BusinessHours returns a QueryOption that filters the logs created in the business hours,
+// from Monday to Friday between 9:00 and 18:00 in the local time zone, like Today
+// Example:
+//
+//	queryOpt := queries.Not(queries.BusinessHours())
+//
+// In this example, the query will return all the logs created at night and on the weekend
+func BusinessHours() logger.QueryOption {
+	weekdays, _ := condition(Weekday(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday))
+	hours, _ := condition(HourBetween(9, 18))
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(weekdays + " AND " + hours)
+	})
+}
+
+// RunID returns a QueryOption that filters the logs created by the given runs (executions of a process)
+// the id of the current run is returned by logger.RunID
+// Example:
+//
+//	queryOpt := queries.RunID(logger.RunID())
+//
+// In this example, the query will return all the logs created by the current execution of the process
+func RunID(id string, ids ...string) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		values := make([]string, 0, len(ids)+1)
+		for _, id := range append([]string{id}, ids...) {
+			values = append(values, quote(id))
+		}
+		sb.WriteString("logs.run_id IN (" + strings.Join(values, ", ") + ")")
+	})
+}
+
+// LastRun returns a QueryOption that filters the logs created by the last run
+// (the last started execution of a process that created logs in the database)
+// Example:
+//
+//	queryOpt := queries.LastRun()
+//
+// In this example, the query will return all the logs of the last execution,
+// e.g. to check what happened before the last crash of the application
+// the processes that only read the logs (e.g. the CLI) don't create a run
+func LastRun() logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString("logs.run_id = (SELECT id FROM runs ORDER BY start DESC, rowid DESC LIMIT 1)")
+	})
+}
+
+// Revision returns a QueryOption that filters the logs created by the binaries built
+// from the VCS revision passed, the revision can be abbreviated (e.g. the short git hash)
+// the revision of the running binary is returned by logger.BuildInfo
+// Example:
+//
+//	queryOpt := queries.Revision("4a0859e")
+//
+// In this example, the query will return all the logs created by the builds of the commit 4a0859e
+// Note: the logs created by the binaries without the VCS information (e.g. go run) have no revision
+func Revision(revision string) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		if revision == "" {
+			sb.WriteString("logs.run_id IN (SELECT id FROM runs WHERE revision = '')")
+			return
+		}
+		sb.WriteString("logs.run_id IN (SELECT id FROM runs WHERE substr(revision, 1, " + fmt.Sprint(len(revision)) + ") = " + quote(revision) + ")")
+	})
+}
+
+// Hostname returns a QueryOption that filters the logs by the host that created them
+// (the logs are saved with their hostname only if the logger captures it, see Logger.CaptureProcess)
+// Example:
+//
+//	queryOpt := queries.Hostname("web-1")
+//
+// In this example, the query will return all the logs created on the host web-1
+func Hostname(name string) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString("logs.hostname = " + quote(name))
+	})
+}
+
+// PID returns a QueryOption that filters the logs by the process id that created them
+// (the logs are saved with their pid only if the logger captures it, see Logger.CaptureProcess)
+// Example:
+//
+//	queryOpt := queries.PID(4242)
+//
+// In this example, the query will return all the logs created by the process 4242
+func PID(pid int) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf("logs.pid = %d", pid))
+	})
+}
+
+// Goroutine returns a QueryOption that filters the logs by the goroutine id that created them
+// (the logs are saved with their goroutine only if the logger captures it, see Logger.CaptureProcess)
+// Example:
+//
+//	queryOpt := queries.Goroutine(7)
+//
+// In this example, the query will return all the logs created by the goroutine 7
+// Note: the goroutine ids are reused by different processes, combine it with PID or RunID
+func Goroutine(id int64) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf("logs.goroutine = %d", id))
+	})
+}
+
+// Correlation returns a QueryOption that filters the logs by their correlation id,
+// so the complete story of a request or a job can be read across the components that logged it
+// (the logs are saved with a correlation id by the loggers created with Logger.WithCorrelation)
+// Example:
+//
+//	queryOpt := queries.Correlation("req-8f2c")
+//
+// In this example, the query will return all the logs of the request req-8f2c, whatever their tags
+func Correlation(id string) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString("logs.correlation = " + quote(id))
+	})
+}
+
+// IDGreaterThan returns a QueryOption that filters the logs with an id greater than the given one
+// the ids grow with the insertion order, so it selects the logs created after the given log
+// Example:
+//
+//	queryOpt := queries.IDGreaterThan(lastID)
+//
+// In this example, the query will return all the logs inserted after the log lastID
+func IDGreaterThan(id int64) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf("logs.id > %d", id))
+	})
+}
+
+// durationColumn is the duration_ms field of the logs of the timers, NULL for the other logs
+const durationColumn = "(CASE WHEN json_valid(logs.fields) THEN json_extract(logs.fields, '$.duration_ms') END)"
+
+// DurationGreaterThan returns a QueryOption that filters the logs of the timers (see Logger.Start)
+// by the duration of their operation, the logs without a duration are excluded
+// the durations of the logs with encrypted fields (see Logger.EncryptionKey) can't be filtered
+// Example:
+//
+//	queryOpt := queries.DurationGreaterThan(500 * time.Millisecond)
+//
+// In this example, the query will return all the operations that took more than 500 milliseconds
+func DurationGreaterThan(d time.Duration) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf("%s > %g", durationColumn, float64(d.Microseconds())/1000))
+	})
+}
+
+// SortDuration returns a QueryOption that sorts the logs of the timers (see Logger.Start)
+// by the duration of their operation, the logs without a duration are the lowest
+// Example:
+//
+//	queryOpt := queries.SortDuration("DESC")
+//
+// In this example, the query will return the logs sorted from the slowest operation
+func SortDuration(order string) logger.QueryOption {
+	return prepareSort(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf("%s %s", durationColumn, getOrder(order)))
 	})
 }
 
@@ -656,6 +1177,19 @@ func SortMessage(order string) logger.QueryOption {
 // it accept only "ASC"/"asc" or "DESC"/"desc" as order. If the order is not valid, it will default to "ASC"
 func SortTimestamp(order string) logger.QueryOption {
 	return prepareSort(func(sb *strings.Builder) {
-		sb.WriteString(fmt.Sprintf("logs.time %s", getOrder(order)))
+		sb.WriteString(fmt.Sprintf("%s %s", instant, getOrder(order)))
+	})
+}
+
+// SortID returns a QueryOption that sorts the logs by the id, the insertion order of the logs
+// Example:
+//
+//	queryOpt := queries.SortID("ASC")
+//
+// In this example, the query will return the logs in the order they were inserted
+// it accept only "ASC"/"asc" or "DESC"/"desc" as order. If the order is not valid, it will default to "ASC"
+func SortID(order string) logger.QueryOption {
+	return prepareSort(func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf("logs.id %s", getOrder(order)))
 	})
 }
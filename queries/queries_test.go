@@ -0,0 +1,48 @@
+package queries_test
+
+import (
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/queries"
+)
+
+func TestNotHasTags(t *testing.T) {
+	l := logger.New()
+	l.Folder(t.TempDir())
+	l.SetOutput(io.Discard)
+	defer l.Close()
+
+	l.Info("untagged")
+	l.Child("api", "http").Info("api")
+	l.Child("http").Info("http")
+
+	tests := []struct {
+		name   string
+		option logger.QueryOption
+		want   []string
+	}{
+		{"HasTags", queries.HasTags("api"), []string{"api"}},
+		{"Not HasTags", queries.Not(queries.HasTags("api")), []string{"untagged", "http"}},
+		{"NotTags", queries.NotTags("api"), []string{"untagged", "http"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := l.GetLogs(tt.option, queries.SortID("ASC"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0, len(entries))
+			for _, e := range entries {
+				got = append(got, e.Message)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("selected %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package queries
+
+import (
+	"strings"
+
+	"github.com/Tagliapietra96/logger/v2"
+)
+
+// Search returns a QueryOption that filters the logs by the words of their message
+// the text supports the phrases in double quotes and the prefixes ending with *,
+// the logs must contain every word, phrase and prefix of the text
+// Example:
+//
+//	queryOpt := queries.Search(`"connection refused" retry*`)
+//
+// In this example, the query will return all the logs with the phrase "connection refused"
+// and a word starting with "retry" (retry, retrying, retries) in their message
+// Note: with the sqlite_fts5 build tag the messages are searched in a full-text index,
+// which is much faster than MessageLike on big databases and supports the whole FTS5
+// query syntax (e.g. OR, NOT, NEAR); without the tag the words are matched with LIKE
+func Search(text string) logger.QueryOption {
+	return prepareFilter(func(sb *strings.Builder) {
+		sb.WriteString(searchFilter(text))
+	})
+}
+
+// SortRank returns a QueryOption that sorts the logs by their relevance for the text passed,
+// usually used with Search and the same text, the most relevant logs are the first ones
+// Example:
+//
+//	queryOpt := []logger.QueryOption{queries.Search("timeout"), queries.SortRank("timeout")}
+//
+// In this example, the query will return the logs with the word timeout, the most relevant first
+// Note: the relevance is computed by the full-text index (the sqlite_fts5 build tag),
+// without the tag the logs are sorted from the newest to the oldest
+func SortRank(text string) logger.QueryOption {
+	return prepareSort(func(sb *strings.Builder) {
+		sb.WriteString(searchRank(text))
+	})
+}
@@ -0,0 +1,16 @@
+//go:build sqlite_fts5
+
+package queries
+
+import "fmt"
+
+// searchFilter returns the filter of the logs matching the text in the full-text index
+func searchFilter(text string) string {
+	return fmt.Sprintf("logs.id IN (SELECT rowid FROM logs_fts WHERE logs_fts MATCH %s)", quote(text))
+}
+
+// searchRank returns the relevance of the logs for the text in the full-text index,
+// bm25 returns the lower values for the more relevant logs
+func searchRank(text string) string {
+	return fmt.Sprintf("(SELECT bm25(logs_fts) FROM logs_fts WHERE logs_fts MATCH %s AND logs_fts.rowid = logs.id) ASC", quote(text))
+}
@@ -0,0 +1,48 @@
+//go:build !sqlite_fts5
+
+package queries
+
+import (
+	"fmt"
+	"strings"
+)
+
+// searchFilter returns the filter of the logs with every term of the text in their message
+func searchFilter(text string) string {
+	terms := searchTerms(text)
+	if len(terms) == 0 {
+		return "1 = 1"
+	}
+
+	filters := make([]string, 0, len(terms))
+	for _, term := range terms {
+		filters = append(filters, fmt.Sprintf("logs.message LIKE %s", contains(term)))
+	}
+	return "(" + strings.Join(filters, " AND ") + ")"
+}
+
+// searchRank returns the newest logs first, the relevance needs the full-text index
+func searchRank(text string) string {
+	return "logs.id DESC"
+}
+
+// searchTerms returns the words, the phrases (without the double quotes)
+// and the prefixes (without the *) of the text passed
+func searchTerms(text string) []string {
+	terms := make([]string, 0)
+	for i, part := range strings.Split(text, `"`) {
+		if i%2 == 1 {
+			if part = strings.TrimSpace(part); part != "" {
+				terms = append(terms, part)
+			}
+			continue
+		}
+
+		for _, word := range strings.Fields(part) {
+			if word = strings.TrimSuffix(word, "*"); word != "" {
+				terms = append(terms, word)
+			}
+		}
+	}
+	return terms
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidQuery is returned when the query built with the query options
+// (e.g. a CustomQuery fragment) is not a valid tail of the logs query
+var ErrInvalidQuery = errors.New("[logger-pkg] invalid query")
+
+// the clauses allowed in the tail of the logs query, in their order
+var queryClauses = []string{"WHERE", "ORDER", "LIMIT", "OFFSET"}
+
+// the keywords never allowed in a query, they could change the database or its connection
+var forbiddenKeywords = map[string]bool{
+	"ALTER": true, "ANALYZE": true, "ATTACH": true, "BEGIN": true, "COMMIT": true,
+	"CREATE": true, "DELETE": true, "DETACH": true, "DROP": true, "INSERT": true,
+	"LOAD_EXTENSION": true, "PRAGMA": true, "REINDEX": true, "RELEASE": true, "REPLACE": true,
+	"ROLLBACK": true, "SAVEPOINT": true, "UPDATE": true, "VACUUM": true,
+}
+
+// the keywords not allowed outside of the parentheses, they would change the selected columns or tables
+var forbiddenTopLevelKeywords = map[string]bool{
+	"EXCEPT": true, "FROM": true, "GROUP": true, "HAVING": true, "INTERSECT": true,
+	"JOIN": true, "SELECT": true, "UNION": true, "WINDOW": true, "WITH": true,
+}
+
+// validateQueryTail checks the part of the query appended by the query options
+// to the base query: the quotes and the parentheses must be balanced, only one
+// statement is allowed (no semicolons nor comments), the clauses must be the
+// allowed ones (WHERE, ORDER BY, LIMIT, OFFSET) in their order and the keywords
+// changing the database are rejected (the subqueries in parentheses can only select)
+func validateQueryTail(tail string) error {
+	depth, clause := 0, -1
+	for i := 0; i < len(tail); {
+		c := tail[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(tail[i+1:], c)
+			if end < 0 {
+				return fmt.Errorf("%w: unbalanced quote %c at position %d", ErrInvalidQuery, c, i)
+			}
+			i += end + 2
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("%w: unbalanced parenthesis at position %d", ErrInvalidQuery, i)
+			}
+			i++
+		case c == ';':
+			return fmt.Errorf("%w: only one statement is allowed, found a semicolon at position %d", ErrInvalidQuery, i)
+		case strings.HasPrefix(tail[i:], "--") || strings.HasPrefix(tail[i:], "/*"):
+			return fmt.Errorf("%w: comments are not allowed, found one at position %d", ErrInvalidQuery, i)
+		case isWordByte(c):
+			j := i + 1
+			for j < len(tail) && isWordByte(tail[j]) {
+				j++
+			}
+
+			word := strings.ToUpper(tail[i:j])
+			if forbiddenKeywords[word] {
+				return fmt.Errorf("%w: the keyword %s is not allowed", ErrInvalidQuery, word)
+			}
+
+			if depth == 0 {
+				if forbiddenTopLevelKeywords[word] {
+					return fmt.Errorf("%w: the clause %s is not allowed, only WHERE, ORDER BY, LIMIT and OFFSET can be used", ErrInvalidQuery, word)
+				}
+
+				for k, allowed := range queryClauses {
+					if word != allowed {
+						continue
+					}
+					if k <= clause {
+						return fmt.Errorf("%w: the clause %s is repeated or out of order", ErrInvalidQuery, word)
+					}
+					clause = k
+				}
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+
+	if depth != 0 {
+		return fmt.Errorf("%w: unbalanced parenthesis", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// isWordByte reports if the byte passed is part of a keyword or an identifier
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+var validQueryTails = []string{
+	"",
+	"WHERE logs.level >= 30",
+	"WHERE logs.level = 10 ORDER BY logs.time DESC LIMIT 10 OFFSET 5",
+	"ORDER BY logs.id",
+	"LIMIT 1",
+	"WHERE logs.message LIKE '%;%'",
+	"WHERE logs.message = 'drop table logs -- /*'",
+	"WHERE logs.message = 'it''s'",
+	"WHERE logs.id IN (SELECT log_id FROM log_tags JOIN tags ON tags.id = log_tags.tag_id)",
+	"where logs.level > 0 order by logs.id limit 5",
+}
+
+var invalidQueryTails = []string{
+	"WHERE 1 = 1; DROP TABLE logs",
+	"WHERE 1 = 1 -- comment",
+	"WHERE 1 = 1 /* comment */",
+	"WHERE (1 = 1",
+	"WHERE 1 = 1)",
+	"WHERE logs.message = 'unterminated",
+	"UNION SELECT * FROM tags",
+	"JOIN runs ON 1 = 1",
+	"WHERE 1 = 1 GROUP BY logs.level",
+	"WHERE logs.id IN (DELETE FROM logs RETURNING id)",
+	"WHERE logs.id IN (SELECT id FROM logs); PRAGMA user_version = 1",
+	"WHERE load_extension('evil')",
+	"LIMIT 1 WHERE 1 = 1",
+	"WHERE 1 = 1 WHERE 2 = 2",
+	"WITH x AS (SELECT 1) SELECT 1",
+}
+
+func TestValidateQueryTailAccepts(t *testing.T) {
+	for _, tail := range validQueryTails {
+		if err := validateQueryTail(tail); err != nil {
+			t.Errorf("validateQueryTail(%q) = %v, want nil", tail, err)
+		}
+	}
+}
+
+func TestValidateQueryTailRejects(t *testing.T) {
+	for _, tail := range invalidQueryTails {
+		if err := validateQueryTail(tail); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("validateQueryTail(%q) = %v, want ErrInvalidQuery", tail, err)
+		}
+	}
+}
+
+// FuzzValidateQueryTail checks that the tails accepted by validateQueryTail
+// never change the database when they run as part of the logs query
+func FuzzValidateQueryTail(f *testing.F) {
+	for _, tail := range append(validQueryTails, invalidQueryTails...) {
+		f.Add(tail)
+	}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		f.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSchema(db, 0, encryption{}); err != nil {
+		f.Fatal(err)
+	}
+
+	schema, changes := databaseState(f, db)
+	f.Fuzz(func(t *testing.T, tail string) {
+		if validateQueryTail(tail) != nil {
+			return
+		}
+
+		// the tail must select, the errors of the SQL itself don't matter
+		if rows, err := db.Query(defaultQuery + " " + tail + ";"); err == nil {
+			rows.Close()
+		}
+
+		if s, c := databaseState(t, db); s != schema || c != changes {
+			t.Fatalf("the accepted tail %q changed the database", tail)
+		}
+	})
+}
+
+// databaseState returns the schema of the database passed and the number of rows changed by its connection
+func databaseState(tb testing.TB, db *sql.DB) (string, int) {
+	var schema string
+	var changes int
+	err := db.QueryRow("SELECT COALESCE(group_concat(sql, ';'), ''), total_changes() FROM sqlite_master;").Scan(&schema, &changes)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return schema, changes
+}
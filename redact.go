@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redactedValue replaces the secrets in the redacted logs
+const redactedValue = "[REDACTED]"
+
+// the common patterns of the secrets, to use with Logger.Redact
+var (
+	RedactEmails       = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	RedactBearerTokens = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	RedactPasswords    = regexp.MustCompile(`(?i)\b(password|passwd|pwd|secret|token|api[_-]?key)\s*[=:]\s*[^\s,;&]+`)
+)
+
+// redaction holds the patterns and the field keys redacted by a logger
+type redaction struct {
+	patterns []*regexp.Regexp
+	keys     map[string]bool // the lowercase keys of the redacted fields
+}
+
+// Redact adds patterns to the redaction of the logger: the parts of the messages and of the
+// string fields of the logs matching a pattern are replaced with "[REDACTED]" before the logs
+// are stored and printed, the package provides RedactEmails, RedactBearerTokens and RedactPasswords
+// the logs already stored are not affected
+// Example:
+//
+//	log.Redact(logger.RedactPasswords, logger.RedactBearerTokens)
+//	log.Redact(regexp.MustCompile(`sk_live_[0-9a-zA-Z]+`))
+//	log.Info("login with password=hunter2") // stored as "login with [REDACTED]"
+func (opts *Logger) Redact(patterns ...*regexp.Regexp) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+
+	for _, p := range patterns {
+		if p != nil {
+			opts.redaction.patterns = append(opts.redaction.patterns, p)
+		}
+	}
+}
+
+// RedactKeys adds field keys to the redaction of the logger: the values of the fields
+// with a key passed (case-insensitive, at any depth) are replaced with "[REDACTED]"
+// before the logs are stored and printed
+// Example:
+//
+//	log.RedactKeys("password", "authorization", "api_key")
+func (opts *Logger) RedactKeys(keys ...string) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+
+	if opts.redaction.keys == nil {
+		opts.redaction.keys = make(map[string]bool, len(keys))
+	}
+	for _, k := range keys {
+		opts.redaction.keys[strings.ToLower(k)] = true
+	}
+}
+
+// copy returns a copy of the redaction
+func (r redaction) copy() redaction {
+	c := redaction{patterns: append(make([]*regexp.Regexp, 0, len(r.patterns)), r.patterns...)}
+	if r.keys != nil {
+		c.keys = make(map[string]bool, len(r.keys))
+		for k := range r.keys {
+			c.keys[k] = true
+		}
+	}
+	return c
+}
+
+// redact replaces the secrets in the message and in the fields of the log passed
+func (opts *Logger) redact(l *log) {
+	opts.mu.RLock()
+	r := opts.redaction
+	opts.mu.RUnlock()
+
+	if len(r.patterns) == 0 && len(r.keys) == 0 {
+		return
+	}
+
+	l.message = r.redactString(l.message)
+	if len(l.fields) > 0 {
+		l.fields = r.redactValue(copyFields(l.fields)).(map[string]any)
+	}
+}
+
+// redactString replaces the parts of the string passed matching the patterns
+func (r redaction) redactString(s string) string {
+	for _, p := range r.patterns {
+		s = p.ReplaceAllString(s, redactedValue)
+	}
+	return s
+}
+
+// redactValue redacts the field value passed, the maps and the slices are modified in place
+func (r redaction) redactValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return r.redactString(v)
+	case []any:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+		return v
+	case []string:
+		for i, item := range v {
+			v[i] = r.redactString(item)
+		}
+		return v
+	case map[string]any:
+		for k, item := range v {
+			if r.keys[strings.ToLower(k)] {
+				v[k] = redactedValue
+				continue
+			}
+			v[k] = r.redactValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
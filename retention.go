@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Retention sets the maximum age of the stored logs, the older ones are deleted by Prune
+// (called by LoadConfig when the file sets the retention), 0 keeps every log (default)
+// Example:
+//
+//	log.Retention(30 * 24 * time.Hour)
+//	log.Prune() // deletes the logs older than 30 days
+func (opts *Logger) Retention(maxAge time.Duration) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.retention = max(maxAge, 0)
+}
+
+// Prune deletes the logs older than the retention of the logger (see Retention)
+// it doesn't delete any log if the logger has no retention
+// this method returns the number of deleted logs and an error if it fails to delete them
+func (opts *Logger) Prune() (int64, error) {
+	opts.mu.RLock()
+	retention := opts.retention
+	opts.mu.RUnlock()
+
+	if retention == 0 {
+		return 0, nil
+	}
+	return opts.DeleteLogs(createdBefore(time.Now().Add(-retention)))
+}
+
+// createdBefore returns the query option of the logs created before the time passed,
+// it must be the only filter of the query
+func createdBefore(before time.Time) QueryOption {
+	return func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf(" WHERE %s < '%s'", instantColumn, before.UTC().Format("2006-01-02 15:04:05")))
+	}
+}
+
+// createdAfter returns the query option of the logs created at the time passed or after it,
+// it must be the only filter of the query
+func createdAfter(after time.Time) QueryOption {
+	return func(sb *strings.Builder) {
+		sb.WriteString(fmt.Sprintf(" WHERE %s >= '%s'", instantColumn, after.UTC().Format("2006-01-02 15:04:05")))
+	}
+}
@@ -0,0 +1,211 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotation represents how the logs are split in multiple SQLite database files
+//   - NoRotation: every log is saved in the same database file (default)
+//   - RotateMonthly: the logs are saved in a file per month (e.g. logs_data_2025_06.db)
+//   - RotateBySize: the logs are saved in numbered files (logs_data_001.db, logs_data_002.db, ...)
+//     and a new file is started when the current one exceeds the maximum size
+type Rotation int
+
+const (
+	NoRotation    Rotation = iota // a single database file
+	RotateMonthly                 // a database file per month
+	RotateBySize                  // a new database file when the current one is full
+)
+
+// defaultRecentFiles is the number of database files read by the queries when no number is set
+const defaultRecentFiles = 3
+
+// rotation is the configuration of the rotation of the database files
+type rotation struct {
+	policy  Rotation // how the files are rotated
+	maxSize int64    // the size in bytes of the files rotated by size
+	recent  int      // the number of the most recent files read by the queries
+	base    string   // the name of the database file the rotated names are built from
+}
+
+// Rotate splits the logs in multiple SQLite database files, so every database stays small
+// and the older files can be archived or removed; the files are named after the database file
+// (see DatabasePath), e.g. logs_data_2025_06.db with RotateMonthly or logs_data_003.db with RotateBySize
+// the maxSizeMB parameter is the size in MB of the files rotated by size (ignored by the other rotations),
+// the size of the current file is checked every 100 logs or 10 seconds, so a file can exceed it by a few logs
+// the recent parameter is the number of the most recent files read by the queries (3 if 0, at most 10):
+// PrintLogs, GetLogs, Export and the other queries read them as a single database
+// Example:
+//
+//	log.Rotate(logger.RotateMonthly, 0, 6)    // the logs of the last 6 months
+//	log.Rotate(logger.RotateBySize, 100, 3)   // files of 100 MB
+//
+// the logs are written in the current file and DeleteLogs deletes the logs in every file,
+// the other methods (Append, AddTagsToLog, FullMessage, ListTags, ...) work on the current file
+// the database file saved before the rotation was enabled is read as the oldest file
+// Note: the queries.Search filter works only without rotation
+func (opts *Logger) Rotate(rotation Rotation, maxSizeMB int, recent int) {
+	if recent <= 0 {
+		recent = defaultRecentFiles
+	}
+
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.rotation.policy = rotation
+	opts.rotation.maxSize = int64(maxSizeMB) << 20
+	opts.rotation.recent = min(recent, maxFederated)
+}
+
+// how often the current file of a rotation by size is looked up again in the folder,
+// after the number of calls or the time passed since the last lookup
+const (
+	currentCheckCalls    = 100
+	currentCheckInterval = 10 * time.Second
+)
+
+// currentFiles caches the current files of the rotations by size of the process, by folder, base name and size
+var currentFiles = struct {
+	sync.Mutex
+	files map[string]*currentFile
+}{files: make(map[string]*currentFile)}
+
+// currentFile is the cached current file of a rotation by size
+type currentFile struct {
+	name    string    // the name of the file
+	calls   int       // the calls that returned the file since it was looked up
+	checked time.Time // when the file was looked up
+}
+
+// rotatedPatterns caches the compiled patterns of the names of the rotated files, by pattern
+var rotatedPatterns sync.Map
+
+// current returns the name of the file where the logs are written now, in the folder passed
+// the current file of a rotation by size is cached, so the folder is listed and the size
+// of the file is checked only every currentCheckCalls calls or currentCheckInterval
+func (r rotation) current(folder string) string {
+	stem, ext := r.split()
+	if r.policy == RotateMonthly {
+		return fmt.Sprintf("%s_%s%s", stem, time.Now().Format("2006_01"), ext)
+	}
+
+	key := fmt.Sprintf("%s\x00%s\x00%d", folder, r.base, r.maxSize)
+	currentFiles.Lock()
+	defer currentFiles.Unlock()
+	if c, ok := currentFiles.files[key]; ok && c.calls < currentCheckCalls && time.Since(c.checked) < currentCheckInterval {
+		c.calls++
+		return c.name
+	}
+
+	name := r.lookupCurrent(folder)
+	currentFiles.files[key] = &currentFile{name: name, checked: time.Now()}
+	return name
+}
+
+// lookupCurrent returns the name of the current file of the rotation by size in the folder passed,
+// the last rotated file or the next one if it exceeds the maximum size
+func (r rotation) lookupCurrent(folder string) string {
+	stem, ext := r.split()
+	files := r.rotated(folder)
+	if len(files) == 0 {
+		return fmt.Sprintf("%s_%03d%s", stem, 1, ext)
+	}
+
+	last := files[len(files)-1]
+	info, err := os.Stat(filepath.Join(folder, last))
+	if err != nil || r.maxSize <= 0 || info.Size() < r.maxSize {
+		return last
+	}
+
+	n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(last, stem+"_"), ext))
+	return fmt.Sprintf("%s_%03d%s", stem, n+1, ext)
+}
+
+// files returns the paths of the recent files read by the queries in the folder passed,
+// from the current one to the oldest, the current file passed is always the first
+// (it isn't counted in the recent files until it is created)
+func (r rotation) files(folder, current string) []string {
+	names := r.rotated(folder)
+	if _, err := os.Stat(filepath.Join(folder, r.base)); err == nil {
+		names = append([]string{r.base}, names...)
+	}
+
+	limit := r.recent
+	paths := []string{filepath.Join(folder, current)}
+	if _, err := os.Stat(paths[0]); err != nil {
+		limit++
+	}
+
+	for i := len(names) - 1; i >= 0 && len(paths) < limit; i-- {
+		if names[i] != current {
+			paths = append(paths, filepath.Join(folder, names[i]))
+		}
+	}
+	return paths
+}
+
+// rotated returns the names of the rotated files in the folder passed, from the oldest to the newest
+func (r rotation) rotated(folder string) []string {
+	stem, ext := r.split()
+	pattern := `_\d{3,}`
+	if r.policy == RotateMonthly {
+		pattern = `_\d{4}_\d{2}`
+	}
+	pattern = "^" + regexp.QuoteMeta(stem) + pattern + regexp.QuoteMeta(ext) + "$"
+	cached, ok := rotatedPatterns.Load(pattern)
+	if !ok {
+		cached, _ = rotatedPatterns.LoadOrStore(pattern, regexp.MustCompile(pattern))
+	}
+	re := cached.(*regexp.Regexp)
+
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0)
+	for _, e := range entries {
+		if !e.IsDir() && re.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) < len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// split returns the name of the database file without the extension and the extension
+func (r rotation) split() (string, string) {
+	ext := filepath.Ext(r.base)
+	return strings.TrimSuffix(r.base, ext), ext
+}
+
+// rotatedStores returns a store for every database file of the rotation of the store passed,
+// the rotated files and the database file saved before the rotation was enabled
+func (s *sqliteStore) rotatedStores() []*sqliteStore {
+	names := s.rotation.rotated(s.folderPath)
+	if _, err := os.Stat(filepath.Join(s.folderPath, s.rotation.base)); err == nil {
+		names = append(names, s.rotation.base)
+	}
+
+	stores := make([]*sqliteStore, 0, len(names))
+	for _, name := range names {
+		c := *s
+		c.fileName = name
+		c.rotation, c.sources = rotation{}, nil
+		stores = append(stores, &c)
+	}
+	return stores
+}
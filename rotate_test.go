@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRotateBySize(t *testing.T) {
+	l := newTestLogger(t)
+	l.Rotate(RotateBySize, 1, 10)
+
+	message := strings.Repeat("x", 16<<10)
+	for i := 0; i < 3*currentCheckCalls; i++ {
+		if _, err := l.Info(message); err != nil {
+			t.Fatalf("Info() = %v", err)
+		}
+	}
+
+	s, ok := sqliteStoreOf(l.getStore())
+	if !ok {
+		t.Fatal("the store of the logger is not a SQLite store")
+	}
+	files := s.rotation.rotated(s.folderPath)
+	if len(files) < 2 {
+		t.Fatalf("rotated files = %v, want at least 2", files)
+	}
+	if s.fileName != files[len(files)-1] {
+		t.Errorf("current file = %s, want the last rotated file %s", s.fileName, files[len(files)-1])
+	}
+
+	entries, err := l.GetLogs()
+	if err != nil {
+		t.Fatalf("GetLogs() = %v", err)
+	}
+	if len(entries) != 3*currentCheckCalls {
+		t.Errorf("GetLogs() returned %d logs, want %d", len(entries), 3*currentCheckCalls)
+	}
+}
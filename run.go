@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// run represents an execution of the process, every log created by the process has its id
+type run struct {
+	id       string    // the id of the run, sortable by its start time
+	start    time.Time // the time the process started
+	hostname string    // the name of the machine running the process
+	pid      int       // the id of the process
+	version  string    // the version of the main module of the process, if known
+	revision string    // the VCS revision the binary was built from, if known
+	dirty    bool      // if true the binary was built with uncommitted changes
+}
+
+// currentRun is the run of this process
+var currentRun = newRun()
+
+// newRun returns the run of a process started now
+func newRun() run {
+	r := run{start: time.Now(), pid: os.Getpid()}
+	r.hostname, _ = os.Hostname()
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Version != "(devel)" {
+			r.version = info.Main.Version
+		}
+
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				r.revision = setting.Value
+			case "vcs.modified":
+				r.dirty = setting.Value == "true"
+			}
+		}
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	r.id = r.start.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+	return r
+}
+
+// RunID returns the id of the current execution of the process
+// the id is generated when the process starts and it is saved with every log
+// created by the process, so the logs of an execution can be selected with
+// queries.RunID (or the logs of the last execution with queries.LastRun)
+// the ids are sorted by the start time of the runs
+func RunID() string {
+	return currentRun.id
+}
+
+// BuildInfo returns the version of the main module, the VCS revision and the dirty flag
+// (true if the working tree had uncommitted changes) of the binary of the process,
+// read with debug.ReadBuildInfo; they are saved with the run of the process, so the logs
+// can be tied to the build that created them (see queries.Revision)
+// the values are empty if the binary was built without the module or the VCS information
+// (e.g. with go run or -buildvcs=false)
+func BuildInfo() (version, revision string, dirty bool) {
+	return currentRun.version, currentRun.revision, currentRun.dirty
+}
+
+// registerRun saves the current run in the runs table of the database, if it is not saved yet,
+// using the transaction passed; the run must be marked registered after the transaction is committed
+// the pool must not be locked
+func registerRun(tx *sql.Tx, s *sqliteStore) error {
+	pool.Lock()
+	registered := pool.runs[s.dbPath()]
+	pool.Unlock()
+	if registered {
+		return nil
+	}
+
+	_, err := tx.Exec(
+		"INSERT OR IGNORE INTO runs (id, start, hostname, pid, version, revision, dirty) VALUES (?, ?, ?, ?, ?, ?, ?);",
+		currentRun.id, currentRun.start.UTC().Format(time.RFC3339), currentRun.hostname, currentRun.pid,
+		currentRun.version, currentRun.revision, currentRun.dirty,
+	)
+	return err
+}
+
+// markRunRegistered records that the current run is saved in the database of the store passed
+func markRunRegistered(s *sqliteStore) {
+	pool.Lock()
+	defer pool.Unlock()
+	pool.runs[s.dbPath()] = true
+}
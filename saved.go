@@ -0,0 +1,298 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// savedQueryRef is the start of the references to the saved queries written by queries.Saved,
+// saved_query('name') with the quotes of the name doubled
+const savedQueryRef = "saved_query("
+
+// maxSavedDepth is the maximum depth of the saved queries expanded in a query,
+// the deeper references are a cycle (e.g. a saved query written in the database referencing itself)
+const maxSavedDepth = 8
+
+// SavedQuery represents a query saved in the database with SaveQuery
+type SavedQuery struct {
+	Name    string    `json:"name"`    // the name of the query
+	Query   string    `json:"query"`   // the SQL appended to the base query (WHERE, ORDER BY, LIMIT ...)
+	Updated time.Time `json:"updated"` // the time the query was saved
+}
+
+// SaveQuery saves in the database the query built with the query options passed with the name passed,
+// so a recurring investigation can be run again with PrintSaved or queries.Saved,
+// by the same program, by other tools reading the database or by the CLI (logger list -saved name)
+// saving a query with the name of an existing one replaces it
+// Example:
+//
+//	log.SaveQuery("prod api errors today", queries.LevelEqual(logger.Error),
+//		queries.TagKeyEquals("env", "prod"), queries.HasTags("api"), queries.Today())
+//	log.PrintSaved("prod api errors today")
+//
+// the filters relative to the current time (queries.Since, queries.Today, queries.ThisWeek, ...) are saved
+// relative, so they select the logs of the time the saved query runs, while the values of the other filters
+// are saved as they are (e.g. the time of queries.InstantAfter); the saved queries used by the query options
+// are saved expanded
+// this method returns ErrNotSupported if the store is not a SQLite store
+// and an error if the name is empty, if the query options are not valid or if it fails to save the query
+func (opts *Logger) SaveQuery(name string, queryOptions ...QueryOption) error {
+	if name == "" {
+		return errors.New("[logger-pkg] the name of the saved query can't be empty")
+	}
+
+	s := opts.getStore()
+	store, ok := s.(*sqliteStore)
+	if !ok {
+		return unsupported(s)
+	}
+
+	query, err := buildQuery(queryOptions...)
+	if err != nil {
+		return err
+	}
+
+	return store.retry(context.Background(), func() error {
+		return saveQuery(context.Background(), store, name, query)
+	})
+}
+
+// SavedQueries returns the queries saved in the database, sorted by name
+// this method returns ErrNotSupported if the store is not a SQLite store or a snapshot
+func (opts *Logger) SavedQueries() ([]SavedQuery, error) {
+	s := opts.getStore()
+	store, ok := sqliteStoreOf(s)
+	if !ok {
+		return nil, unsupported(s)
+	}
+
+	var saved []SavedQuery
+	err := store.retry(context.Background(), func() error {
+		var err error
+		saved, err = listSavedQueries(context.Background(), store)
+		return err
+	})
+	return saved, err
+}
+
+// DeleteSavedQuery deletes the saved query with the name passed, it does nothing if the query doesn't exist
+// this method returns ErrNotSupported if the store is not a SQLite store
+func (opts *Logger) DeleteSavedQuery(name string) error {
+	s := opts.getStore()
+	store, ok := s.(*sqliteStore)
+	if !ok {
+		return unsupported(s)
+	}
+
+	return store.retry(context.Background(), func() error {
+		db, err := getWritableDBConnection(store)
+		if err != nil {
+			return err
+		}
+		defer releaseDBConnection(store, db)
+
+		_, err = db.ExecContext(context.Background(), "DELETE FROM saved_queries WHERE name = ?;", name)
+		if err != nil {
+			return errors.New("[logger-pkg] failed to delete the saved query: " + err.Error())
+		}
+		return nil
+	})
+}
+
+// PrintSaved prints the logs selected by the saved query with the name passed (see SaveQuery),
+// in the order of the query, the query options passed add their filters and sorts to the saved ones
+// Example:
+//
+//	log.PrintSaved("prod api errors today", queries.MessageLike("timeout"))
+//
+// this method returns ErrNotSupported if the store is not a SQLite store or a snapshot,
+// an error if the query doesn't exist or if it fails to query the logs
+func (opts *Logger) PrintSaved(name string, queryOptions ...QueryOption) error {
+	s := opts.getStore()
+	store, ok := sqliteStoreOf(s)
+	if !ok {
+		return unsupported(s)
+	}
+
+	var query string
+	err := store.retry(context.Background(), func() error {
+		db, err := getDBConnection(store)
+		if err != nil {
+			return err
+		}
+		defer releaseDBConnection(store, db)
+
+		query, err = getSavedQuery(context.Background(), db, name)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	saved := func(sb *strings.Builder) {
+		sb.WriteString(" ")
+		sb.WriteString(query)
+	}
+	return opts.PrintLogs(append([]QueryOption{saved}, queryOptions...)...)
+}
+
+// saveQuery saves the tail of the query passed in the database with the name passed,
+// the references to the other saved queries are expanded
+func saveQuery(ctx context.Context, s *sqliteStore, name, query string) error {
+	db, err := getWritableDBConnection(s)
+	if err != nil {
+		return err
+	}
+	defer releaseDBConnection(s, db)
+
+	query, err = expandSavedQueries(ctx, db, query)
+	if err != nil {
+		return err
+	}
+
+	tail := strings.TrimSpace(strings.TrimPrefix(query, defaultQuery))
+	_, err = db.ExecContext(ctx, "INSERT INTO saved_queries (name, query, updated) VALUES (?, ?, ?) ON CONFLICT (name) DO UPDATE SET query = excluded.query, updated = excluded.updated;",
+		name, tail, time.Now().UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return errors.New("[logger-pkg] failed to save the query: " + err.Error())
+	}
+	return nil
+}
+
+// listSavedQueries returns the queries saved in the database sorted by name
+func listSavedQueries(ctx context.Context, s *sqliteStore) ([]SavedQuery, error) {
+	db, err := getDBConnection(s)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseDBConnection(s, db)
+
+	rows, err := db.QueryContext(ctx, "SELECT name, query, updated FROM saved_queries ORDER BY name;")
+	if err != nil {
+		return nil, errors.New("[logger-pkg] failed to list the saved queries: " + err.Error())
+	}
+	defer rows.Close()
+
+	saved := make([]SavedQuery, 0)
+	for rows.Next() {
+		var q SavedQuery
+		var updated string
+		if err := rows.Scan(&q.Name, &q.Query, &updated); err != nil {
+			return nil, errors.New("[logger-pkg] failed to list the saved queries: " + err.Error())
+		}
+		q.Updated, _ = time.Parse(time.RFC3339Nano, updated)
+		saved = append(saved, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("[logger-pkg] failed to list the saved queries: " + err.Error())
+	}
+	return saved, nil
+}
+
+// getSavedQuery returns the tail of the saved query with the name passed
+// it returns an error if the query doesn't exist
+func getSavedQuery(ctx context.Context, db *sql.DB, name string) (string, error) {
+	var query string
+	err := db.QueryRowContext(ctx, "SELECT query FROM saved_queries WHERE name = ?;", name).Scan(&query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("[logger-pkg] the saved query %q doesn't exist", name)
+	}
+
+	if err != nil {
+		return "", errors.New("[logger-pkg] failed to read the saved query: " + err.Error())
+	}
+	return query, nil
+}
+
+// expandSavedQueries replaces the references to the saved queries in the query passed (see queries.Saved)
+// with the filters selecting the logs of the saved queries, read from the database passed
+// only the references outside of the quoted strings are replaced, so a filter value like "saved_query('x')"
+// is never expanded, and every saved query is validated as the query options are, since the database
+// can be written by other programs
+func expandSavedQueries(ctx context.Context, db *sql.DB, query string) (string, error) {
+	return expandSaved(ctx, db, query, 0)
+}
+
+// expandSaved expands the references to the saved queries in the query passed,
+// the depth is the number of saved queries being expanded
+func expandSaved(ctx context.Context, db *sql.DB, query string, depth int) (string, error) {
+	if !strings.Contains(query, savedQueryRef) {
+		return query, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				end = len(query) - i - 2
+			}
+			sb.WriteString(query[i : i+end+2])
+			i += end + 2
+		case strings.HasPrefix(query[i:], savedQueryRef) && (i == 0 || !isWordByte(query[i-1])):
+			name, n, ok := savedQueryName(query[i+len(savedQueryRef):])
+			if !ok {
+				sb.WriteByte(c)
+				i++
+				continue
+			}
+
+			if depth >= maxSavedDepth {
+				return "", fmt.Errorf("[logger-pkg] the saved query %q references too many saved queries or itself", name)
+			}
+
+			tail, err := getSavedQuery(ctx, db, name)
+			if err != nil {
+				return "", err
+			}
+
+			if err := validateQueryTail(tail); err != nil {
+				return "", fmt.Errorf("[logger-pkg] the saved query %q is not valid: %w", name, err)
+			}
+
+			tail, err = expandSaved(ctx, db, tail, depth+1)
+			if err != nil {
+				return "", err
+			}
+
+			sb.WriteString("logs.id IN (SELECT DISTINCT logs.id FROM logs LEFT JOIN log_tags ON logs.id = log_tags.log_id LEFT JOIN tags ON log_tags.tag_id = tags.id " + tail + ")")
+			i += len(savedQueryRef) + n
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String(), nil
+}
+
+// savedQueryName returns the name of the saved query referenced by the text passed, the part of the
+// reference after "saved_query(": the name quoted with its quotes doubled and the closing parenthesis
+// it also returns the length of the part of the reference and false if the text is not a reference
+func savedQueryName(ref string) (string, int, bool) {
+	if !strings.HasPrefix(ref, "'") {
+		return "", 0, false
+	}
+
+	var name strings.Builder
+	for i := 1; i < len(ref); i++ {
+		switch {
+		case ref[i] != '\'':
+			name.WriteByte(ref[i])
+		case strings.HasPrefix(ref[i:], "''"):
+			name.WriteByte('\'')
+			i++
+		case strings.HasPrefix(ref[i:], "')"):
+			return name.String(), i + 2, true
+		default:
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,461 @@
+// Package sentry reports the Error and Fatal logs created by a logger to Sentry,
+// with their stack traces and their tags, so the errors are logged and reported
+// by the same call instead of being instrumented twice
+// Example:
+//
+//	hook, err := sentry.New("https://<key>@o0.ingest.sentry.io/<project>")
+//	if err != nil {
+//		return err
+//	}
+//	hook.Environment("prod")
+//	hook.Release(version)
+//	log.AddHook(hook)
+//	defer hook.Flush(2 * time.Second)
+//
+// The events are built from the logs as follows:
+//   - the message of the log is the message of the event and the value of its exception
+//   - the type of the root cause of the errors logged with Err is the type of the exception
+//   - the stack trace is the one saved in the "stack" field (Fatal and panics), or the one of the log call
+//   - the tags of the log are the "tags" tag (sorted and separated by commas), the tags with the
+//     key:value form are Sentry tags too (e.g. the tag "tenant:acme" is the tag tenant=acme)
+//   - the fields of the log are the extra data of the event
+//
+// The Error events are sent asynchronously by a background goroutine (at most 100 are queued,
+// the next ones are dropped), the Fatal events are sent before returning, so they are
+// delivered before the program exits
+package sentry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tagliapietra96/logger/v2"
+)
+
+// the names of the fields of the logs read by the hook
+const (
+	stackField     = "stack"      // the stack trace of the Fatal logs and of the panics
+	errorTypeField = "error_type" // the type of the root cause of the errors logged with Err
+)
+
+// queueSize is the number of the events waiting to be sent
+const queueSize = 100
+
+// Hook is a logger.Hook that reports the Error and Fatal logs to Sentry
+type Hook struct {
+	endpoint    string // the URL of the envelope endpoint of the project
+	auth        string // the X-Sentry-Auth header
+	dsn         string
+	mu          sync.RWMutex
+	client      *http.Client
+	environment string
+	release     string
+	level       logger.LogLevel // the minimum level of the logs reported
+	queue       chan []byte
+	pending     sync.WaitGroup
+	lastErr     error
+}
+
+// New returns a Hook reporting the Error and Fatal logs to the Sentry project of the DSN passed
+// and starts its background goroutine
+// this function returns an error if the DSN is not valid
+func New(dsn string) (*Hook, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.New("[logger-pkg] invalid Sentry DSN: " + err.Error())
+	}
+
+	key := u.User.Username()
+	path := strings.Trim(u.Path, "/")
+	project := path
+	prefix := ""
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+
+	if key == "" || project == "" || u.Host == "" {
+		return nil, errors.New("[logger-pkg] invalid Sentry DSN: the key, the host and the project are required")
+	}
+
+	h := &Hook{
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project),
+		auth:     "Sentry sentry_version=7, sentry_client=github.com/Tagliapietra96/logger/v2, sentry_key=" + key,
+		dsn:      dsn,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		level:    logger.Error,
+		queue:    make(chan []byte, queueSize),
+	}
+	go h.run()
+	return h, nil
+}
+
+// Environment sets the environment of the events (e.g. prod, staging)
+func (h *Hook) Environment(environment string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.environment = environment
+}
+
+// Release sets the release of the events (e.g. the version of the program)
+func (h *Hook) Release(release string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.release = release
+}
+
+// Client sets the HTTP client used to send the events
+func (h *Hook) Client(client *http.Client) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.client = client
+}
+
+// MinLevel sets the minimum level of the logs reported (Error by default)
+func (h *Hook) MinLevel(level logger.LogLevel) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.level = level
+}
+
+// Levels returns the levels of the logs reported, it implements the logger.Hook interface
+func (h *Hook) Levels() []logger.LogLevel {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	levels := make([]logger.LogLevel, 0)
+	for _, level := range logger.Levels() {
+		if level >= h.level {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// BeforeWrite doesn't modify the log, it implements the logger.Hook interface
+func (h *Hook) BeforeWrite(entry *logger.Entry) error {
+	return nil
+}
+
+// AfterWrite reports the log stored to Sentry, it implements the logger.Hook interface
+// the Fatal logs are sent before returning, the other ones are queued
+func (h *Hook) AfterWrite(entry logger.Entry) {
+	envelope, err := h.envelope(entry)
+	if err != nil {
+		h.setErr(err)
+		return
+	}
+
+	if entry.Level == logger.Fatal {
+		h.setErr(h.send(envelope))
+		return
+	}
+
+	h.pending.Add(1)
+	select {
+	case h.queue <- envelope:
+	default:
+		h.pending.Done()
+		h.setErr(errors.New("[logger-pkg] the Sentry queue is full, the event was dropped"))
+	}
+}
+
+// Flush waits until the queued events are sent or the timeout expires
+// and reports if every event was sent
+func (h *Hook) Flush(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		h.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Err returns the error of the last event sent, nil if it succeeded
+func (h *Hook) Err() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastErr
+}
+
+func (h *Hook) setErr(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastErr = err
+}
+
+// run sends the queued events
+func (h *Hook) run() {
+	for envelope := range h.queue {
+		h.setErr(h.send(envelope))
+		h.pending.Done()
+	}
+}
+
+// send posts the envelope passed to Sentry
+func (h *Hook) send(envelope []byte) error {
+	h.mu.RLock()
+	client := h.client
+	h.mu.RUnlock()
+
+	req, err := http.NewRequest(http.MethodPost, h.endpoint, bytes.NewReader(envelope))
+	if err != nil {
+		return errors.New("[logger-pkg] failed to send the event to Sentry: " + err.Error())
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", h.auth)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to send the event to Sentry: " + err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("[logger-pkg] failed to send the event to Sentry: %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// frame is a frame of the stack trace of an event, the frames are sorted from the oldest call
+type frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// envelope returns the envelope of the event of the entry passed
+func (h *Hook) envelope(e logger.Entry) ([]byte, error) {
+	h.mu.RLock()
+	environment, release := h.environment, h.release
+	h.mu.RUnlock()
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, errors.New("[logger-pkg] failed to create the Sentry event: " + err.Error())
+	}
+	eventID := hex.EncodeToString(id)
+
+	var frames []frame
+	if stack, ok := e.Fields[stackField].(string); ok {
+		frames = parseStack(stack)
+	} else {
+		frames = callerStack()
+	}
+
+	exceptionType := strings.ToLower(e.Level.String())
+	if t, ok := e.Fields[errorTypeField].(string); ok && t != "" {
+		exceptionType = t
+	}
+
+	extra := make(map[string]any, len(e.Fields)+1)
+	for k, v := range e.Fields {
+		if k != stackField {
+			extra[k] = v
+		}
+	}
+	if e.ID != 0 {
+		extra["log_id"] = e.ID
+	}
+
+	event := map[string]any{
+		"event_id":    eventID,
+		"timestamp":   e.Time.UTC().Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       sentryLevel(e.Level),
+		"logger":      "github.com/Tagliapietra96/logger/v2",
+		"message":     map[string]string{"formatted": e.Message},
+		"tags":        sentryTags(e.Tags),
+		"extra":       extra,
+		"environment": environment,
+		"release":     release,
+		"server_name": e.Hostname,
+		"culprit":     e.CallerFunction,
+		"exception": map[string]any{"values": []any{map[string]any{
+			"type":       exceptionType,
+			"value":      e.Message,
+			"stacktrace": map[string]any{"frames": frames},
+		}}},
+	}
+
+	for _, k := range []string{"environment", "release", "server_name"} {
+		if event[k] == "" {
+			delete(event, k)
+		}
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, errors.New("[logger-pkg] failed to encode the Sentry event: " + err.Error())
+	}
+
+	header, _ := json.Marshal(map[string]string{
+		"event_id": eventID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":      h.dsn,
+	})
+	item, _ := json.Marshal(map[string]any{"type": "event", "length": len(data)})
+
+	var b bytes.Buffer
+	b.Write(header)
+	b.WriteByte('\n')
+	b.Write(item)
+	b.WriteByte('\n')
+	b.Write(data)
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// sentryTags returns the Sentry tags of the tags of a log
+func sentryTags(tags []string) map[string]string {
+	result := make(map[string]string)
+	if len(tags) == 0 {
+		return result
+	}
+
+	sorted := slices.Clone(tags)
+	slices.Sort(sorted)
+	result["tags"] = strings.Join(slices.Compact(sorted), ",")
+	for _, tag := range tags {
+		if k, v, ok := strings.Cut(tag, ":"); ok && k != "" && v != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// skipFunction reports if the function of a frame is not part of the stack trace of the events:
+// the functions of the runtime, of the logger package and of this package
+func skipFunction(function string) bool {
+	return strings.HasPrefix(function, "runtime.") ||
+		strings.HasPrefix(function, "runtime/debug.") ||
+		strings.HasPrefix(function, "github.com/Tagliapietra96/logger/v2.") ||
+		strings.HasPrefix(function, "github.com/Tagliapietra96/logger/v2/sentry.")
+}
+
+// callerStack returns the frames of the stack of the current goroutine, the one of the log call
+func callerStack() []frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	result := make([]frame, 0, n)
+	for {
+		f, more := frames.Next()
+		if f.Function != "" && !skipFunction(f.Function) {
+			result = append(result, newFrame(f.Function, f.File, f.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	slices.Reverse(result)
+	return result
+}
+
+// parseStack returns the frames of a stack trace formatted by runtime/debug.Stack
+//
+//	goroutine 1 [running]:
+//	main.main()
+//		/app/main.go:10 +0x1d
+func parseStack(stack string) []frame {
+	lines := strings.Split(stack, "\n")
+	result := make([]frame, 0, len(lines)/2)
+	for i := 0; i+1 < len(lines); i++ {
+		function := strings.TrimSpace(lines[i])
+		location := lines[i+1]
+		if function == "" || !strings.HasPrefix(location, "\t") {
+			continue
+		}
+		i++
+
+		function = strings.TrimPrefix(function, "created by ")
+		if j := strings.Index(function, " in goroutine "); j >= 0 {
+			function = function[:j]
+		}
+		if j := strings.LastIndex(function, "("); j > 0 && strings.HasSuffix(function, ")") {
+			function = function[:j]
+		}
+
+		location = strings.TrimSpace(location)
+		if j := strings.LastIndex(location, " +0x"); j >= 0 {
+			location = location[:j]
+		}
+		file, line := location, 0
+		if j := strings.LastIndex(location, ":"); j >= 0 {
+			file = location[:j]
+			line, _ = strconv.Atoi(location[j+1:])
+		}
+
+		if !skipFunction(function) {
+			result = append(result, newFrame(function, file, line))
+		}
+	}
+	slices.Reverse(result)
+	return result
+}
+
+// newFrame returns the frame of the function passed, the functions of the standard library are not in app
+func newFrame(function, file string, line int) frame {
+	module := function
+	if i := strings.LastIndex(module, "/"); i >= 0 {
+		if j := strings.Index(module[i:], "."); j >= 0 {
+			module = module[:i+j]
+		}
+	} else if j := strings.Index(module, "."); j >= 0 {
+		module = module[:j]
+	}
+
+	first, _, _ := strings.Cut(module, "/")
+	return frame{
+		Function: function,
+		Module:   module,
+		Filename: file[strings.LastIndex(file, "/")+1:],
+		AbsPath:  file,
+		Lineno:   line,
+		InApp:    module == "main" || strings.Contains(first, "."),
+	}
+}
+
+// sentryLevel returns the Sentry level of the level passed, the levels renamed or defined
+// with logger.SetLevelLabel are reported with the level of the package below them
+func sentryLevel(level logger.LogLevel) string {
+	switch {
+	case level >= logger.Fatal:
+		return "fatal"
+	case level >= logger.Error:
+		return "error"
+	case level >= logger.Warning:
+		return "warning"
+	case level >= logger.Info:
+		return "info"
+	default:
+		return "debug"
+	}
+}
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/queries"
+)
+
+// forwarding defaults
+const (
+	maxIngestSize       = 32 << 20         // the maximum size of the body of POST /logs
+	defaultBatchSize    = 500              // the number of logs sent by a request of the forwarder
+	defaultForwardEvery = 10 * time.Second // how often the forwarder looks for the new logs
+	maxBackoff          = 5 * time.Minute  // the maximum wait of the forwarder after a failure
+)
+
+// Ingest enables POST /logs if the enabled parameter is true, so the server
+// stores the logs sent by the forwarders of other processes (see Forwarder)
+// the logs already stored are skipped, so a batch sent twice is not duplicated
+// it is disabled by default and when the server is read-only
+func (s *Server) Ingest(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ingest = enabled
+}
+
+// postLogs imports the logs of the request body, a JSON array of logs in the format of GET /logs
+func (s *Server) postLogs(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	ingest := s.ingest && !s.readOnly
+	s.mu.RUnlock()
+	if !ingest {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("the server doesn't accept logs"))
+		return
+	}
+
+	var received []entry
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxIngestSize))
+	decoder.UseNumber()
+	if err := decoder.Decode(&received); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid logs: "+err.Error()))
+		return
+	}
+
+	entries := make([]logger.Entry, 0, len(received))
+	for i, e := range received {
+		level, err := logger.ParseLevel(e.Level)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("log %d: %s", i, err.Error()))
+			return
+		}
+		e.Entry.Level = level
+		entries = append(entries, e.Entry)
+	}
+
+	imported, err := s.logger.ImportEntries(entries...)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"received": len(entries), "imported": imported})
+}
+
+// Forwarder ships the logs stored by a logger to a central server (see Server.Ingest),
+// so the logs of a fleet of processes are consolidated in one database
+// the logs are sent in batches in the order they were stored, the id of the last log
+// accepted by the server is the high-water mark: the next batches start after it,
+// and a failed batch is sent again after a wait that doubles at every failure (up to 5 minutes)
+// Example:
+//
+//	f := server.NewForwarder(log, "http://logs.internal:8080")
+//	f.StateFile("forwarder.state")
+//	go f.Run(ctx)
+//
+// the central server skips the logs it already stores, so losing the high-water mark
+// (e.g. without a state file) sends the logs again without duplicating them
+type Forwarder struct {
+	logger    *logger.Logger
+	url       string
+	client    *http.Client
+	mu        sync.Mutex
+	batchSize int                  // the number of logs sent by a request
+	interval  time.Duration        // how often Run looks for the new logs
+	filters   []logger.QueryOption // the filters of the logs forwarded, every log if empty
+	stateFile string               // the file where the high-water mark is saved, if empty it is kept in memory
+	mark      int64                // the id of the last log accepted by the server
+}
+
+// NewForwarder returns a Forwarder of the logs of the logger passed to the server
+// at the URL passed (the URL where the Server is mounted, the logs are sent to its /logs endpoint)
+func NewForwarder(l *logger.Logger, url string) *Forwarder {
+	return &Forwarder{
+		logger:    l,
+		url:       strings.TrimSuffix(url, "/") + "/logs",
+		client:    &http.Client{Timeout: 30 * time.Second},
+		batchSize: defaultBatchSize,
+		interval:  defaultForwardEvery,
+	}
+}
+
+// Client sets the HTTP client used to send the logs (e.g. to add the authentication of the server)
+func (f *Forwarder) Client(client *http.Client) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.client = client
+}
+
+// BatchSize sets the number of logs sent by a request (500 by default, at most 1000)
+func (f *Forwarder) BatchSize(n int) {
+	if n <= 0 {
+		n = defaultBatchSize
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batchSize = min(n, maxLimit)
+}
+
+// Interval sets how often Run looks for the new logs to forward (10 seconds by default)
+func (f *Forwarder) Interval(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultForwardEvery
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.interval = interval
+}
+
+// Filter sets the filters of the logs forwarded (e.g. queries.LevelBetween(logger.Warning, logger.Fatal)),
+// without filters every log is forwarded
+func (f *Forwarder) Filter(filters ...logger.QueryOption) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.filters = append([]logger.QueryOption(nil), filters...)
+}
+
+// StateFile sets the file where the high-water mark is saved after every batch,
+// so a restarted process continues from the last log forwarded
+// the mark saved in the file, if it exists, is loaded
+// this method returns an error if it fails to read the file
+func (f *Forwarder) StateFile(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.New("[logger-pkg] failed to read the forwarder state: " + err.Error())
+	}
+
+	if err == nil {
+		mark, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return errors.New("[logger-pkg] failed to read the forwarder state: " + err.Error())
+		}
+		f.mark = mark
+	}
+
+	f.stateFile = path
+	return nil
+}
+
+// HighWaterMark returns the id of the last log accepted by the server, 0 if none
+func (f *Forwarder) HighWaterMark() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mark
+}
+
+// Run forwards the new logs every interval until the context is done, waiting longer
+// after every failure, and returns the error of the context
+func (f *Forwarder) Run(ctx context.Context) error {
+	f.mu.Lock()
+	interval := f.interval
+	f.mu.Unlock()
+
+	wait := time.Duration(0)
+	backoff := interval
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		if _, err := f.Flush(ctx); err != nil && ctx.Err() == nil {
+			wait = backoff
+			backoff = min(backoff*2, maxBackoff)
+			continue
+		}
+
+		wait, backoff = interval, interval
+	}
+}
+
+// Flush sends the logs stored after the high-water mark, in batches, until every log is forwarded
+// this method returns the number of logs forwarded and an error if a batch fails,
+// the logs of the failed batch are sent again by the next call
+func (f *Forwarder) Flush(ctx context.Context) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	forwarded := 0
+	for {
+		entries, err := f.logger.GetLogsContext(ctx, append(f.filters, queries.IDGreaterThan(f.mark), queries.SortID("ASC"), queries.AddLimit(f.batchSize))...)
+		if err != nil {
+			return forwarded, err
+		}
+
+		if len(entries) == 0 {
+			return forwarded, nil
+		}
+
+		if err := f.send(ctx, entries); err != nil {
+			return forwarded, err
+		}
+
+		f.mark = entries[len(entries)-1].ID
+		forwarded += len(entries)
+		if err := f.saveMark(); err != nil {
+			return forwarded, err
+		}
+
+		if len(entries) < f.batchSize {
+			return forwarded, nil
+		}
+	}
+}
+
+// send posts the entries passed to the server
+func (f *Forwarder) send(ctx context.Context, entries []logger.Entry) error {
+	batch := make([]entry, 0, len(entries))
+	for _, e := range entries {
+		batch = append(batch, entry{Entry: e, Level: e.Level.String()})
+	}
+
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to encode the logs to forward: " + err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("[logger-pkg] failed to forward the logs: " + err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to forward the logs: " + err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("[logger-pkg] failed to forward the logs: %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// saveMark writes the high-water mark in the state file, if set
+// the mark is written in a temporary file renamed over the state file, so it is never truncated
+func (f *Forwarder) saveMark() error {
+	if f.stateFile == "" {
+		return nil
+	}
+
+	tmp := filepath.Join(filepath.Dir(f.stateFile), "."+filepath.Base(f.stateFile)+".tmp")
+	err := os.WriteFile(tmp, []byte(strconv.FormatInt(f.mark, 10)+"\n"), 0o644)
+	if err == nil {
+		err = os.Rename(tmp, f.stateFile)
+	}
+
+	if err != nil {
+		return errors.New("[logger-pkg] failed to save the forwarder state: " + err.Error())
+	}
+	return nil
+}
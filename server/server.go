@@ -0,0 +1,398 @@
+// Package server exposes the logs database of a logger over HTTP with a JSON API,
+// so a small dashboard or curl can inspect the logs of a process remotely
+// Example:
+//
+//	log := logger.New()
+//	http.Handle("/logs/", http.StripPrefix("/logs", server.New(log)))
+//	http.ListenAndServe(":8080", nil)
+//
+// The server has the following endpoints:
+//   - GET /: a web UI to browse the logs with filters, infinite scroll and a live mode
+//   - GET /logs: returns the logs matching the filters of the query string
+//   - GET /stats: returns the number of the logs matching the filters by level and by tag
+//   - DELETE /logs: deletes the logs matching the filters of the query string (disabled by default, see AllowDelete)
+//   - POST /logs: imports the logs of the request body, sent by a Forwarder (disabled by default, see Ingest)
+//   - GET /stream: pushes the new logs matching the filters as Server-Sent Events (a live tail)
+//
+// The filters mirror the options of the queries package:
+//   - level: the minimum level of the logs (trace, debug, info, notice, warning, error, fatal or a custom label)
+//   - tag: the logs with the tag (it can be repeated, the logs with at least one of the tags)
+//   - q: a filter expression (see queries.Parse), e.g. q=level>=warning AND tag:api
+//   - message: the logs whose message contains the text
+//   - search: the logs matching the full-text search (see queries.Search)
+//   - since, until: the logs created after or before a time, as a duration before now (1h) or a RFC 3339 time
+//   - run: the logs created by the run with the id, or by the last one with run=last
+//
+// GET /logs accepts the sort (time, level, caller_file, caller_line, caller_function or message,
+// prefixed with "-" for the descending order, default -time, the logs with the same value are sorted by id),
+// limit (default 100, at most 1000) and offset parameters for the pagination
+// DELETE /logs requires at least one filter, or all=true to delete every log
+//
+// The server has no authentication: it is read-only by default, wrap it in the middleware
+// of the application before enabling DELETE (AllowDelete) or POST (Ingest) when it is reachable by others
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/queries"
+)
+
+// pagination limits of GET /logs
+const (
+	defaultLimit = 100  // the number of logs returned when no limit is passed
+	maxLimit     = 1000 // the maximum number of logs returned by a request
+)
+
+// Server is the http.Handler of the JSON API of a logger
+type Server struct {
+	logger   *logger.Logger
+	mux      *http.ServeMux
+	mu       sync.RWMutex
+	readOnly bool          // if true DELETE and POST /logs are disabled
+	delete   bool          // if true DELETE /logs deletes the logs of the request
+	ingest   bool          // if true POST /logs imports the logs of the request
+	interval time.Duration // how often GET /stream polls the database for the new logs
+}
+
+// New returns the Server of the logs database of the logger passed,
+// the server is read-only until DELETE or POST /logs are enabled with AllowDelete or Ingest
+func New(l *logger.Logger) *Server {
+	s := &Server{logger: l, mux: http.NewServeMux(), interval: defaultStreamInterval}
+	s.mux.HandleFunc("/logs", s.handleLogs)
+	s.mux.HandleFunc("/stats", s.handleStats)
+	s.mux.HandleFunc("/stream", s.handleStream)
+	s.mux.HandleFunc("/", s.handleUI)
+	return s
+}
+
+// ReadOnly disables DELETE and POST /logs if the enabled parameter is true, even if
+// they are enabled by AllowDelete or Ingest, the requests are answered with 405 Method Not Allowed
+func (s *Server) ReadOnly(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readOnly = enabled
+}
+
+// AllowDelete enables DELETE /logs if the enabled parameter is true, so the clients
+// can delete the logs matching the filters of the request
+// it is disabled by default and when the server is read-only
+func (s *Server) AllowDelete(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delete = enabled
+}
+
+// ServeHTTP serves the requests of the API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the API of the logger passed on the address passed
+// it is a shortcut of http.ListenAndServe(addr, New(l))
+func ListenAndServe(addr string, l *logger.Logger) error {
+	return http.ListenAndServe(addr, New(l))
+}
+
+// entry is a log in the responses, with the level as a label
+type entry struct {
+	logger.Entry
+	Level string `json:"level"`
+}
+
+// handleLogs serves GET, POST and DELETE /logs
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.getLogs(w, r)
+	case http.MethodPost:
+		s.postLogs(w, r)
+	case http.MethodDelete:
+		s.mu.RLock()
+		allowed := s.delete && !s.readOnly
+		s.mu.RUnlock()
+		if !allowed {
+			writeError(w, http.StatusMethodNotAllowed, errors.New("the server doesn't delete logs"))
+			return
+		}
+		s.deleteLogs(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// getLogs writes the page of the logs matching the filters of the request
+func (s *Server) getLogs(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sort, err := parseSort(r.URL.Query().Get("sort"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// one more log is read to know if there is a next page
+	queryOptions := append(filters, sort, queries.AddLimit(limit+1, offset))
+	entries, err := s.logger.GetLogsContext(r.Context(), queryOptions...)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	more := len(entries) > limit
+	if more {
+		entries = entries[:limit]
+	}
+
+	logs := make([]entry, 0, len(entries))
+	for _, e := range entries {
+		logs = append(logs, entry{Entry: e, Level: e.Level.String()})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"logs":   logs,
+		"limit":  limit,
+		"offset": offset,
+		"more":   more,
+	})
+}
+
+// deleteLogs deletes the logs matching the filters of the request
+func (s *Server) deleteLogs(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if len(filters) == 0 && r.URL.Query().Get("all") != "true" {
+		writeError(w, http.StatusBadRequest, errors.New("pass at least one filter, or all=true to delete every log"))
+		return
+	}
+
+	deleted, err := s.logger.DeleteLogsContext(r.Context(), filters...)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"deleted": deleted})
+}
+
+// handleStats serves GET /stats
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	filters, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// the logs are counted by the store, without reading them
+	summary, err := s.logger.SummaryContext(r.Context(), filters...)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	levels := logger.Levels()
+	byLevel := make(map[string]int, len(levels))
+	for _, level := range levels {
+		byLevel[level.String()] = 0
+	}
+	for level, count := range summary.Levels {
+		byLevel[level.String()] += count
+	}
+
+	var first, last *time.Time
+	if summary.Total > 0 {
+		first, last = &summary.First, &summary.Last
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"total":  summary.Total,
+		"levels": byLevel,
+		"tags":   summary.Tags,
+		"first":  first,
+		"last":   last,
+	})
+}
+
+// parseFilters returns the query options of the filters of the request
+func parseFilters(r *http.Request) ([]logger.QueryOption, error) {
+	params := r.URL.Query()
+	filters := make([]logger.QueryOption, 0)
+
+	if v := params.Get("level"); v != "" {
+		level, err := logger.ParseLevel(v)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, queries.Not(queries.LevelLessThan(level)))
+	}
+
+	if tags := params["tag"]; len(tags) > 0 {
+		filters = append(filters, queries.HasTags(tags[0], tags[1:]...))
+	}
+
+	if v := params.Get("q"); v != "" {
+		filter, err := queries.Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+
+	if v := params.Get("message"); v != "" {
+		filters = append(filters, queries.MessageLike(v))
+	}
+
+	if v := params.Get("search"); v != "" {
+		filters = append(filters, queries.Search(v))
+	}
+
+	if v := params.Get("since"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, queries.InstantAfter(t))
+	}
+
+	if v := params.Get("until"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, queries.InstantBefore(t))
+	}
+
+	if v := params.Get("run"); v == "last" {
+		filters = append(filters, queries.LastRun())
+	} else if v != "" {
+		filters = append(filters, queries.RunID(v))
+	}
+
+	return filters, nil
+}
+
+// parseSort returns the sort option of the field passed, "-" before the field sorts in descending order
+// the logs with the same value are sorted by id, so the pages don't overlap or skip the logs
+func parseSort(s string) (logger.QueryOption, error) {
+	if s == "" {
+		s = "-time"
+	}
+
+	order := "ASC"
+	if field, ok := strings.CutPrefix(s, "-"); ok {
+		s, order = field, "DESC"
+	}
+
+	var sort logger.QueryOption
+	switch s {
+	case "time":
+		sort = queries.SortTimestamp(order)
+	case "level":
+		sort = queries.SortLevel(order)
+	case "caller_file":
+		sort = queries.SortCallerFile(order)
+	case "caller_line":
+		sort = queries.SortCallerLine(order)
+	case "caller_function":
+		sort = queries.SortCallerFunction(order)
+	case "message":
+		sort = queries.SortMessage(order)
+	default:
+		return nil, fmt.Errorf("invalid sort %q", s)
+	}
+
+	tieBreaker := queries.SortID(order)
+	return func(sb *strings.Builder) {
+		sort(sb)
+		tieBreaker(sb)
+	}, nil
+}
+
+// parsePage returns the limit and the offset of the request
+func parsePage(r *http.Request) (int, int, error) {
+	limit, offset := defaultLimit, 0
+	params := r.URL.Query()
+
+	if v := params.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("invalid limit %q", v)
+		}
+		limit = min(n, maxLimit)
+	}
+
+	if v := params.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %q", v)
+		}
+		offset = n
+	}
+
+	return limit, offset, nil
+}
+
+// parseTime returns the time represented by the string passed,
+// a duration before now (e.g. 24h) or a RFC 3339 time
+func parseTime(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use a duration (24h) or a RFC 3339 time", s)
+	}
+	return t, nil
+}
+
+// statusOf returns the HTTP status of the error of the logger passed
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, logger.ErrInvalidQuery):
+		return http.StatusBadRequest
+	case errors.Is(err, logger.ErrNotSupported), errors.Is(err, logger.ErrNoStore):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeJSON writes the value passed as the JSON body of the response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes the error passed as the JSON body of the response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
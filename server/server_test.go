@@ -0,0 +1,117 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tagliapietra96/logger/v2"
+)
+
+// newTestServer returns a server of a logger writing in a temporary folder
+func newTestServer(t *testing.T) (*logger.Logger, *Server) {
+	t.Helper()
+	l := logger.New()
+	l.Folder(t.TempDir())
+	l.SetOutput(io.Discard)
+	t.Cleanup(func() { l.Close() })
+	return l, New(l)
+}
+
+// serve sends the request passed to the server and decodes the JSON body of the response in v
+func serve(t *testing.T, s *Server, method, target string, v any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: invalid body %q: %v", method, target, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestStats(t *testing.T) {
+	l, s := newTestServer(t)
+	l.Info("untagged")
+	l.Child("api").Error("failed")
+	l.Child("api", "db").Error("failed again")
+
+	var stats struct {
+		Total  int            `json:"total"`
+		Levels map[string]int `json:"levels"`
+		Tags   map[string]int `json:"tags"`
+		First  *string        `json:"first"`
+	}
+	if code := serve(t, s, http.MethodGet, "/stats", &stats); code != http.StatusOK {
+		t.Fatalf("GET /stats = %d, want 200", code)
+	}
+
+	if stats.Total != 3 {
+		t.Errorf("total = %d, want 3", stats.Total)
+	}
+	if stats.Levels["INFO"] != 1 || stats.Levels["ERROR"] != 2 || stats.Levels["DEBUG"] != 0 {
+		t.Errorf("levels = %v, want 1 info and 2 errors", stats.Levels)
+	}
+	if stats.Tags["api"] != 2 || stats.Tags["db"] != 1 || len(stats.Tags) != 2 {
+		t.Errorf("tags = %v, want api: 2 and db: 1", stats.Tags)
+	}
+	if stats.First == nil {
+		t.Errorf("first = nil, want the time of the oldest log")
+	}
+
+	if code := serve(t, s, http.MethodGet, "/stats?level=error&tag=db", &stats); code != http.StatusOK || stats.Total != 1 {
+		t.Errorf("GET /stats?level=error&tag=db = %d with %d logs, want 200 with 1 log", code, stats.Total)
+	}
+}
+
+func TestGetLogsSortTies(t *testing.T) {
+	l, s := newTestServer(t)
+	for _, message := range []string{"first", "second", "third"} {
+		l.Info(message)
+	}
+
+	var page struct {
+		Logs []struct {
+			ID int64 `json:"id"`
+		} `json:"logs"`
+	}
+	if code := serve(t, s, http.MethodGet, "/logs", &page); code != http.StatusOK {
+		t.Fatalf("GET /logs = %d, want 200", code)
+	}
+	if len(page.Logs) != 3 {
+		t.Fatalf("GET /logs returned %d logs, want 3", len(page.Logs))
+	}
+	// the logs share the second of their time, the newest ones are returned first
+	for i := 1; i < len(page.Logs); i++ {
+		if page.Logs[i].ID >= page.Logs[i-1].ID {
+			t.Errorf("GET /logs ids = %v, want them in descending order", page.Logs)
+			break
+		}
+	}
+}
+
+func TestDeleteDisabledByDefault(t *testing.T) {
+	l, s := newTestServer(t)
+	l.Info("kept")
+
+	if code := serve(t, s, http.MethodDelete, "/logs?all=true", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE /logs = %d, want 405", code)
+	}
+
+	s.AllowDelete(true)
+	s.ReadOnly(true)
+	if code := serve(t, s, http.MethodDelete, "/logs?all=true", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE /logs of a read-only server = %d, want 405", code)
+	}
+
+	s.ReadOnly(false)
+	var deleted struct {
+		Deleted int64 `json:"deleted"`
+	}
+	if code := serve(t, s, http.MethodDelete, "/logs?all=true", &deleted); code != http.StatusOK || deleted.Deleted != 1 {
+		t.Errorf("DELETE /logs = %d with %d deleted logs, want 200 with 1", code, deleted.Deleted)
+	}
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/queries"
+)
+
+// stream defaults
+const (
+	defaultStreamInterval = time.Second      // how often the database is polled for new logs
+	keepAliveInterval     = 15 * time.Second // how often a comment is sent to the idle streams
+)
+
+// StreamInterval sets how often GET /stream polls the database for the new logs (1 second by default)
+func (s *Server) StreamInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultStreamInterval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = interval
+}
+
+// handleStream serves GET /stream: the new logs matching the filters of the request are pushed
+// to the client as Server-Sent Events ("log" events with the log as JSON data) while the connection is open
+// the tail parameter sends the last N matching logs before the new ones
+// Example:
+//
+//	const events = new EventSource("/stream?level=warning");
+//	events.addEventListener("log", (e) => console.log(JSON.parse(e.data)));
+//
+// the database is polled, so the logs written by other processes are streamed too
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("the response doesn't support streaming"))
+		return
+	}
+
+	filters, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tail := 0
+	if v := r.URL.Query().Get("tail"); v != "" {
+		tail, err = strconv.Atoi(v)
+		if err != nil || tail < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid tail %q", v))
+			return
+		}
+		tail = min(tail, maxLimit)
+	}
+
+	// the stream starts after the last log of the database
+	latest, err := s.logger.GetLogsContext(r.Context(), queries.SortID("DESC"), queries.AddLimit(1))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	var last int64
+	if len(latest) > 0 {
+		last = latest[0].ID
+	}
+
+	var backlog []logger.Entry
+	if tail > 0 {
+		backlog, err = s.logger.GetLogsContext(r.Context(), append(filters, queries.SortID("DESC"), queries.AddLimit(tail))...)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for i := len(backlog) - 1; i >= 0; i-- {
+		writeEvent(w, "log", entry{Entry: backlog[i], Level: backlog[i].Level.String()})
+	}
+	flusher.Flush()
+
+	s.mu.RLock()
+	interval := s.interval
+	s.mu.RUnlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	idle := time.Now()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		entries, err := s.logger.GetLogsContext(r.Context(), append(filters, queries.IDGreaterThan(last), queries.SortID("ASC"), queries.AddLimit(maxLimit))...)
+		if err != nil {
+			writeEvent(w, "error", map[string]string{"error": err.Error()})
+			flusher.Flush()
+			return
+		}
+
+		for _, e := range entries {
+			writeEvent(w, "log", entry{Entry: e, Level: e.Level.String()})
+			last = max(last, e.ID)
+		}
+
+		if len(entries) > 0 {
+			idle = time.Now()
+			flusher.Flush()
+		} else if time.Since(idle) >= keepAliveInterval {
+			fmt.Fprint(w, ": keep-alive\n\n")
+			idle = time.Now()
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event with the name passed and the value passed as JSON data
+func writeEvent(w http.ResponseWriter, name string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamUntagged(t *testing.T) {
+	l, s := newTestServer(t)
+	s.StreamInterval(10 * time.Millisecond)
+	l.Info("before")
+
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/stream?tail=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /stream = %v", err)
+	}
+	defer resp.Body.Close()
+
+	// the new log is written once the backlog is received, so it is after the start of the stream
+	var messages []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && len(messages) < 2 {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		messages = append(messages, data)
+		if len(messages) == 1 {
+			l.Info("after")
+		}
+	}
+
+	if len(messages) != 2 || !strings.Contains(messages[0], `"before"`) || !strings.Contains(messages[1], `"after"`) {
+		t.Errorf("GET /stream events = %q, want the untagged logs before and after", messages)
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+)
+
+// index is the single-page web UI served at the root of the server
+//
+//go:embed ui/index.html
+var index []byte
+
+// handleUI serves the web UI at GET /: it lists the logs with the filters of the API,
+// loads the next pages while scrolling and streams the new logs in live mode
+// the UI calls the API with relative URLs, so the server can be mounted under a prefix
+// (e.g. http.StripPrefix("/logs", server.New(log)) at "/logs/")
+func (s *Server) handleUI(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%s not found", r.URL.Path))
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(index)
+}
@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// Sink is a destination of the logs created by the logger (with Log, Info, Error, ...)
+// in addition to its store, every sink has its own minimum level and tags (see AddSink),
+// so e.g. the debug logs go only to the console while the errors go to the database and to Slack
+// the sink receives the log after it is stored, with its id (0 if the store didn't receive it)
+// The package provides the following sinks:
+//   - ConsoleSink: prints the logs in the console with the options of the logger
+//   - StoreSink: writes the logs in another store
+//   - HookSink: passes the logs to the AfterWrite method of a hook (e.g. the loki and otlp sub-packages)
+//   - NotifierSink: sends the logs to a notifier one by one (e.g. WebhookNotifier)
+type Sink interface {
+	Write(entry Entry) error
+}
+
+// SinkFunc is a function that implements the Sink interface
+type SinkFunc func(entry Entry) error
+
+// Write calls the function with the entry passed
+func (f SinkFunc) Write(entry Entry) error {
+	return f(entry)
+}
+
+// route is a sink with the filter of the logs it receives
+type route struct {
+	sink   Sink
+	level  LogLevel // the minimum level of the logs
+	tags   []string // the logs must have at least one of the tags, every log if empty
+	config bool     // if true the sink was added by the configuration file, see LoadConfig
+}
+
+// match reports if the log passed must be written in the sink of the route
+func (r route) match(l *log) bool {
+	if l.level < r.level {
+		return false
+	}
+
+	if len(r.tags) == 0 {
+		return true
+	}
+
+	for _, tag := range l.tags {
+		if slices.Contains(r.tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddSink adds a sink of the logs with the level passed or a higher one, and with at least one
+// of the tags passed (every tag if none is passed), the sinks are written in the order they are added
+// the copies and the children of the logger inherit its sinks
+// Example:
+//
+//	log.StoreFilter(logger.Warning)                                   // the database keeps the warnings and the errors
+//	log.AddSink(logger.ConsoleSink(), logger.Debug)                   // the console shows every log
+//	log.AddSink(logger.NotifierSink(slack), logger.Error, "payments") // Slack receives the payment errors
+func (opts *Logger) AddSink(sink Sink, level LogLevel, tags ...string) {
+	if sink == nil {
+		return
+	}
+
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.sinks = append(opts.sinks, route{sink: sink, level: level, tags: slices.Clone(tags)})
+}
+
+// StoreFilter sets the filter of the logs written in the store of the logger (in the console for the
+// console only loggers): only the logs with the level passed or a higher one, and with at least one
+// of the tags passed (every tag if none is passed), are stored
+// the other logs are still passed to the sinks and to the hooks, with 0 as id
+// unlike Level, that drops the logs for every destination
+func (opts *Logger) StoreFilter(level LogLevel, tags ...string) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.storeRoute = route{level: level, tags: slices.Clone(tags)}
+}
+
+// storeEnabled reports if the log passed must be written in the store of the logger
+func (opts *Logger) storeEnabled(l *log) bool {
+	opts.mu.RLock()
+	defer opts.mu.RUnlock()
+	return opts.storeRoute.match(l)
+}
+
+// OnSinkError sets the handler of the errors of the sinks, the failure of a sink doesn't fail
+// the write of the log: the logging methods return the id of the stored log and no error,
+// the errors are counted as SinkErrors by ReadMetrics and passed to the handler, if any
+// the handler is called by the goroutine writing the log, a nil handler removes it
+// Example:
+//
+//	log.OnSinkError(func(err error) {
+//		fmt.Fprintln(os.Stderr, err)
+//	})
+func (opts *Logger) OnSinkError(handler func(error)) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.sinkErrors = handler
+}
+
+// writeSinks writes the log stored with the id passed in the sinks matching it,
+// the errors of the sinks are counted and passed to the handler set with OnSinkError
+func (opts *Logger) writeSinks(l *log, id int64) {
+	opts.mu.RLock()
+	routes := slices.Clone(opts.sinks)
+	handler := opts.sinkErrors
+	opts.mu.RUnlock()
+
+	for _, r := range routes {
+		if !r.match(l) {
+			continue
+		}
+
+		if _, ok := r.sink.(consoleSink); ok {
+			printLogs(opts.Copy(), []*log{l})
+			continue
+		}
+
+		e := l.entry()
+		e.ID = id
+		if err := r.sink.Write(e); err != nil {
+			metrics.Lock()
+			metrics.sinkErrors++
+			metrics.Unlock()
+			if handler != nil {
+				handler(errors.New("[logger-pkg] failed to write the log in a sink: " + err.Error()))
+			}
+		}
+	}
+}
+
+// consoleSink is the sink printing the logs in the console with the options of the logger
+type consoleSink struct{}
+
+// Write doesn't do anything, the logger prints the logs of the console sink itself
+func (consoleSink) Write(entry Entry) error {
+	return nil
+}
+
+// ConsoleSink returns a sink printing the logs in the console with the options of the logger
+// (format, caller, timestamp, theme, output, ...) as the Print methods do
+func ConsoleSink() Sink {
+	return consoleSink{}
+}
+
+// StoreSink returns a sink writing the logs in the store passed, e.g. a second SQLite database
+// that keeps only the errors for a longer time
+func StoreSink(store Store) Sink {
+	return SinkFunc(func(entry Entry) error {
+		_, err := store.Write(context.Background(), entry)
+		return err
+	})
+}
+
+// HookSink returns a sink passing the logs to the AfterWrite method of the hook passed,
+// so the hooks pushing the logs to external systems (e.g. the loki and otlp sub-packages)
+// can be filtered by tag, the levels of the hook are respected
+func HookSink(hook Hook) Sink {
+	return SinkFunc(func(entry Entry) error {
+		levels := hook.Levels()
+		if len(levels) == 0 || slices.Contains(levels, entry.Level) {
+			hook.AfterWrite(entry)
+		}
+		return nil
+	})
+}
+
+// NotifierSink returns a sink sending every log to the notifier passed, without the aggregation
+// of SetNotifier, e.g. to post the errors of a component to a chat webhook
+func NotifierSink(notifier Notifier) Sink {
+	return SinkFunc(func(entry Entry) error {
+		return notifier.Notify(Notification{Level: entry.Level, Count: 1, Entry: entry})
+	})
+}
@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sort"
+)
+
+// ErrReadOnly is returned when a write operation is requested to a read-only store
+var ErrReadOnly = errors.New("[logger-pkg] the store is read-only")
+
+// snapshotStore is the read-only Store of an exported snapshot
+// the snapshot is materialized in a temporary SQLite database,
+// so it can be queried with the same query options of the live database
+type snapshotStore struct {
+	store  *sqliteStore // the store of the temporary database
+	folder string       // the temporary folder removed when the store is closed
+}
+
+// OpenSnapshot opens the snapshot in the file passed as a read-only Store
+// the snapshot can be one of the following:
+//   - a SQLite database created by this package (e.g. a copy of logs_data.db)
+//   - a JSON export (.json)
+//   - a NDJSON export (.ndjson, .jsonl)
+//   - a gzip-compressed JSON or NDJSON export or archive (.json.gz, .ndjson.gz)
+//
+// the snapshot is copied in a temporary database, so the original file
+// is never modified, and it can be browsed with the same query options,
+// printing and exporting methods of the live database
+// the store must be closed to remove the temporary database
+// Example:
+//
+//	snapshot, err := logger.OpenSnapshot("20240102150405_logs.ndjson")
+//	if err != nil {
+//		return err
+//	}
+//	defer snapshot.Close()
+//
+//	log.SetStore(snapshot)
+//	log.PrintLogs(queries.LevelEqual(logger.Error))
+func OpenSnapshot(path string) (Store, error) {
+	folder, err := os.MkdirTemp("", "logger-snapshot-")
+	if err != nil {
+		return nil, errors.New("[logger-pkg] failed to open the snapshot: " + err.Error())
+	}
+
+	s := &snapshotStore{store: NewSQLiteStore(folder).(*sqliteStore), folder: folder}
+	s.store.compat = true
+	switch exportExt(path) {
+	case ".json", ".ndjson", ".jsonl":
+		err = s.loadExport(path)
+	default:
+		err = s.loadDatabase(path)
+	}
+
+	if err != nil {
+		os.RemoveAll(folder)
+		return nil, errors.New("[logger-pkg] failed to open the snapshot: " + err.Error())
+	}
+
+	return s, nil
+}
+
+// loadExport loads the logs of the JSON export passed in the temporary database
+func (s *snapshotStore) loadExport(path string) error {
+	data, err := readExport(path)
+	if err != nil {
+		return err
+	}
+
+	logs, err := parseJSONLogs(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = importLogs(context.Background(), s.store, logs)
+	return err
+}
+
+// loadDatabase copies the database passed (and its WAL file if any) in the temporary database
+func (s *snapshotStore) loadDatabase(path string) error {
+	err := copyFile(path, s.store.dbPath())
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path + "-wal"); err == nil {
+		return copyFile(path+"-wal", s.store.dbPath()+"-wal")
+	}
+
+	return nil
+}
+
+// copyFile copies the file in the src path to the dst path
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// Write always fails with ErrReadOnly
+func (s *snapshotStore) Write(ctx context.Context, entry Entry) (int64, error) {
+	return 0, ErrReadOnly
+}
+
+// Query returns the entries of the snapshot selected by the query options passed
+func (s *snapshotStore) Query(ctx context.Context, queryOptions ...QueryOption) ([]Entry, error) {
+	return s.store.Query(ctx, queryOptions...)
+}
+
+// Delete always fails with ErrReadOnly
+func (s *snapshotStore) Delete(ctx context.Context, queryOptions ...QueryOption) (int64, error) {
+	return 0, ErrReadOnly
+}
+
+// Close removes the temporary database of the snapshot
+func (s *snapshotStore) Close() error {
+	s.store.Close()
+	return os.RemoveAll(s.folder)
+}
+
+// MultiReader is a read-only Store that queries several stores
+// (e.g. the live database and some exported snapshots) as a single source
+// the query options are applied to every source and the results
+// are merged in chronological order
+// Example:
+//
+//	snapshot, _ := logger.OpenSnapshot("last_month.json")
+//	reader := logger.NewMultiReader(logger.NewSQLiteStore("/var/log/my-app"), snapshot)
+//	defer reader.Close()
+//
+//	log.SetStore(reader)
+//	log.PrintLogs(queries.HasTags("api"))
+type MultiReader struct {
+	sources []Store
+}
+
+// NewMultiReader creates a new MultiReader that reads from the sources passed
+func NewMultiReader(sources ...Store) *MultiReader {
+	return &MultiReader{sources: append(make([]Store, 0, len(sources)), sources...)}
+}
+
+// Add registers the store passed as an additional source of the reader
+// this method is not safe for concurrent use with the queries of the reader
+func (r *MultiReader) Add(source Store) {
+	r.sources = append(r.sources, source)
+}
+
+// Write always fails with ErrReadOnly
+func (r *MultiReader) Write(ctx context.Context, entry Entry) (int64, error) {
+	return 0, ErrReadOnly
+}
+
+// Query returns the entries of all the sources selected by the query options passed
+// the entries are sorted by time, the entries with the same time keep the order of the sources
+func (r *MultiReader) Query(ctx context.Context, queryOptions ...QueryOption) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	for _, source := range r.sources {
+		result, err := source.Query(ctx, queryOptions...)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, result...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
+
+// Delete always fails with ErrReadOnly
+func (r *MultiReader) Delete(ctx context.Context, queryOptions ...QueryOption) (int64, error) {
+	return 0, ErrReadOnly
+}
+
+// Close closes all the sources of the reader
+// it returns the first error encountered
+func (r *MultiReader) Close() error {
+	var firstErr error
+	for _, source := range r.sources {
+		if err := source.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
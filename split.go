@@ -0,0 +1,238 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExportManifest is the index of an export split in more files (see ExportOptions),
+// it is written next to the files as <name>_manifest.json and it is updated after every file,
+// so an interrupted export can be resumed from the last log exported:
+//
+//	m, _ := logger.ReadExportManifest(path)
+//	log.Export(logger.JSON, queries.IDGreaterThan(m.LastID))
+type ExportManifest struct {
+	Created time.Time     `json:"created"` // the time of the export
+	Files   []ExportChunk `json:"files"`   // the files of the export, in the order they were written
+	Count   int           `json:"count"`   // the number of logs exported in the files
+	LastID  int64         `json:"last_id"` // the highest id of the logs exported in the files
+}
+
+// ExportChunk is a file of an export split in more files
+type ExportChunk struct {
+	File    string    `json:"file"`     // the name of the file, in the folder of the manifest
+	Count   int       `json:"count"`    // the number of logs in the file
+	Size    int64     `json:"size"`     // the size of the file in bytes
+	FirstID int64     `json:"first_id"` // the lowest id of the logs in the file
+	LastID  int64     `json:"last_id"`  // the highest id of the logs in the file
+	From    time.Time `json:"from"`     // the time of the oldest log in the file
+	To      time.Time `json:"to"`       // the time of the newest log in the file
+}
+
+// ReadExportManifest reads the manifest of a split export (see ExportOptions) in the file passed
+// Example:
+//
+//	m, err := logger.ReadExportManifest("/var/backups/logs/20240102150405_logs_manifest.json")
+//	for _, f := range m.Files {
+//		fmt.Println(f.File, f.Count)
+//	}
+//
+// this function returns an error if it fails to read or to parse the file
+func ReadExportManifest(path string) (ExportManifest, error) {
+	var m ExportManifest
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, errors.New("[logger-pkg] failed to read the export manifest: " + err.Error())
+	}
+
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return m, errors.New("[logger-pkg] failed to parse the export manifest: " + err.Error())
+	}
+	return m, nil
+}
+
+// split reports if the options split the exports in more files
+func (o ExportOptions) split() bool {
+	return o.SplitByDay || o.MaxRows > 0 || o.MaxSize > 0
+}
+
+// chunk is a group of logs written in the same file of a split export
+type chunk struct {
+	suffix string // the suffix of the name of the file
+	logs   []*log
+}
+
+// chunks groups the logs passed in the files of a split export, by day and then by number of logs
+// and by size, the size of the logs is measured writing them with the write function passed
+func (o ExportOptions) chunks(logs []*log, write func(io.Writer, []*log, string) error, layout string) ([]chunk, error) {
+	days := map[string][]*log{"": logs}
+	keys := []string{""}
+	if o.SplitByDay {
+		days = make(map[string][]*log)
+		keys = keys[:0]
+		for _, l := range logs {
+			day := time.Time(l.timestamp).Format("2006-01-02")
+			if _, ok := days[day]; !ok {
+				keys = append(keys, day)
+			}
+			days[day] = append(days[day], l)
+		}
+		sort.Strings(keys)
+	}
+
+	// the overhead is the size of a file without logs (e.g. the header of the CSV files)
+	var overhead int64
+	if o.MaxSize > 0 {
+		counter := new(countingWriter)
+		if err := write(counter, nil, layout); err != nil {
+			return nil, err
+		}
+		overhead = counter.n
+	}
+
+	var chunks []chunk
+	for _, day := range keys {
+		var parts [][]*log
+		var current []*log
+		size := overhead
+		for _, l := range days[day] {
+			var n int64
+			if o.MaxSize > 0 {
+				counter := new(countingWriter)
+				if err := write(counter, []*log{l}, layout); err != nil {
+					return nil, err
+				}
+				n = counter.n - overhead
+			}
+
+			full := (o.MaxRows > 0 && len(current) >= o.MaxRows) || (o.MaxSize > 0 && size+n > o.MaxSize)
+			if full && len(current) > 0 {
+				parts = append(parts, current)
+				current, size = nil, overhead
+			}
+			current = append(current, l)
+			size += n
+		}
+		if len(current) > 0 {
+			parts = append(parts, current)
+		}
+
+		for i, part := range parts {
+			suffix := day
+			if len(parts) > 1 || day == "" {
+				suffix = strings.TrimPrefix(fmt.Sprintf("%s_%04d", day, i+1), "_")
+			}
+			chunks = append(chunks, chunk{suffix: suffix, logs: part})
+		}
+	}
+	return chunks, nil
+}
+
+// exportChunks writes the logs passed in the files of a split export and their manifest,
+// the files are named as the export file passed followed by the day or by the number of the file
+// (e.g. 20240102150405_logs_2024-01-02.json, 20240102150405_logs_0001.json)
+// it returns the path of the manifest
+func (o ExportOptions) exportChunks(filePath, ext string, logs []*log, write func(io.Writer, []*log, string) error, layout string) (string, error) {
+	chunks, err := o.chunks(logs, write, layout)
+	if err != nil {
+		return "", err
+	}
+
+	if o.Compress {
+		ext += ".gz"
+	}
+	base := strings.TrimSuffix(filePath, ext)
+
+	file, manifestPath, err := o.create(base + "_manifest.json")
+	if err != nil {
+		return "", err
+	}
+	file.Close()
+
+	manifest := ExportManifest{Created: time.Now(), Files: make([]ExportChunk, 0, len(chunks))}
+	err = writeManifest(manifestPath, manifest)
+	if err != nil {
+		return "", err
+	}
+
+	compressedWrite := o.compressed(write)
+	for _, c := range chunks {
+		file, path, err := o.create(base + "_" + c.suffix + ext)
+		if err != nil {
+			return "", err
+		}
+
+		err = compressedWrite(file, c.logs, layout)
+		if err == nil {
+			err = file.Close()
+		}
+		if err != nil {
+			file.Close()
+			os.Remove(path)
+			return "", err
+		}
+
+		info := ExportChunk{File: filepath.Base(path), Count: len(c.logs)}
+		if stat, err := os.Stat(path); err == nil {
+			info.Size = stat.Size()
+		}
+		for i, l := range c.logs {
+			t := time.Time(l.timestamp)
+			if i == 0 || l.id < info.FirstID {
+				info.FirstID = l.id
+			}
+			if l.id > info.LastID {
+				info.LastID = l.id
+			}
+			if i == 0 || t.Before(info.From) {
+				info.From = t
+			}
+			if t.After(info.To) {
+				info.To = t
+			}
+		}
+
+		manifest.Files = append(manifest.Files, info)
+		manifest.Count += info.Count
+		manifest.LastID = max(manifest.LastID, info.LastID)
+		err = writeManifest(manifestPath, manifest)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return manifestPath, nil
+}
+
+// writeManifest writes the manifest passed in the file passed
+func writeManifest(path string, manifest ExportManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return errors.New("[logger-pkg] failed to write the export manifest: " + err.Error())
+	}
+
+	err = os.WriteFile(path, data, 0666)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to write the export manifest: " + err.Error())
+	}
+	return nil
+}
+
+// countingWriter is an io.Writer that counts the bytes written and discards them
+type countingWriter struct {
+	n int64
+}
+
+// Write counts the bytes passed
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,225 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WrapDriver returns a database/sql driver that wraps the driver passed and creates
+// a log in the database tagged with "sql" for every query executed through it
+// the log contains the query and its duration, the successful queries are logged
+// with the Debug level while the failed ones are logged with the Error level
+// and the error message; the query arguments are never logged
+// Example:
+//
+//	sql.Register("sqlite3-logged", log.WrapDriver(&sqlite3.SQLiteDriver{}))
+//	db, err := sql.Open("sqlite3-logged", "app.db")
+func (opts *Logger) WrapDriver(d driver.Driver) driver.Driver {
+	return &loggingDriver{logger: opts, driver: d}
+}
+
+// RegisterSQLDriver registers with the name passed the driver passed wrapped
+// by WrapDriver, so the queries of the databases opened with sql.Open(name, ...)
+// are logged in the same timeline of the application logs
+// like sql.Register it panics if a driver with the same name is already registered
+func (opts *Logger) RegisterSQLDriver(name string, d driver.Driver) {
+	sql.Register(name, opts.WrapDriver(d))
+}
+
+// logQuery creates the log of an executed query
+func (opts *Logger) logQuery(query string, start time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+
+	level := Debug
+	message := fmt.Sprintf("%s (%s)", strings.TrimSpace(query), time.Since(start).Round(time.Microsecond))
+	if err != nil {
+		level = Error
+		message += ": " + err.Error()
+	}
+
+	tags := append(opts.getTags(), "sql")
+	l, logErr := newLog(level, tags, message)
+	if logErr == nil {
+		opts.writeLog(l)
+	}
+}
+
+type loggingDriver struct {
+	logger *Logger
+	driver driver.Driver
+}
+
+func (d *loggingDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &loggingConn{logger: d.logger, conn: conn}, nil
+}
+
+type loggingConn struct {
+	logger *Logger
+	conn   driver.Conn
+}
+
+func (c *loggingConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &loggingStmt{logger: c.logger, stmt: stmt, query: query}, nil
+}
+
+func (c *loggingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	preparer, ok := c.conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+
+	stmt, err := preparer.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &loggingStmt{logger: c.logger, stmt: stmt, query: query}, nil
+}
+
+func (c *loggingConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *loggingConn) Begin() (driver.Tx, error) {
+	return c.conn.Begin()
+}
+
+func (c *loggingConn) BeginTx(ctx context.Context, txOpts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, txOpts)
+	}
+	return c.Begin()
+}
+
+func (c *loggingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	c.logger.logQuery(query, start, err)
+	return result, err
+}
+
+func (c *loggingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	c.logger.logQuery(query, start, err)
+	return rows, err
+}
+
+func (c *loggingConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *loggingConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *loggingConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type loggingStmt struct {
+	logger *Logger
+	stmt   driver.Stmt
+	query  string
+}
+
+func (s *loggingStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *loggingStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *loggingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	result, err := s.stmt.Exec(args)
+	s.logger.logQuery(s.query, start, err)
+	return result, err
+}
+
+func (s *loggingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.stmt.Query(args)
+	s.logger.logQuery(s.query, start, err)
+	return rows, err
+}
+
+func (s *loggingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.stmt.(driver.StmtExecContext)
+	if !ok {
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Exec(values)
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, args)
+	s.logger.logQuery(s.query, start, err)
+	return result, err
+}
+
+func (s *loggingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.stmt.(driver.StmtQueryContext)
+	if !ok {
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Query(values)
+	}
+
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, args)
+	s.logger.logQuery(s.query, start, err)
+	return rows, err
+}
+
+// namedValues converts the named values passed to plain values
+// it fails if any of the values has a name, since the wrapped
+// statement doesn't support named parameters
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, 0, len(args))
+	for _, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("[logger-pkg] the driver does not support named parameters")
+		}
+		values = append(values, arg.Value)
+	}
+	return values, nil
+}
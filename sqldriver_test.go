@@ -0,0 +1,47 @@
+package logger_test
+
+import (
+	"database/sql"
+	"io"
+	"testing"
+
+	"github.com/Tagliapietra96/logger/v2"
+	"github.com/Tagliapietra96/logger/v2/queries"
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestWrapDriverCaller(t *testing.T) {
+	l := logger.New()
+	l.Folder(t.TempDir())
+	l.SetOutput(io.Discard)
+	defer l.Close()
+
+	sql.Register("sqlite3-caller-test", l.WrapDriver(&sqlite3.SQLiteDriver{}))
+	db, err := sql.Open("sqlite3-caller-test", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE users (name TEXT);"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("SELECT name FROM users;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	entries, err := l.GetLogs(queries.HasTags("sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d query logs, want 2", len(entries))
+	}
+	for _, e := range entries {
+		if e.CallerFile != "sqldriver_test.go" {
+			t.Errorf("the log %q has the caller %s:%d (%s), want sqldriver_test.go", e.Message, e.CallerFile, e.CallerLine, e.CallerFunction)
+		}
+	}
+}
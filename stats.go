@@ -0,0 +1,631 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tagliapietra96/tui"
+	"github.com/Tagliapietra96/tui/opts"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sparkBlocks are the characters of the sparklines, from the lowest value to the highest one
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkSteps are the durations of the intervals of the error-rate sparkline of PrintStats,
+// the shortest one that fits the time range of the logs in the width of the output is used
+var sparkSteps = []time.Duration{
+	time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute, time.Hour, 3 * time.Hour,
+	6 * time.Hour, 12 * time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour,
+}
+
+// TimeBucket represents the logs created in an interval of time, counted by level
+type TimeBucket struct {
+	Start  time.Time        `json:"start"`  // the start of the interval, in the location of the logger
+	Counts map[LogLevel]int `json:"counts"` // the number of logs of every level in the interval
+	Total  int              `json:"total"`  // the number of logs in the interval
+}
+
+// MessageCount represents a message of the logs with the number of times it was logged
+type MessageCount struct {
+	Message string    `json:"message"` // the message of the logs
+	Count   int       `json:"count"`   // the number of logs with the message (the merged ones included, see Dedup)
+	Level   LogLevel  `json:"level"`   // the highest level of the logs with the message
+	First   time.Time `json:"first"`   // the time of the oldest log with the message
+	Last    time.Time `json:"last"`    // the time of the newest log with the message
+}
+
+// LogsSummary represents the number of the logs selected by a query, by level and by tag, with their time range
+type LogsSummary struct {
+	Total  int              `json:"total"`  // the number of logs
+	Levels map[LogLevel]int `json:"levels"` // the number of logs of every level
+	Tags   map[string]int   `json:"tags"`   // the number of logs with every tag
+	First  time.Time        `json:"first"`  // the time of the oldest log, zero if there are no logs
+	Last   time.Time        `json:"last"`   // the time of the newest log, zero if there are no logs
+}
+
+// instantMillis is the SQL expression of the unix time in milliseconds of the logs selected by the subquery q
+const instantMillis = `CAST(strftime('%s', CASE WHEN q.timestamp != '' THEN q.timestamp ELSE datetime(q.time, 'utc') END) AS INTEGER) * 1000`
+
+// logsHistogram is the histogram of the logs selected by a query, with the time range of the logs
+type logsHistogram struct {
+	buckets []TimeBucket  // the logs counted by level in intervals of the step
+	first   time.Time     // the time of the oldest log
+	last    time.Time     // the time of the newest log
+	step    time.Duration // the duration of the intervals
+}
+
+// Histogram returns the number of logs of every level selected by the query options passed,
+// in intervals of the duration passed (e.g. an hour), from the oldest log to the newest one
+// the intervals without logs are included, so the buckets can be plotted as they are,
+// and they are aligned to the clock of the location of the logger (e.g. the days start at midnight)
+// the logs merged by Dedup are counted with their occurrences, the SQLite store counts the logs
+// in the database without reading them
+// Example:
+//
+//	buckets, err := log.Histogram(time.Hour, queries.InstantAfter(time.Now().Add(-24*time.Hour)))
+//	for _, b := range buckets {
+//		fmt.Println(b.Start.Format("15:04"), b.Counts[logger.Error])
+//	}
+//
+// this method returns an error if the duration is shorter than a millisecond or if it fails to read the logs
+func (opts *Logger) Histogram(bucket time.Duration, queryOptions ...QueryOption) ([]TimeBucket, error) {
+	if bucket < time.Millisecond {
+		return nil, errors.New("[logger-pkg] the duration of the buckets must be at least a millisecond")
+	}
+
+	h, err := opts.histogram(func(time.Time, time.Time) time.Duration { return bucket }, queryOptions...)
+	return h.buckets, err
+}
+
+// PrintStats prints in the console a summary of the logs selected by the query options passed:
+// the number of logs and their time range, a bar for every level with its number of logs
+// and a sparkline of the error rate (the share of Error and Fatal logs) over time
+// Example:
+//
+//	log.PrintStats(queries.InstantAfter(time.Now().AddDate(0, 0, -7)))
+//
+// the summary uses the colors of the theme of the logger (see SetTheme), the SQLite store counts the logs
+// in the database without reading them (see Histogram)
+// this method returns an error if it fails to read the logs
+func (opts *Logger) PrintStats(queryOptions ...QueryOption) error {
+	cfg := opts.Copy()
+	width := max(outputWidth(cfg, 100)-30, 10)
+	h, err := opts.histogram(func(first, last time.Time) time.Duration {
+		return sparkStep(first, last, width)
+	}, queryOptions...)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(cfg.getOutput(), renderStats(cfg, h))
+	return err
+}
+
+// TopMessages returns the n most frequent messages of the logs selected by the query options passed,
+// from the most frequent one (all the messages if n is not positive)
+// the messages are compared as they are, the logs merged by Dedup are counted with their occurrences
+// the SQLite store groups the messages in the database, unless the messages are encrypted (see EncryptionKey)
+// Example:
+//
+//	// what's been failing this week
+//	top, err := log.TopMessages(10, queries.LevelEqual(logger.Error), queries.InstantAfter(time.Now().AddDate(0, 0, -7)))
+//	for _, m := range top {
+//		fmt.Printf("%5d  %s\n", m.Count, m.Message)
+//	}
+//
+// this method returns an error if it fails to read the logs
+func (opts *Logger) TopMessages(n int, queryOptions ...QueryOption) ([]MessageCount, error) {
+	if s, ok := sqliteStoreOf(opts.getStore()); ok && len(s.readPaths()) == 0 && s.encryption.aead == nil {
+		var messages []MessageCount
+		err := s.retry(context.Background(), func() error {
+			var err error
+			messages, err = selectTopMessages(context.Background(), s, n, opts.getLocation(), queryOptions...)
+			return err
+		})
+		return messages, err
+	}
+
+	// the other stores, the federated databases and the encrypted messages are grouped in memory
+	logs, err := opts.queryLogs(queryOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	messages := make([]MessageCount, 0)
+	for _, l := range logs {
+		t := time.Time(l.timestamp)
+		i, ok := index[l.message]
+		if !ok {
+			index[l.message] = len(messages)
+			messages = append(messages, MessageCount{Message: l.message, Level: l.level, First: t, Last: t})
+			i = len(messages) - 1
+		}
+
+		m := &messages[i]
+		m.Count += l.occurrences()
+		m.Level = max(m.Level, l.level)
+		if t.Before(m.First) {
+			m.First = t
+		}
+		if t.After(m.Last) {
+			m.Last = t
+		}
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		if messages[i].Count != messages[j].Count {
+			return messages[i].Count > messages[j].Count
+		}
+		return messages[i].Last.After(messages[j].Last)
+	})
+
+	if n > 0 && len(messages) > n {
+		messages = messages[:n]
+	}
+	return messages, nil
+}
+
+// Summary returns the number of the logs selected by the query options passed, by level and by tag,
+// with the time range of the logs in the location of the logger
+// the logs merged by Dedup are counted once, the SQLite store counts the logs in the database without reading them
+// Example:
+//
+//	summary, err := log.Summary(queries.InstantAfter(time.Now().Add(-time.Hour)))
+//	fmt.Printf("%d logs, %d errors\n", summary.Total, summary.Levels[logger.Error])
+//
+// this method returns an error if it fails to read the logs
+func (opts *Logger) Summary(queryOptions ...QueryOption) (LogsSummary, error) {
+	return opts.SummaryContext(context.Background(), queryOptions...)
+}
+
+// SummaryContext returns the summary of the logs selected by the query options passed like Summary,
+// the context passed can cancel the query
+// if it fails to read the logs it will return an error, that wraps the error of the context if it is done
+func (opts *Logger) SummaryContext(ctx context.Context, queryOptions ...QueryOption) (LogsSummary, error) {
+	loc := opts.getLocation()
+	if s, ok := sqliteStoreOf(opts.getStore()); ok && len(s.readPaths()) == 0 {
+		var summary LogsSummary
+		err := s.retry(ctx, func() error {
+			var err error
+			summary, err = selectSummary(ctx, s, loc, queryOptions...)
+			return err
+		})
+		if err != nil {
+			return LogsSummary{}, cancelled(ctx, err)
+		}
+		return summary, nil
+	}
+
+	// the other stores and the federated databases are counted in memory
+	logs, err := opts.queryLogsContext(ctx, queryOptions...)
+	if err != nil {
+		return LogsSummary{}, err
+	}
+
+	summary := LogsSummary{Total: len(logs), Levels: make(map[LogLevel]int), Tags: make(map[string]int)}
+	if len(logs) > 0 {
+		summary.First, summary.Last = timeRange(logs)
+	}
+	for _, l := range logs {
+		summary.Levels[l.level]++
+		for _, tag := range l.tags {
+			summary.Tags[tag]++
+		}
+	}
+	return summary, nil
+}
+
+// histogram returns the histogram of the logs selected by the query options passed, in intervals of the
+// duration returned by the step function passed for the time range of the logs
+// the SQLite store counts the logs in the database, the other stores and the federated databases in memory
+func (opts *Logger) histogram(step func(first, last time.Time) time.Duration, queryOptions ...QueryOption) (logsHistogram, error) {
+	loc := opts.getLocation()
+	if s, ok := sqliteStoreOf(opts.getStore()); ok && len(s.readPaths()) == 0 {
+		var h logsHistogram
+		err := s.retry(context.Background(), func() error {
+			var err error
+			h, err = selectHistogram(context.Background(), s, loc, step, queryOptions...)
+			return err
+		})
+		return h, err
+	}
+
+	logs, err := opts.queryLogs(queryOptions...)
+	if err != nil || len(logs) == 0 {
+		return logsHistogram{}, err
+	}
+
+	h := logsHistogram{}
+	h.first, h.last = timeRange(logs)
+	h.step = step(h.first, h.last)
+	h.buckets = emptyBuckets(h.first, h.last, h.step, loc)
+	for _, l := range logs {
+		b := h.bucket(time.Time(l.timestamp), loc)
+		b.Counts[l.level] += l.occurrences()
+		b.Total += l.occurrences()
+	}
+	return h, nil
+}
+
+// selectHistogram returns the histogram of the logs selected by the query options passed counted in the
+// database of the store, grouped by level and by interval: the intervals are aligned to the clock of the
+// location passed with the offsets of its zones in the time range of the logs
+func selectHistogram(ctx context.Context, s *sqliteStore, loc *time.Location, step func(first, last time.Time) time.Duration, configs ...QueryOption) (logsHistogram, error) {
+	db, err := getDBConnection(s)
+	if err != nil {
+		return logsHistogram{}, err
+	}
+	defer releaseDBConnection(s, db)
+
+	millis, query, err := aggregateQuery(ctx, s, db, configs...)
+	if err != nil {
+		return logsHistogram{}, err
+	}
+
+	rangeQuery := fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM (%s) AS q", millis, millis, query)
+	s.logSQL(rangeQuery)
+
+	var first, last sql.NullInt64
+	err = db.QueryRowContext(ctx, rangeQuery+";").Scan(&first, &last)
+	if err != nil {
+		return logsHistogram{}, errors.New("[logger-pkg] failed to count the logs: " + err.Error())
+	}
+	if !first.Valid {
+		return logsHistogram{}, nil
+	}
+
+	h := logsHistogram{first: time.UnixMilli(first.Int64).In(loc), last: time.UnixMilli(last.Int64).In(loc)}
+	h.step = step(h.first, h.last)
+	h.buckets = emptyBuckets(h.first, h.last, h.step, loc)
+
+	countQuery := fmt.Sprintf("SELECT MIN(ms), level, SUM(MAX(count, 1)) FROM (SELECT %s AS ms, q.level AS level, q.count AS count FROM (%s) AS q) GROUP BY (ms + %s) / %d, level",
+		millis, query, zoneOffsets("ms", loc, h.first, h.last), h.step.Milliseconds())
+	s.logSQL(countQuery)
+
+	rows, err := db.QueryContext(ctx, countQuery+";")
+	if err != nil {
+		return logsHistogram{}, errors.New("[logger-pkg] failed to count the logs: " + err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ms int64
+		var level, count int
+		if err := rows.Scan(&ms, &level, &count); err != nil {
+			return logsHistogram{}, errors.New("[logger-pkg] failed to count the logs: " + err.Error())
+		}
+
+		b := h.bucket(time.UnixMilli(ms), loc)
+		b.Counts[LogLevel(level)] += count
+		b.Total += count
+	}
+
+	if err := rows.Err(); err != nil {
+		return logsHistogram{}, errors.New("[logger-pkg] failed to count the logs: " + err.Error())
+	}
+	return h, nil
+}
+
+// selectTopMessages returns the n most frequent messages of the logs selected by the query options passed
+// (all the messages if n is not positive) grouped in the database of the store, the times are in the location passed
+func selectTopMessages(ctx context.Context, s *sqliteStore, n int, loc *time.Location, configs ...QueryOption) ([]MessageCount, error) {
+	db, err := getDBConnection(s)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseDBConnection(s, db)
+
+	millis, query, err := aggregateQuery(ctx, s, db, configs...)
+	if err != nil {
+		return nil, err
+	}
+
+	query = fmt.Sprintf("SELECT message, SUM(MAX(count, 1)) AS occurrences, MAX(level), MIN(ms), MAX(ms) FROM (SELECT q.message AS message, q.count AS count, q.level AS level, %s AS ms FROM (%s) AS q) GROUP BY message ORDER BY occurrences DESC, MAX(ms) DESC",
+		millis, query)
+	if n > 0 {
+		query += fmt.Sprintf(" LIMIT %d", n)
+	}
+	s.logSQL(query)
+
+	rows, err := db.QueryContext(ctx, query+";")
+	if err != nil {
+		return nil, errors.New("[logger-pkg] failed to group the messages: " + err.Error())
+	}
+	defer rows.Close()
+
+	messages := make([]MessageCount, 0)
+	for rows.Next() {
+		var m MessageCount
+		var level int
+		var first, last int64
+		if err := rows.Scan(&m.Message, &m.Count, &level, &first, &last); err != nil {
+			return nil, errors.New("[logger-pkg] failed to group the messages: " + err.Error())
+		}
+
+		m.Level = LogLevel(level)
+		m.First, m.Last = time.UnixMilli(first).In(loc), time.UnixMilli(last).In(loc)
+		messages = append(messages, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("[logger-pkg] failed to group the messages: " + err.Error())
+	}
+	return messages, nil
+}
+
+// selectSummary returns the summary of the logs selected by the query options passed counted in the
+// database of the store, the times are in the location passed
+func selectSummary(ctx context.Context, s *sqliteStore, loc *time.Location, configs ...QueryOption) (LogsSummary, error) {
+	db, err := getDBConnection(s)
+	if err != nil {
+		return LogsSummary{}, err
+	}
+	defer releaseDBConnection(s, db)
+
+	millis, query, err := aggregateQuery(ctx, s, db, configs...)
+	if err != nil {
+		return LogsSummary{}, err
+	}
+
+	summary := LogsSummary{Levels: make(map[LogLevel]int), Tags: make(map[string]int)}
+	rangeQuery := fmt.Sprintf("SELECT COUNT(*), MIN(%s), MAX(%s) FROM (%s) AS q", millis, millis, query)
+	s.logSQL(rangeQuery)
+
+	var first, last sql.NullInt64
+	err = db.QueryRowContext(ctx, rangeQuery+";").Scan(&summary.Total, &first, &last)
+	if err != nil {
+		return LogsSummary{}, errors.New("[logger-pkg] failed to count the logs: " + err.Error())
+	}
+	if !first.Valid {
+		return summary, nil
+	}
+	summary.First, summary.Last = time.UnixMilli(first.Int64).In(loc), time.UnixMilli(last.Int64).In(loc)
+
+	levelsQuery := fmt.Sprintf("SELECT q.level, COUNT(*) FROM (%s) AS q GROUP BY q.level", query)
+	err = countGroups(ctx, s, db, levelsQuery, func(level int64, count int) {
+		summary.Levels[LogLevel(level)] = count
+	})
+	if err != nil {
+		return LogsSummary{}, err
+	}
+
+	tagsQuery := fmt.Sprintf("SELECT tags.name, COUNT(*) FROM (%s) AS q INNER JOIN log_tags ON q.id = log_tags.log_id INNER JOIN tags ON log_tags.tag_id = tags.id GROUP BY tags.name", query)
+	err = countGroups(ctx, s, db, tagsQuery, func(tag string, count int) {
+		summary.Tags[tag] = count
+	})
+	if err != nil {
+		return LogsSummary{}, err
+	}
+	return summary, nil
+}
+
+// countGroups runs the query passed, that selects a key and a number for every group,
+// and calls the function passed with every group
+func countGroups[K any](ctx context.Context, s *sqliteStore, db *sql.DB, query string, fn func(key K, count int)) error {
+	s.logSQL(query)
+	rows, err := db.QueryContext(ctx, query+";")
+	if err != nil {
+		return errors.New("[logger-pkg] failed to count the logs: " + err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key K
+		var count int
+		if err := rows.Scan(&key, &count); err != nil {
+			return errors.New("[logger-pkg] failed to count the logs: " + err.Error())
+		}
+		fn(key, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.New("[logger-pkg] failed to count the logs: " + err.Error())
+	}
+	return nil
+}
+
+// aggregateQuery returns the SQL expression of the unix time in milliseconds of the logs of the subquery q,
+// with the clock offset of the database, and the query of the logs selected by the query options passed,
+// with the saved queries expanded, so the logs can be aggregated without being read
+func aggregateQuery(ctx context.Context, s *sqliteStore, db *sql.DB, configs ...QueryOption) (string, string, error) {
+	offset, err := getClockOffset(ctx, db)
+	if err != nil {
+		return "", "", err
+	}
+
+	query, err := buildQuery(configs...)
+	if err != nil {
+		return "", "", err
+	}
+
+	query, err = expandSavedQueries(ctx, db, query)
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("(%s + %d)", instantMillis, offset.Milliseconds()), query, nil
+}
+
+// zoneOffsets returns the SQL expression of the offset in milliseconds of the location passed at the
+// unix time in milliseconds of the column passed, with the zones of the location between the times passed
+func zoneOffsets(column string, loc *time.Location, first, last time.Time) string {
+	t := first.In(loc)
+	_, offset := t.Zone()
+	var sb strings.Builder
+	for {
+		_, end := t.ZoneBounds()
+		if end.IsZero() || end.After(last) {
+			break
+		}
+
+		sb.WriteString(fmt.Sprintf(" WHEN %s < %d THEN %d", column, end.UnixMilli(), offset*1000))
+		t = end
+		_, offset = t.Zone()
+	}
+
+	if sb.Len() == 0 {
+		return fmt.Sprint(offset * 1000)
+	}
+	return fmt.Sprintf("(CASE%s ELSE %d END)", sb.String(), offset*1000)
+}
+
+// bucket returns the bucket of the histogram containing the time passed, aligned to the clock of the location passed
+// the times in the days shortened by a change of the zone (e.g. daylight saving time) are kept in the last bucket
+func (h logsHistogram) bucket(t time.Time, loc *time.Location) *TimeBucket {
+	i := int(bucketStart(t, h.step, loc).Sub(h.buckets[0].Start) / h.step)
+	return &h.buckets[min(max(i, 0), len(h.buckets)-1)]
+}
+
+// emptyBuckets returns the buckets of the duration passed from the one containing the first time passed
+// to the one containing the last time passed, aligned to the clock of the location passed
+func emptyBuckets(first, last time.Time, bucket time.Duration, loc *time.Location) []TimeBucket {
+	start := bucketStart(first, bucket, loc)
+	buckets := make([]TimeBucket, int(bucketStart(last, bucket, loc).Sub(start)/bucket)+1)
+	for i := range buckets {
+		buckets[i] = TimeBucket{Start: start.Add(time.Duration(i) * bucket), Counts: make(map[LogLevel]int)}
+	}
+	return buckets
+}
+
+// timeRange returns the times of the oldest and of the newest log passed
+func timeRange(logs []*log) (time.Time, time.Time) {
+	first, last := time.Time(logs[0].timestamp), time.Time(logs[0].timestamp)
+	for _, l := range logs {
+		t := time.Time(l.timestamp)
+		if t.Before(first) {
+			first = t
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	return first, last
+}
+
+// bucketStart returns the start of the bucket of the duration passed containing the time passed,
+// the buckets are aligned to the clock of the location passed
+func bucketStart(t time.Time, bucket time.Duration, loc *time.Location) time.Time {
+	t = t.In(loc)
+	_, offset := t.Zone()
+	local := time.Duration(t.UnixNano()) + time.Duration(offset)*time.Second
+	return t.Add(-(local % bucket))
+}
+
+// renderStats returns the summary of the logs counted in the histogram passed printed by PrintStats
+func renderStats(lopts *Logger, h logsHistogram) string {
+	w := outputWidth(lopts, 100)
+	th := lopts.theme.complete()
+	page := tui.NewStyle(opts.Margin(1, 2, 1, 1), opts.Width(w))
+	if len(h.buckets) == 0 {
+		return page.Render(tui.Render("no logs", th.muted())) + "\n"
+	}
+
+	counts := make(map[LogLevel]int)
+	total := 0
+	for _, b := range h.buckets {
+		for level, n := range b.Counts {
+			counts[level] += n
+		}
+		total += b.Total
+	}
+
+	loc := lopts.getLocation()
+	layout := "2006-01-02 15:04"
+	header := tui.Render(fmt.Sprintf("%d logs", total), opts.Bold) + "  " +
+		tui.Render(h.first.In(loc).Format(layout)+" → "+h.last.In(loc).Format(layout), th.muted())
+
+	labelWidth := 0
+	maxCount := 0
+	for level, n := range counts {
+		labelWidth = max(labelWidth, lipgloss.Width(level.String()))
+		maxCount = max(maxCount, n)
+	}
+
+	barWidth := max(w-labelWidth-20, 10)
+	rows := []string{header, ""}
+	for _, level := range Levels() {
+		n := counts[level]
+		if n == 0 && !defaultLevel(level) {
+			continue
+		}
+
+		bar := strings.Repeat("█", n*barWidth/maxCount)
+		if n > 0 && bar == "" {
+			bar = "▏"
+		}
+		rows = append(rows, tui.Render(level.String(), opts.Width(labelWidth+2), opts.Color(th.level(level)))+
+			tui.Render(bar, opts.Color(th.level(level)))+" "+
+			tui.Render(fmt.Sprintf("%d (%.1f%%)", n, float64(n)*100/float64(total)), th.muted()))
+	}
+
+	rows = append(rows, "", errorRateSparkline(h, max(w-30, 10), th))
+	return page.Render(lipgloss.JoinVertical(lipgloss.Left, rows...)) + "\n"
+}
+
+// sparkStep returns the shortest interval of the sparkline that fits the time range passed in the width passed
+func sparkStep(first, last time.Time, width int) time.Duration {
+	for _, s := range sparkSteps {
+		if last.Sub(first)/s < time.Duration(width) {
+			return s
+		}
+	}
+	return sparkSteps[len(sparkSteps)-1]
+}
+
+// errorRateSparkline returns the sparkline of the share of Error and Fatal logs over time
+// of the histogram passed, with the last intervals that fit in the width passed
+func errorRateSparkline(h logsHistogram, width int, th Theme) string {
+	buckets := h.buckets
+	if len(buckets) > width {
+		buckets = buckets[len(buckets)-width:]
+	}
+
+	var spark strings.Builder
+	peak := 0.0
+	for _, b := range buckets {
+		if b.Total == 0 {
+			spark.WriteRune(' ')
+			continue
+		}
+
+		errs := 0
+		for level, n := range b.Counts {
+			if level >= Error {
+				errs += n
+			}
+		}
+		rate := float64(errs) / float64(b.Total)
+		peak = max(peak, rate)
+		spark.WriteRune(sparkBlocks[int(rate*float64(len(sparkBlocks)-1)+0.5)])
+	}
+
+	label := fmt.Sprintf("error rate per %s", formatStep(h.step))
+	return tui.Render(label, th.muted()) + "\n" +
+		tui.Render(spark.String(), opts.Color(th.level(Error))) + " " +
+		tui.Render(fmt.Sprintf("peak %.0f%%", peak*100), th.muted())
+}
+
+// formatStep returns the duration of an interval of the sparkline in a short form (e.g. 15m, 1h, 7d)
+func formatStep(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
+
+// defaultLevel reports if the level passed is one of the levels always shown by PrintStats
+// (Debug, Info, Warning, Error and Fatal), the other ones are shown only when they have logs
+func defaultLevel(level LogLevel) bool {
+	return level >= Debug && level <= Fatal && level%10 == 0
+}
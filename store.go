@@ -0,0 +1,267 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrNotSupported is returned when the operation requested
+// is not supported by the store used by the logger
+var ErrNotSupported = errors.New("[logger-pkg] the operation is not supported by the store")
+
+// Store represents the storage backend of the logger
+// the logger front-end (the log methods, the query options and the printing)
+// works with any Store implementation, the default one is the SQLite store
+// created with NewSQLiteStore
+// The store must implement the following methods:
+//   - Write: stores the entry passed and returns its id (the ID field of the entry is ignored)
+//   - Query: returns the entries selected by the query options passed
+//   - Delete: deletes the entries selected by the query options passed and returns their number
+//   - Close: releases the resources used by the store
+type Store interface {
+	Write(ctx context.Context, entry Entry) (int64, error)
+	Query(ctx context.Context, queryOptions ...QueryOption) ([]Entry, error)
+	Delete(ctx context.Context, queryOptions ...QueryOption) (int64, error)
+	Close() error
+}
+
+// default values of the SQLite store options
+const (
+	defaultBusyTimeout = 5 * time.Second // the default time SQLite waits for a locked database
+	defaultBusyRetries = 3               // the default number of retries of an operation on a locked database
+)
+
+// sqliteStore is the Store implementation that saves the logs
+// in the logs_data.db SQLite database inside its folder
+type sqliteStore struct {
+	folderPath  string        // the folder path of the database file
+	fileName    string        // the name of the database file, if empty logs_data.db is used
+	wal         bool          // if true the database uses the WAL journal mode
+	busyTimeout time.Duration // the time SQLite waits for a locked database before failing
+	busyRetries int           // the number of retries of an operation failed because the database is locked
+	compat      bool          // if true a database newer than the package is opened read-only instead of failing
+	idleTimeout time.Duration // the time the connection is kept open without being used, if 0 it is closed after every operation
+	dedup       time.Duration // the window of the deduplication of the logs, if 0 every log is inserted
+	encryption  encryption    // the encryption of the messages and of the fields, the zero value stores them as they are
+	rotation    rotation      // the rotation of the database files, the file name is the current file when it is enabled
+	sources     []string      // the paths of the other databases read by the queries
+	debug       io.Writer     // the writer of the SQL of the queries (see DebugSQL), nil to disable it
+}
+
+// NewSQLiteStore creates a new SQLite store that saves the logs
+// in the logs_data.db database inside the folder passed
+// the store uses the WAL journal mode, a busy timeout of 5 seconds
+// and retries 3 times the operations failed because the database is locked,
+// so multiple processes and goroutines can write in the same database
+// the connection is kept open between the operations and closed
+// after 5 minutes without operations (it is reopened when needed)
+func NewSQLiteStore(folderPath string) Store {
+	return &sqliteStore{
+		folderPath:  folderPath,
+		wal:         true,
+		busyTimeout: defaultBusyTimeout,
+		busyRetries: defaultBusyRetries,
+		idleTimeout: defaultIdleTimeout,
+	}
+}
+
+// dbPath returns the path of the database file
+func (s *sqliteStore) dbPath() string {
+	if s.fileName == "" {
+		return filepath.Join(s.folderPath, defaultFileName)
+	}
+	return filepath.Join(s.folderPath, s.fileName)
+}
+
+// dsnParams returns the parameters of the connection string of the database
+func (s *sqliteStore) dsnParams() string {
+	journal := "DELETE"
+	if s.wal {
+		journal = "WAL"
+	}
+	return fmt.Sprintf("?_journal_mode=%s&_busy_timeout=%d&_txlock=immediate", journal, s.busyTimeout.Milliseconds())
+}
+
+// retry runs the operation passed retrying it with an exponential backoff
+// while it fails because the database is locked by another connection
+func (s *sqliteStore) retry(ctx context.Context, operation func() error) error {
+	backoff := 50 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := operation()
+		if err == nil || attempt >= s.busyRetries || !isBusy(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isBusy reports whether the error passed was caused by a locked database
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	message := err.Error()
+	return strings.Contains(message, "database is locked") || strings.Contains(message, "database table is locked")
+}
+
+// Write stores the entry passed in the database and returns its id
+func (s *sqliteStore) Write(ctx context.Context, entry Entry) (int64, error) {
+	return s.write(ctx, fromEntry(entry))
+}
+
+// write stores the log passed in the database and returns its id
+func (s *sqliteStore) write(ctx context.Context, l *log) (int64, error) {
+	if time.Time(l.timestamp).IsZero() {
+		l.timestamp = timestamp(time.Now())
+	}
+
+	if l.runID == "" {
+		l.runID = currentRun.id
+	}
+
+	var id int64
+	err := s.retry(ctx, func() error {
+		var err error
+		id, err = createNewLog(ctx, s, l)
+		return err
+	})
+	return id, err
+}
+
+// Query returns the entries selected by the query options passed
+func (s *sqliteStore) Query(ctx context.Context, queryOptions ...QueryOption) ([]Entry, error) {
+	var logs []*log
+	err := s.retry(ctx, func() error {
+		var err error
+		logs, err = selectLogs(ctx, s, queryOptions...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toEntries(logs), nil
+}
+
+// Delete deletes the entries selected by the query options passed
+// and returns the number of deleted entries
+func (s *sqliteStore) Delete(ctx context.Context, queryOptions ...QueryOption) (int64, error) {
+	if s.rotation.policy != NoRotation {
+		var deleted int64
+		for _, rotated := range s.rotatedStores() {
+			n, err := rotated.Delete(ctx, queryOptions...)
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+		return deleted, nil
+	}
+
+	var deleted int64
+	err := s.retry(ctx, func() error {
+		var err error
+		deleted, err = deleteLogs(ctx, s, queryOptions...)
+		return err
+	})
+	return deleted, err
+}
+
+// Close releases the resources used by the store
+func (s *sqliteStore) Close() error {
+	return closeDBConnection(s)
+}
+
+// ErrNoStore is returned when an operation needs the store of a console-only logger
+// (created with NewConsoleOnly), such as querying, exporting or deleting the logs
+var ErrNoStore = errors.New("[logger-pkg] the logger has no store, it prints the logs in the console only")
+
+// noStore is the Store of the console-only loggers, every operation fails with ErrNoStore
+type noStore struct{}
+
+// Write always fails with ErrNoStore
+func (noStore) Write(ctx context.Context, entry Entry) (int64, error) {
+	return 0, ErrNoStore
+}
+
+// Query always fails with ErrNoStore
+func (noStore) Query(ctx context.Context, queryOptions ...QueryOption) ([]Entry, error) {
+	return nil, ErrNoStore
+}
+
+// Delete always fails with ErrNoStore
+func (noStore) Delete(ctx context.Context, queryOptions ...QueryOption) (int64, error) {
+	return 0, ErrNoStore
+}
+
+// Close does nothing, there are no resources to release
+func (noStore) Close() error {
+	return nil
+}
+
+// unsupported returns the error of an operation that the store passed doesn't support
+func unsupported(store Store) error {
+	if _, ok := store.(noStore); ok {
+		return ErrNoStore
+	}
+	return ErrNotSupported
+}
+
+// sqliteStoreOf returns the SQLite database behind the store passed
+// it supports the SQLite store and the snapshots, that are temporary SQLite databases
+func sqliteStoreOf(store Store) (*sqliteStore, bool) {
+	switch s := store.(type) {
+	case *sqliteStore:
+		return s, true
+	case *snapshotStore:
+		return s.store, true
+	default:
+		return nil, false
+	}
+}
+
+// fromEntry returns the log represented by the entry passed
+func fromEntry(e Entry) *log {
+	tags := append(make([]string, 0, len(e.Tags)), e.Tags...)
+	return &log{
+		id:             e.ID,
+		level:          e.Level,
+		tags:           tags,
+		callerFile:     e.CallerFile,
+		callerLine:     e.CallerLine,
+		callerFunction: e.CallerFunction,
+		message:        e.Message,
+		timestamp:      timestamp(e.Time),
+		fields:         copyFields(e.Fields),
+		runID:          e.RunID,
+		hostname:       e.Hostname,
+		pid:            e.PID,
+		goroutine:      e.Goroutine,
+		count:          e.Count,
+		correlation:    e.Correlation,
+	}
+}
+
+// fromEntries returns the logs represented by the entries passed
+func fromEntries(entries []Entry) []*log {
+	logs := make([]*log, 0, len(entries))
+	for _, e := range entries {
+		logs = append(logs, fromEntry(e))
+	}
+	return logs
+}
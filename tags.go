@@ -0,0 +1,265 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// tagSeparator separates the key and the value of the key:value tags
+const tagSeparator = ":"
+
+// KeyValueTag returns the key:value tag with the key and the value passed (e.g. "env:prod"),
+// the key:value tags are dimensions of the logs: they are filtered with queries.TagKeyEquals
+// and queries.HasTagKey and they are printed as key=value after the other tags
+// Example:
+//
+//	log := logger.New("api", logger.KeyValueTag("env", "prod"), "service:billing")
+func KeyValueTag(key, value string) string {
+	return key + tagSeparator + value
+}
+
+// SplitTag returns the key and the value of the key:value tag passed,
+// it returns false if the tag is not a key:value tag
+func SplitTag(tag string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(tag, tagSeparator)
+	if !ok || key == "" {
+		return "", "", false
+	}
+	return key, value, true
+}
+
+// TagValue returns the value of the key:value tag of the entry with the key passed,
+// it returns false if the entry has no tag with the key
+func (e Entry) TagValue(key string) (string, bool) {
+	for _, tag := range e.Tags {
+		if k, v, ok := SplitTag(tag); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// TagCount represents a tag of the database with the number of logs using it
+type TagCount struct {
+	Name  string `json:"name"`  // the name of the tag
+	Count int    `json:"count"` // the number of logs with the tag
+}
+
+// ListTags returns the tags of the database with the number of logs using them,
+// sorted from the most used to the least used (and by name)
+// the tags without logs are included with a count of 0
+// this method returns ErrNotSupported if the store is not a SQLite store or a snapshot
+func (opts *Logger) ListTags() ([]TagCount, error) {
+	s := opts.getStore()
+	store, ok := sqliteStoreOf(s)
+	if !ok {
+		return nil, unsupported(s)
+	}
+
+	var tags []TagCount
+	err := store.retry(context.Background(), func() error {
+		var err error
+		tags, err = listTags(context.Background(), store)
+		return err
+	})
+	return tags, err
+}
+
+// RenameTag renames the tag old to new in every log of the database
+// if the tag new already exists the two tags are merged (see MergeTags)
+// this method returns ErrNotSupported if the store is not a SQLite store
+func (opts *Logger) RenameTag(old, new string) error {
+	return opts.MergeTags(new, old)
+}
+
+// MergeTags moves the logs of the tags from to the tag into and deletes the tags from,
+// the tag into is created if it doesn't exist
+// Example:
+//
+//	log.MergeTags("database", "db", "sql", "sqlite")
+//
+// this method returns ErrNotSupported if the store is not a SQLite store
+func (opts *Logger) MergeTags(into string, from ...string) error {
+	if into == "" {
+		return errors.New("[logger-pkg] the name of the tag can't be empty")
+	}
+
+	s := opts.getStore()
+	store, ok := s.(*sqliteStore)
+	if !ok {
+		return unsupported(s)
+	}
+
+	return store.retry(context.Background(), func() error {
+		return mergeTags(context.Background(), store, into, from)
+	})
+}
+
+// DeleteTag removes the tag passed from every log of the database and deletes it,
+// it returns the number of logs that had the tag
+// Note: the logs left without tags are not returned by the queries
+// this method returns ErrNotSupported if the store is not a SQLite store
+func (opts *Logger) DeleteTag(name string) (int64, error) {
+	s := opts.getStore()
+	store, ok := s.(*sqliteStore)
+	if !ok {
+		return 0, unsupported(s)
+	}
+
+	var removed int64
+	err := store.retry(context.Background(), func() error {
+		var err error
+		removed, err = deleteTag(context.Background(), store, name)
+		return err
+	})
+	return removed, err
+}
+
+// listTags returns the tags of the database with the number of their logs
+func listTags(ctx context.Context, s *sqliteStore) ([]TagCount, error) {
+	db, err := getDBConnection(s)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseDBConnection(s, db)
+
+	rows, err := db.QueryContext(ctx, `SELECT tags.name, COUNT(log_tags.log_id) AS count
+FROM tags LEFT JOIN log_tags ON tags.id = log_tags.tag_id
+GROUP BY tags.id ORDER BY count DESC, tags.name ASC;`)
+	if err != nil {
+		return nil, errors.New("[logger-pkg] failed to list the tags: " + err.Error())
+	}
+	defer rows.Close()
+
+	tags := make([]TagCount, 0)
+	for rows.Next() {
+		var t TagCount
+		if err := rows.Scan(&t.Name, &t.Count); err != nil {
+			return nil, errors.New("[logger-pkg] failed to list the tags: " + err.Error())
+		}
+		tags = append(tags, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("[logger-pkg] failed to list the tags: " + err.Error())
+	}
+	return tags, nil
+}
+
+// mergeTags moves the logs of the tags from to the tag into and deletes the tags from
+func mergeTags(ctx context.Context, s *sqliteStore, into string, from []string) error {
+	names := make([]any, 0, len(from))
+	for _, name := range from {
+		if name != into {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	db, err := getWritableDBConnection(s)
+	if err != nil {
+		return err
+	}
+	defer releaseDBConnection(s, db)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.New("[logger-pkg] failed to merge the tags: " + err.Error())
+	}
+
+	err = execMergeTags(tx, into, names)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("[logger-pkg] failed to merge the tags: " + err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return errors.New("[logger-pkg] failed to merge the tags: " + err.Error())
+	}
+
+	return nil
+}
+
+// execMergeTags runs the statements of mergeTags in the transaction passed
+func execMergeTags(tx *sql.Tx, into string, names []any) error {
+	in := "(?" + strings.Repeat(", ?", len(names)-1) + ")"
+
+	var exists int
+	err := tx.QueryRow("SELECT COUNT(*) FROM tags WHERE name = ?;", into).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists == 0 && len(names) == 1 {
+		// a simple rename keeps the id of the tag
+		_, err = tx.Exec("UPDATE tags SET name = ? WHERE name = ?;", into, names[0])
+		return err
+	}
+
+	_, err = tx.Exec(insertTagQuery, into)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT OR IGNORE INTO log_tags (log_id, tag_id)
+SELECT log_tags.log_id, (SELECT id FROM tags WHERE name = ?) FROM log_tags
+INNER JOIN tags ON log_tags.tag_id = tags.id WHERE tags.name IN `+in+`;`, append([]any{into}, names...)...)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM log_tags WHERE tag_id IN (SELECT id FROM tags WHERE name IN "+in+");", names...)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM tags WHERE name IN "+in+";", names...)
+	return err
+}
+
+// deleteTag removes the tag passed from the logs and deletes it, it returns the number of logs that had the tag
+func deleteTag(ctx context.Context, s *sqliteStore, name string) (int64, error) {
+	db, err := getWritableDBConnection(s)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseDBConnection(s, db)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.New("[logger-pkg] failed to delete the tag: " + err.Error())
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM log_tags WHERE tag_id IN (SELECT id FROM tags WHERE name = ?);", name)
+	if err != nil {
+		tx.Rollback()
+		return 0, errors.New("[logger-pkg] failed to delete the tag: " + err.Error())
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, errors.New("[logger-pkg] failed to delete the tag: " + err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM tags WHERE name = ?;", name)
+	if err != nil {
+		tx.Rollback()
+		return 0, errors.New("[logger-pkg] failed to delete the tag: " + err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return 0, errors.New("[logger-pkg] failed to delete the tag: " + err.Error())
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"github.com/Tagliapietra96/tui"
+	"github.com/Tagliapietra96/tui/opts"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme represents the colors used to print the logs in the console
+// the nil colors are replaced by the default ones, so a theme can override only some of them
+// the custom levels (see SetLevelLabel) are printed with the color of the level below them
+type Theme struct {
+	Trace      lipgloss.TerminalColor // the color of the trace logs
+	Debug      lipgloss.TerminalColor // the color of the debug logs (label, card and legend)
+	Info       lipgloss.TerminalColor // the color of the info logs
+	Notice     lipgloss.TerminalColor // the color of the notice logs
+	Warning    lipgloss.TerminalColor // the color of the warning logs
+	Error      lipgloss.TerminalColor // the color of the error logs
+	Fatal      lipgloss.TerminalColor // the color of the fatal logs
+	Border     lipgloss.TerminalColor // the color of the separators between the inline rows and inside the cards
+	Muted      lipgloss.TerminalColor // the color of the secondary information (timestamp, caller, fields)
+	LightMuted lipgloss.TerminalColor // the color of the tags and of the header row
+}
+
+// DefaultTheme returns the default colors of the package, they adapt to the light
+// and dark backgrounds of the terminal
+func DefaultTheme() Theme {
+	return Theme{
+		Trace:      Trace.color(),
+		Debug:      Debug.color(),
+		Info:       Info.color(),
+		Notice:     Notice.color(),
+		Warning:    Warning.color(),
+		Error:      Error.color(),
+		Fatal:      Fatal.color(),
+		Border:     tui.ColorMuted,
+		Muted:      tui.ColorMuted,
+		LightMuted: tui.ColorLightMuted,
+	}
+}
+
+// SetTheme sets the colors used to print the logs, the nil colors of the theme
+// keep the default ones, passing an empty Theme restores the default colors
+// Example:
+//
+//	log.SetTheme(logger.Theme{
+//		Info:  lipgloss.Color("#0057B8"),
+//		Muted: lipgloss.AdaptiveColor{Light: "238", Dark: "250"},
+//	})
+func (opts *Logger) SetTheme(theme Theme) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	opts.theme = theme
+}
+
+// complete returns the theme with the nil colors replaced by the default ones
+func (t Theme) complete() Theme {
+	d := DefaultTheme()
+	for _, c := range []struct{ color, def *lipgloss.TerminalColor }{
+		{&t.Trace, &d.Trace}, {&t.Debug, &d.Debug}, {&t.Info, &d.Info}, {&t.Notice, &d.Notice},
+		{&t.Warning, &d.Warning}, {&t.Error, &d.Error},
+		{&t.Fatal, &d.Fatal}, {&t.Border, &d.Border}, {&t.Muted, &d.Muted}, {&t.LightMuted, &d.LightMuted},
+	} {
+		if *c.color == nil {
+			*c.color = *c.def
+		}
+	}
+	return t
+}
+
+// level returns the color of the level passed
+func (t Theme) level(level LogLevel) lipgloss.TerminalColor {
+	switch level.base() {
+	case Trace:
+		return t.Trace
+	case Debug:
+		return t.Debug
+	case Info:
+		return t.Info
+	case Notice:
+		return t.Notice
+	case Warning:
+		return t.Warning
+	case Error:
+		return t.Error
+	case Fatal:
+		return t.Fatal
+	default:
+		return t.Muted
+	}
+}
+
+// levelString returns the label of the level passed in its color
+func (t Theme) levelString(level LogLevel) string {
+	return tui.Render(level.String(), opts.Color(t.level(level)))
+}
+
+// muted returns the style option of the secondary information
+func (t Theme) muted() tui.StyleOption {
+	return opts.Color(t.Muted)
+}
+
+// lightMuted returns the style option of the tags and of the header row
+func (t Theme) lightMuted() tui.StyleOption {
+	return opts.Color(t.LightMuted)
+}
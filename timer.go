@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+)
+
+// the fields of the logs created by the timers
+const (
+	operationField = "operation"   // the name of the operation timed
+	durationField  = "duration_ms" // the duration of the operation in milliseconds
+)
+
+// Timer measures the duration of an operation and logs it when the operation is done
+// (see Logger.Start), the timer can be used by a single goroutine
+type Timer struct {
+	logger *Logger
+	name   string
+	start  time.Time
+}
+
+// Start starts a timer of the operation with the name passed, the Done method of the timer
+// creates an Info log with the elapsed duration, stored in the "duration_ms" field
+// as a number, so the slow operations can be found with queries.DurationGreaterThan
+// Example:
+//
+//	t := log.Start("import users")
+//	importUsers()
+//	t.Done() // "import users done in 1.532s"
+//
+//	slow, err := log.GetLogs(queries.DurationGreaterThan(time.Second), queries.SortDuration("DESC"))
+func (opts *Logger) Start(name string) *Timer {
+	return &Timer{logger: opts, name: name, start: time.Now()}
+}
+
+// Elapsed returns the time passed since the timer was started
+func (t *Timer) Elapsed() time.Duration {
+	return time.Since(t.start)
+}
+
+// Done creates an Info log with the duration of the operation in the "duration_ms" field
+// and the name of the operation in the "operation" field
+// this method returns the id of the log and an error if it fails to create the log
+func (t *Timer) Done() (int64, error) {
+	return t.log(Info, nil)
+}
+
+// Fail creates an Error log with the duration of the operation and the error passed,
+// e.g. "import users failed in 1.532s: connection refused", with the fields of Done
+// this method returns the id of the log and an error if it fails to create the log
+func (t *Timer) Fail(err error) (int64, error) {
+	return t.log(Error, err)
+}
+
+// log creates the log of the timer with the level passed
+func (t *Timer) log(level LogLevel, failure error) (int64, error) {
+	elapsed := t.Elapsed()
+
+	message := fmt.Sprintf("%s done in %s", t.name, elapsed.Round(time.Millisecond))
+	if failure != nil {
+		message = fmt.Sprintf("%s failed in %s: %s", t.name, elapsed.Round(time.Millisecond), failure.Error())
+	}
+
+	l, err := newLog(level, t.logger.getTags(), message)
+	if err != nil {
+		return 0, err
+	}
+
+	l.fields = map[string]any{
+		operationField: t.name,
+		durationField:  float64(elapsed.Microseconds()) / 1000,
+	}
+	if failure != nil {
+		for k, v := range errorFields(failure) {
+			l.fields[k] = v
+		}
+	}
+	return t.logger.writeLogID(l)
+}
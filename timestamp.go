@@ -1,14 +1,23 @@
 package logger
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/Tagliapietra96/tui"
-	"github.com/Tagliapietra96/tui/opts"
 )
 
-func newTimestamp(s string) timestamp {
-	t, _ := time.Parse("2006-01-02 15:04:05", s)
+// parseStoredTimestamp returns the timestamp of a stored log
+// the logs created by the newer versions have the timestamp with the offset (RFC3339),
+// the older ones only have the local time without the offset
+func parseStoredTimestamp(localTime, offsetTimestamp string) timestamp {
+	if offsetTimestamp != "" {
+		if t, err := time.Parse(time.RFC3339, offsetTimestamp); err == nil {
+			return timestamp(t)
+		}
+	}
+
+	t, _ := time.ParseInLocation("2006-01-02 15:04:05", localTime, time.Local)
 	return timestamp(t)
 }
 
@@ -18,9 +27,28 @@ func (t timestamp) String() string {
 	return time.Time(t).Format("2006-01-02 15:04:05")
 }
 
-func (t timestamp) toString(level ShowTimestampLevel) string {
+// format returns the timestamp with the layout passed,
+// if the layout is empty the default one (2006-01-02 15:04:05) is used
+func (t timestamp) format(layout string) string {
+	if layout == "" {
+		return t.String()
+	}
+	return time.Time(t).Format(layout)
+}
+
+// rfc3339 returns the timestamp with its offset, the instant it represents is unambiguous
+func (t timestamp) rfc3339() string {
+	return time.Time(t).Format(time.RFC3339)
+}
+
+// toString returns the timestamp in the location passed with the precision of the level passed
+// if the custom layout is not empty it is used instead of the one of the level
+// (the relative times are not affected by the custom layout)
+func (t timestamp) toString(level ShowTimestampLevel, loc *time.Location, custom string, th Theme) string {
 	var layout string
 	switch level {
+	case ShowRelativeTime:
+		return tui.Render(t.relative(time.Now(), loc), th.muted())
 	case ShowDate:
 		layout = "2006-01-02"
 	case ShowDateTime:
@@ -30,7 +58,40 @@ func (t timestamp) toString(level ShowTimestampLevel) string {
 	default:
 		return ""
 	}
-	return tui.Render(time.Time(t).Format(layout), opts.Muted)
+
+	if custom != "" {
+		layout = custom
+	}
+	return tui.Render(time.Time(t).In(loc).Format(layout), th.muted())
+}
+
+// relative returns the time elapsed from the timestamp to now in a short form
+// (30s ago, 3m ago, 2h ago, yesterday, 4d ago), the timestamps older than a week
+// are returned as dates in the location passed
+func (t timestamp) relative(now time.Time, loc *time.Location) string {
+	d := now.Sub(time.Time(t))
+	switch {
+	case d < time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	}
+
+	y, m, dd := time.Time(t).In(loc).Date()
+	ny, nm, nd := now.In(loc).Date()
+	days := int(time.Date(ny, nm, nd, 0, 0, 0, 0, time.UTC).Sub(time.Date(y, m, dd, 0, 0, 0, 0, time.UTC)) / (24 * time.Hour))
+	switch {
+	case days <= 1:
+		return "yesterday"
+	case days < 7:
+		return fmt.Sprintf("%dd ago", days)
+	default:
+		return time.Time(t).In(loc).Format("2006-01-02")
+	}
 }
 
 // ShowTimestampLevel is an enum to define the level of timestamp information to be shown
@@ -39,6 +100,7 @@ func (t timestamp) toString(level ShowTimestampLevel) string {
 //   - ShowDate: show the date 2006-01-02
 //   - ShowDateTime: show the date and time 2006-01-02 15:04:05
 //   - ShowFullTimestamp: show the full timestamp Monday 2006-01-02 15:04:05
+//   - ShowRelativeTime: show the time elapsed since the log (3m ago, 2h ago, yesterday)
 type ShowTimestampLevel int
 
 const (
@@ -46,4 +108,5 @@ const (
 	ShowDate                                    // show the date 2006-01-02
 	ShowDateTime                                // show the date and time 2006-01-02 15:04:05
 	ShowFullTimestamp                           // show the full timestamp Monday 2006-01-02 15:04:05
+	ShowRelativeTime                            // show the time elapsed since the log (3m ago, 2h ago, yesterday)
 )
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// the statements of the overflow of the truncated messages
+const (
+	insertOverflowQuery = "INSERT OR REPLACE INTO log_overflow (log_id, message) VALUES (?, ?);"
+	selectOverflowQuery = "SELECT message FROM log_overflow WHERE log_id = ?;"
+)
+
+// MaxMessageSize sets the maximum size in bytes of the messages of the logs created and printed
+// the longer messages are cut (at a character boundary) and end with a marker like
+// "… [truncated 52340 bytes]", so a huge payload logged by mistake doesn't balloon the database
+// and doesn't wreck the output in the console; a size of 0 disables the limit (default)
+// if the overflow parameter is true the SQLite store keeps the full messages in the
+// log_overflow table, they can be read with FullMessage
+// Example:
+//
+//	log.MaxMessageSize(4096, true)
+//	log.Info(string(body))              // stored with the first 4096 bytes of the body
+//	full, _ := log.FullMessage(id)     // the whole body
+func (opts *Logger) MaxMessageSize(size int, overflow bool) {
+	opts.mu.Lock()
+	defer opts.mu.Unlock()
+	if size < 0 {
+		size = 0
+	}
+	opts.maxMessage = size
+	opts.overflow = overflow && size > 0
+}
+
+// FullMessage returns the whole message of the log with the id passed, also if it was truncated
+// by MaxMessageSize: the message kept in the overflow table or the stored one
+// this method returns ErrNotSupported if the store is not a SQLite store or a snapshot
+func (opts *Logger) FullMessage(id int64) (string, error) {
+	s := opts.getStore()
+	store, ok := sqliteStoreOf(s)
+	if !ok {
+		return "", unsupported(s)
+	}
+
+	db, err := getDBConnection(store)
+	if err != nil {
+		return "", err
+	}
+	defer releaseDBConnection(store, db)
+
+	var message string
+	err = db.QueryRowContext(context.Background(), selectOverflowQuery, id).Scan(&message)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = db.QueryRowContext(context.Background(), "SELECT message FROM logs WHERE id = ?;", id).Scan(&message)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("[logger-pkg] the log %d doesn't exist", id)
+	}
+
+	if err != nil {
+		return "", errors.New("[logger-pkg] failed to read the message: " + err.Error())
+	}
+	return store.encryption.open(message), nil
+}
+
+// truncate cuts the message of the log passed to the maximum size of the logger
+// if keep is true and the logger keeps the overflow, the full message is saved in the log
+func (opts *Logger) truncate(l *log, keep bool) {
+	opts.mu.RLock()
+	size, overflow := opts.maxMessage, opts.overflow
+	opts.mu.RUnlock()
+
+	if size <= 0 || len(l.message) <= size {
+		return
+	}
+
+	if keep && overflow {
+		l.overflow = l.message
+	}
+	l.message = truncateMessage(l.message, size)
+}
+
+// truncateMessage returns the first size bytes of the message passed (without cutting
+// a character) followed by the number of the bytes removed
+func truncateMessage(message string, size int) string {
+	cut := size
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s… [truncated %d bytes]", message[:cut], len(message)-cut)
+}
+
+// insertOverflow saves the full message of the truncated log passed, using the transaction
+// and the encryption passed
+func insertOverflow(tx *sql.Tx, enc encryption, logId int64, l *log) error {
+	if l.overflow == "" {
+		return nil
+	}
+
+	_, err := tx.Exec(insertOverflowQuery, logId, enc.seal(l.overflow))
+	return err
+}
@@ -1,7 +1,7 @@
 package logger
 
 import (
-	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -11,7 +11,24 @@ import (
 	"github.com/charmbracelet/x/term"
 )
 
+// printLogs writes the logs passed to the output of the logger (the standard output by default)
 func printLogs(lopts *Logger, logs []*log) {
+	io.WriteString(lopts.getOutput(), renderLogs(lopts, logs))
+}
+
+// pageLogs writes the logs passed to the output of the logger like printLogs,
+// through the pager if it is enabled (see Logger.Pager)
+func pageLogs(lopts *Logger, logs []*log) {
+	writePaged(lopts, renderLogs(lopts, logs))
+}
+
+// renderLogs returns the logs passed as they are printed by the logger
+// the width of the styled logs is limited by the width of the output, if it is a terminal
+func renderLogs(lopts *Logger, logs []*log) string {
+	if lopts.format != StyledFormat {
+		return formatLines(lopts, logs)
+	}
+
 	var strLogs []string
 	w := 100
 
@@ -19,28 +36,58 @@ func printLogs(lopts *Logger, logs []*log) {
 		w = 130
 	}
 
-	tw, _, err := term.GetSize(os.Stdout.Fd())
-	if tw > 0 && tw < w && err == nil {
-		w = tw - 4
+	w = outputWidth(lopts, w)
+	page := tui.NewStyle(opts.Margin(1, 2, 1, 1), opts.Width(w))
+	groups := groupLogs(lopts, logs)
+	th := lopts.theme.complete()
+	sections := make([]string, 0, len(groups))
+	for _, g := range groups {
+		custom := lopts.renderHooks(w, g.logs)
+		if lopts.inline {
+			strLogs = getInlineLogs(w, lopts, g.logs, custom)
+		} else {
+			strLogs = getBlockLogs(w, lopts, g.logs, custom)
+		}
+
+		if g.title != "" {
+			// the sections are joined by lines, the rows of a section fill its width
+			section := tui.NewStyle(opts.Width(w))
+			tui.Concat(&section, strLogs...)
+			sections = append(sections, lipgloss.JoinVertical(lipgloss.Left, groupHeader(w, g, th), section.String()))
+		}
 	}
 
-	page := tui.NewStyle(opts.Margin(1, 2, 1, 1), opts.Width(w))
-	if lopts.inline {
-		strLogs = getInlineLogs(w, lopts, logs)
-	} else {
-		strLogs = getBlockLogs(w, lopts, logs)
+	if len(sections) > 0 {
+		strLogs = []string{lipgloss.JoinVertical(lipgloss.Left, sections...)}
 	}
 
 	tui.Concat(&page, strLogs...)
-	fmt.Print(page.String())
-	println("")
+	return page.String() + "\n"
 }
 
-func getInlineLogs(w int, lopts *Logger, logs []*log) []string {
-	var lw, tw, cw, tgw, mw int
+// outputWidth returns the width passed limited by the width of the output of the logger, if it is a terminal
+func outputWidth(lopts *Logger, w int) int {
+	if f, ok := lopts.getOutput().(*os.File); ok {
+		tw, _, err := term.GetSize(f.Fd())
+		if tw > 0 && tw < w && err == nil {
+			w = tw - 4
+		}
+	}
+	return w
+}
 
-	if w <= 75 && lopts.showTimestamp == ShowFullTimestamp {
-		lopts.Timestamp(ShowDateTime)
+// getInlineLogs returns the logs as rows of a table, the logs rendered by the hooks
+// (custom, by index) are printed as they are and they don't affect the columns width
+func getInlineLogs(w int, lopts *Logger, logs []*log, custom map[int]string) []string {
+	var lw, tw, cw, tgw, mw int
+	showTimestamp := lopts.showTimestamp
+	showCaller := lopts.showCaller
+	showCallerColumn := showCaller != HideCaller || lopts.showProcess
+	showTags := lopts.showTags
+	th := lopts.theme.complete()
+
+	if w <= 75 && showTimestamp == ShowFullTimestamp {
+		showTimestamp = ShowDateTime
 	}
 
 	levels := make([]string, 0, len(logs))
@@ -49,12 +96,21 @@ func getInlineLogs(w int, lopts *Logger, logs []*log) []string {
 	tags := make([]string, 0, len(logs))
 	messages := make([]string, 0, len(logs))
 
-	for _, log := range logs {
-		level := log.level.toString()
-		timestamp := log.timestamp.toString(lopts.showTimestamp)
-		caller := log.getCaller(lopts.inline, lopts.showCaller)
+	for i, log := range logs {
+		if _, ok := custom[i]; ok {
+			levels = append(levels, "")
+			timestamps = append(timestamps, "")
+			callers = append(callers, "")
+			tags = append(tags, "")
+			messages = append(messages, "")
+			continue
+		}
+
+		level := th.levelString(log.level)
+		timestamp := log.timestamp.toString(showTimestamp, lopts.getLocation(), lopts.timeLayout, th)
+		caller := log.getCallerProcess(lopts.inline, showCaller, lopts.showProcess, th)
 		tag := ""
-		if lopts.showTags && len(log.tags) > 0 {
+		if showTags && len(log.tags) > 0 {
 			tag = strings.Join(log.getTags(), ", ")
 			if tgw < lipgloss.Width(tag)+2 {
 				tgw = lipgloss.Width(tag) + 2
@@ -65,37 +121,43 @@ func getInlineLogs(w int, lopts *Logger, logs []*log) []string {
 			lw = lipgloss.Width(level) + 2
 		}
 
-		if lopts.showTimestamp != HideTimestamp {
+		if showTimestamp != HideTimestamp {
 			if tw < lipgloss.Width(timestamp)+2 {
 				tw = lipgloss.Width(timestamp) + 2
 			}
 		}
 
-		if lopts.showCaller != HideCaller {
+		if showCallerColumn {
 			if cw < lipgloss.Width(caller)+2 {
 				cw = lipgloss.Width(caller) + 2
 			}
 		}
 
-		if mw < lipgloss.Width(log.message)+1 {
-			mw = lipgloss.Width(log.message) + 1
+		message := highlight(log.message, lopts.highlights) + log.getOccurrences(th)
+		if len(log.fields) > 0 {
+			message += "\n" + tui.Render(fieldsString(log.fields), th.muted())
+		}
+
+		if mw < lipgloss.Width(message)+1 {
+			mw = lipgloss.Width(message) + 1
 		}
 
 		levels = append(levels, level)
 		timestamps = append(timestamps, timestamp)
 		callers = append(callers, caller)
 		tags = append(tags, tag)
-		messages = append(messages, log.message)
+		messages = append(messages, message)
 	}
 
 	if w <= 75 {
-		lopts.showTags = false
+		showTags = false
 		mw += tgw
 		tgw = 0
 	}
 
 	if w <= 60 {
-		lopts.Caller(HideCaller)
+		showCaller = HideCaller
+		showCallerColumn = false
 		mw += cw
 		cw = 0
 	}
@@ -106,7 +168,7 @@ func getInlineLogs(w int, lopts *Logger, logs []*log) []string {
 				tw--
 			}
 
-			if lopts.showCaller > ShowCallerLine {
+			if showCaller > ShowCallerLine {
 				if cw > 1 {
 					cw--
 				}
@@ -118,28 +180,56 @@ func getInlineLogs(w int, lopts *Logger, logs []*log) []string {
 		}
 	}
 
-	rows := make([]string, 0, len(logs))
+	// the rows fill the page, so the next row starts on a new line
+	if lw+tw+cw+tgw+mw < w {
+		mw = w - lw - tw - cw - tgw
+	}
+
+	rows := make([]string, 0, len(logs)+2)
+
+	if lopts.showHeader {
+		var ts, tg, cl string
+		if showTimestamp != HideTimestamp {
+			ts = headerCell("TIME", tw, th)
+		}
+
+		if showTags {
+			tg = headerCell("TAGS", tgw, th)
+		}
+
+		if showCallerColumn {
+			cl = headerCell("CALLER", cw, th)
+		}
+
+		header := lipgloss.JoinHorizontal(lipgloss.Top, ts, tg, headerCell("LEVEL", lw, th), cl, headerCell("MESSAGE", mw, th))
+		rows = append(rows, lipgloss.JoinVertical(lipgloss.Left, getLegend(th), header))
+	}
 
 	for i := range len(logs) {
 		var ts, lvl, cl, tg, msg string
-		row := tui.NewStyle(opts.Color(nil, nil, tui.ColorMuted))
-		if i != 0 {
+		row := tui.NewStyle(opts.Color(nil, nil, th.Border))
+		if i != 0 || lopts.showHeader {
 			row = row.Border(lipgloss.NormalBorder(), true, false, false, false)
 		}
 
-		if lopts.showTimestamp != HideTimestamp {
-			ts = tui.Render(timestamps[i], opts.Width(tw), opts.Muted)
+		if s, ok := custom[i]; ok {
+			rows = append(rows, row.Render(tui.Render(s, opts.Width(w))))
+			continue
+		}
+
+		if showTimestamp != HideTimestamp {
+			ts = tui.Render(timestamps[i], opts.Width(tw), th.muted())
 		}
 
-		if lopts.showCaller != HideCaller {
-			cl = tui.Render(callers[i], opts.Width(cw), opts.Muted)
+		if showCallerColumn {
+			cl = tui.Render(callers[i], opts.Width(cw), th.muted())
 		}
 
-		if lopts.showTags {
-			tg = tui.Render(tags[i], opts.Width(tgw), opts.LightMuted)
+		if showTags {
+			tg = tui.Render(tags[i], opts.Width(tgw), th.lightMuted())
 		}
 
-		lvl = tui.Render(levels[i], opts.Width(lw), opts.Color(logs[i].level.color()))
+		lvl = tui.Render(levels[i], opts.Width(lw), opts.Color(th.level(logs[i].level)))
 		msg = tui.Render(messages[i], opts.Width(mw))
 		rows = append(rows, row.Render(lipgloss.JoinHorizontal(lipgloss.Top, ts, tg, lvl, cl, msg)))
 	}
@@ -147,54 +237,153 @@ func getInlineLogs(w int, lopts *Logger, logs []*log) []string {
 	return rows
 }
 
-func getBlockLogs(w int, lopts *Logger, logs []*log) []string {
-	result := make([]string, 0, len(logs))
-	for _, log := range logs {
-		var timestamp, caller, tags string
-		l := tui.NewStyle(opts.Padding(0, 1))
-		l = l.Border(lipgloss.RoundedBorder(), true)
-		tui.Config(&l, opts.FitWidth(w))
-		color := log.level.color()
+// headerCell returns the label of a column of the header row with the width passed
+// the label is truncated if the column is narrower than the label
+func headerCell(label string, w int, th Theme) string {
+	if w <= 0 {
+		return ""
+	}
+
+	if len(label) >= w {
+		label = label[:w-1]
+	}
+	return tui.Render(label, opts.Width(w), opts.Bold, th.lightMuted())
+}
 
-		tui.Config(&l, opts.Color(nil, nil, color))
+// getLegend returns the legend of the colors of the levels
+func getLegend(th Theme) string {
+	levels := Levels()
+	items := make([]string, 0, len(levels))
+	for _, level := range levels {
+		items = append(items, tui.Render("■ ", opts.Color(th.level(level)))+tui.Render(level.String(), th.muted()))
+	}
+	return tui.Render(strings.Join(items, "  "), opts.Padding(0, 0, 1, 0))
+}
 
-		logTitle := tui.NewStyle(opts.Color(nil, nil, tui.ColorMuted), opts.Width(w-4)).Border(lipgloss.NormalBorder(), false, false, true, false)
-		level := log.level.toString()
+// getBlockLogs returns the logs as cards, the logs rendered by the hooks
+// (custom, by index) are printed as they are
+func getBlockLogs(w int, lopts *Logger, logs []*log, custom map[int]string) []string {
+	th := lopts.theme.complete()
+	result := make([]string, 0, len(logs))
+	for i, log := range logs {
+		if s, ok := custom[i]; ok {
+			result = append(result, tui.Render(s, opts.Width(w)))
+			continue
+		}
+
+		var timestamp, caller, tags string
+		color := th.level(log.level)
+		level := th.levelString(log.level)
 
 		if lopts.showTimestamp != HideTimestamp {
-			timestamp = tui.Render(log.timestamp.toString(lopts.showTimestamp), opts.Right)
+			timestamp = tui.Render(log.timestamp.toString(lopts.showTimestamp, lopts.getLocation(), lopts.timeLayout, th), opts.Right)
 		}
 
-		if lopts.showCaller != HideCaller {
-			caller = log.getCaller(lopts.inline, lopts.showCaller)
+		if lopts.showCaller != HideCaller || lopts.showProcess {
+			caller = log.getCallerProcess(lopts.inline, lopts.showCaller, lopts.showProcess, th)
 		}
 
 		if lopts.showTags && len(log.tags) > 0 {
 			tags = tui.Render(strings.Join(log.getTags(), " ･ "))
 		}
 
-		var titlefirtsRow, titleSecondRow string
-		if w-4-lipgloss.Width(level)-lipgloss.Width(timestamp) > 0 {
-			titlefirtsRow = lipgloss.JoinHorizontal(lipgloss.Top, level, lipgloss.PlaceHorizontal(w-4-lipgloss.Width(level)-lipgloss.Width(timestamp), lipgloss.Center, ""), timestamp)
-		} else {
-			titlefirtsRow = level
-			if timestamp != "" {
-				titlefirtsRow += "\n" + timestamp
-			}
+		message := highlight(log.message, lopts.highlights) + log.getOccurrences(th)
+		if len(log.fields) > 0 {
+			message += "\n" + blockFields(log.fields, th)
 		}
 
-		if w-4-lipgloss.Width(caller)-lipgloss.Width(tags) > 0 {
-			titleSecondRow = lipgloss.JoinHorizontal(lipgloss.Top, caller, lipgloss.PlaceHorizontal(w-4-lipgloss.Width(caller)-lipgloss.Width(tags), lipgloss.Center, ""), tags)
-		} else {
-			titleSecondRow = caller + "\n" + tags
+		switch lopts.density {
+		case Compact:
+			result = append(result, getCompactLog(w, message, color, level, timestamp, caller, tags, th))
+		case Dense:
+			result = append(result, getDenseLog(w, message, color, level, timestamp, caller, tags, th))
+		default:
+			result = append(result, getComfortableLog(w, message, color, level, timestamp, caller, tags, th))
+		}
+	}
+
+	return result
+}
+
+// getComfortableLog returns a log as a card with the level and the timestamp in the first row,
+// the caller and the tags in the second one and the message separated by a line
+func getComfortableLog(w int, message string, color lipgloss.TerminalColor, level, timestamp, caller, tags string, th Theme) string {
+	l := tui.NewStyle(opts.Padding(0, 1))
+	l = l.Border(lipgloss.RoundedBorder(), true)
+	tui.Config(&l, opts.FitWidth(w))
+	tui.Config(&l, opts.Color(nil, nil, color))
+
+	logTitle := tui.NewStyle(opts.Color(nil, nil, th.Border), opts.Width(w-4)).Border(lipgloss.NormalBorder(), false, false, true, false)
+
+	var titlefirtsRow, titleSecondRow string
+	if w-4-lipgloss.Width(level)-lipgloss.Width(timestamp) > 0 {
+		titlefirtsRow = lipgloss.JoinHorizontal(lipgloss.Top, level, lipgloss.PlaceHorizontal(w-4-lipgloss.Width(level)-lipgloss.Width(timestamp), lipgloss.Center, ""), timestamp)
+	} else {
+		titlefirtsRow = level
+		if timestamp != "" {
+			titlefirtsRow += "\n" + timestamp
 		}
+	}
 
-		tui.ConcatLn(&logTitle, titlefirtsRow, titleSecondRow)
+	if w-4-lipgloss.Width(caller)-lipgloss.Width(tags) > 0 {
+		titleSecondRow = lipgloss.JoinHorizontal(lipgloss.Top, caller, lipgloss.PlaceHorizontal(w-4-lipgloss.Width(caller)-lipgloss.Width(tags), lipgloss.Center, ""), tags)
+	} else {
+		titleSecondRow = caller + "\n" + tags
+	}
+
+	tui.ConcatLn(&logTitle, titlefirtsRow, titleSecondRow)
+
+	msg := tui.Render(message, opts.Left, opts.Padding(1, 0), opts.Width(w-4))
+	tui.Concat(&l, logTitle.String(), msg)
+	return l.String()
+}
 
-		message := tui.Render(log.message, opts.Left, opts.Padding(1, 0), opts.Width(w-4))
-		tui.Concat(&l, logTitle.String(), message)
-		result = append(result, l.String())
+// getCompactLog returns a log as a card with the level, the caller, the tags and the timestamp
+// in a single row followed by the message, without separators and blank lines
+func getCompactLog(w int, message string, color lipgloss.TerminalColor, level, timestamp, caller, tags string, th Theme) string {
+	l := tui.NewStyle(opts.Padding(0, 1))
+	l = l.Border(lipgloss.RoundedBorder(), true)
+	tui.Config(&l, opts.FitWidth(w))
+	tui.Config(&l, opts.Color(nil, nil, color))
+
+	left := strings.Join(nonEmpty(level, caller, tags), "  ")
+	var title string
+	if w-4-lipgloss.Width(left)-lipgloss.Width(timestamp) > 0 {
+		title = lipgloss.JoinHorizontal(lipgloss.Top, left, lipgloss.PlaceHorizontal(w-4-lipgloss.Width(left)-lipgloss.Width(timestamp), lipgloss.Center, ""), timestamp)
+	} else {
+		title = strings.Join(nonEmpty(left, timestamp), "\n")
+	}
+
+	title = tui.Render(title, opts.Width(w-4), th.muted())
+	msg := tui.Render(message, opts.Left, opts.Width(w-4))
+	tui.ConcatLn(&l, title, msg)
+	return l.String()
+}
+
+// getDenseLog returns a log as a single row with the level, the timestamp, the caller and the tags
+// followed by the message, marked on the left with the color of the level
+func getDenseLog(w int, message string, color lipgloss.TerminalColor, level, timestamp, caller, tags string, th Theme) string {
+	l := tui.NewStyle(opts.Padding(0, 0, 0, 1))
+	l = l.Border(lipgloss.ThickBorder(), false, false, false, true)
+	tui.Config(&l, opts.FitWidth(w))
+	tui.Config(&l, opts.Color(nil, nil, color))
+
+	title := tui.Render(level, opts.Bold, opts.Color(color))
+	if meta := strings.Join(nonEmpty(strings.TrimSpace(timestamp), caller, tags), " · "); meta != "" {
+		title += " " + tui.Render(meta, th.muted())
 	}
 
+	tui.ConcatLn(&l, title, message)
+	return l.String()
+}
+
+// nonEmpty returns the strings passed that are not empty
+func nonEmpty(strs ...string) []string {
+	result := make([]string, 0, len(strs))
+	for _, s := range strs {
+		if s != "" {
+			result = append(result, s)
+		}
+	}
 	return result
 }
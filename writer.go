@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	stdlog "log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stdWriter is the io.Writer that creates a log for every message of the standard log package
+type stdWriter struct {
+	logger *Logger
+	level  LogLevel
+}
+
+// StdWriter returns an io.Writer that creates a log with the level passed
+// for every message written, the trailing newline of the messages is removed
+// it is meant to be the output of the loggers of the standard log package, so the
+// messages of the libraries that use them are saved in the database with the tags of the logger
+// the caller of the logs is the function that called the standard logger
+// Example:
+//
+//	std := log.New(logger.StdWriter(logger.Warning), "", 0)
+//	std.Println("disk almost full") // a warning log with the message "disk almost full"
+//
+// Note: each Write creates one log, use it with writers that write whole messages
+func (opts *Logger) StdWriter(level LogLevel) io.Writer {
+	return &stdWriter{logger: opts, level: level}
+}
+
+// Write creates a log with the message passed, it always returns the length of the message
+// unless the log can't be created
+func (w *stdWriter) Write(p []byte) (int, error) {
+	if !w.level.valid() {
+		return 0, fmt.Errorf("[logger-pkg] invalid log level %d", w.level)
+	}
+
+	message := strings.TrimSuffix(string(p), "\n")
+	l, err := newLog(w.level, w.logger.getTags(), message)
+	if err != nil {
+		return 0, err
+	}
+
+	err = w.logger.writeLog(l)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// RedirectStdLog sets the output of the standard logger (the one used by log.Print, log.Printf, ...)
+// to the logger with the level passed (see StdWriter), so the messages of the standard library
+// and of the third-party libraries that use it are saved in the database
+// the date and time flags of the standard logger are removed, the logs have their own timestamp
+// it returns the function that restores the previous output, flags and prefix
+// Example:
+//
+//	restore := log.RedirectStdLog(logger.Info)
+//	defer restore()
+//	stdlog.Println("server started") // an info log with the message "server started"
+func (opts *Logger) RedirectStdLog(level LogLevel) func() {
+	output := stdlog.Writer()
+	flags := stdlog.Flags()
+	prefix := stdlog.Prefix()
+
+	stdlog.SetOutput(opts.StdWriter(level))
+	stdlog.SetFlags(flags &^ (stdlog.Ldate | stdlog.Ltime | stdlog.Lmicroseconds | stdlog.LUTC))
+
+	return func() {
+		stdlog.SetOutput(output)
+		stdlog.SetFlags(flags)
+		stdlog.SetPrefix(prefix)
+	}
+}
+
+// lineWriter is the io.WriteCloser that creates a log for every line written
+type lineWriter struct {
+	logger *Logger
+	level  LogLevel
+	tags   []string
+	caller log // the caller of Writer, the lines are often written by other goroutines
+	buf    []byte
+	mu     sync.Mutex
+}
+
+// Writer returns an io.WriteCloser that splits the bytes written on the newlines
+// and creates a log with the level passed for every line, the logs have the tags of the logger
+// followed by the tags passed and their caller is the function that called Writer
+// the empty lines are skipped and the last line without a newline is kept until
+// more bytes are written or the writer is closed
+// it is useful to save the output of the subprocesses
+// Example:
+//
+//	w := log.Writer(logger.Info, "ffmpeg")
+//	cmd := exec.Command("ffmpeg", args...)
+//	cmd.Stdout = w
+//	cmd.Stderr = w
+//	err := cmd.Run()
+//	w.Close()
+func (opts *Logger) Writer(level LogLevel, tags ...string) io.WriteCloser {
+	w := &lineWriter{logger: opts, level: level, tags: append(opts.getTags(), tags...)}
+	getCaller(&w.caller)
+	return w
+}
+
+// Write creates a log for every complete line of the bytes written,
+// it returns an error if a log can't be created
+func (w *lineWriter) Write(p []byte) (int, error) {
+	if !w.level.valid() {
+		return 0, fmt.Errorf("[logger-pkg] invalid log level %d", w.level)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := w.buf[:i]
+		w.buf = w.buf[i+1:]
+		if err := w.writeLine(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close creates the log of the last line written without a newline, if any
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	line := w.buf
+	w.buf = nil
+	return w.writeLine(line)
+}
+
+// writeLine creates the log of the line passed, the carriage return at the end of the line is removed
+func (w *lineWriter) writeLine(line []byte) error {
+	message := strings.TrimSuffix(string(line), "\r")
+	if strings.TrimSpace(message) == "" {
+		return nil
+	}
+
+	l := &log{
+		level:          w.level,
+		tags:           w.tags,
+		callerFile:     w.caller.callerFile,
+		callerLine:     w.caller.callerLine,
+		callerFunction: w.caller.callerFunction,
+		callerPath:     w.caller.callerPath,
+		message:        message,
+		timestamp:      timestamp(time.Now()),
+		runID:          currentRun.id,
+	}
+	return w.logger.writeLog(l)
+}